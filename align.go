@@ -0,0 +1,76 @@
+package logftxt
+
+import (
+	"sync"
+)
+
+func newKeyColumns(memory int) *keyColumns {
+	return &keyColumns{
+		memory:  uint64(memory),
+		columns: make(map[string]keyColumn),
+	}
+}
+
+// keyColumns remembers columns of recurring top-level field keys for a limited number of lines.
+// It is shared by all entry encoders of an encoder.
+type keyColumns struct {
+	mu      sync.Mutex
+	memory  uint64
+	line    uint64
+	columns map[string]keyColumn
+}
+
+// nextLine starts a new line and returns its number.
+func (c *keyColumns) nextLine() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.line++
+
+	// Forget all expired keys from time to time, so that map does not grow infinitely.
+	if c.line%c.memory == 0 {
+		for key, column := range c.columns {
+			if c.expired(column) {
+				delete(c.columns, key)
+			}
+		}
+	}
+
+	return c.line
+}
+
+// get returns column of the key remembered from the recent lines or zero if there is no such key.
+func (c *keyColumns) get(key string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	column, ok := c.columns[key]
+	if !ok || c.expired(column) {
+		return 0
+	}
+
+	return column.column
+}
+
+// set remembers column of the key in the given line.
+func (c *keyColumns) set(key string, column int, line uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if old, ok := c.columns[key]; ok && old.line > line {
+		return
+	}
+
+	c.columns[key] = keyColumn{column, line}
+}
+
+func (c *keyColumns) expired(column keyColumn) bool {
+	return c.line-column.line > c.memory
+}
+
+// ---
+
+type keyColumn struct {
+	column int
+	line   uint64
+}
@@ -3,8 +3,10 @@ package logftxt
 
 import (
 	"io"
+	"os"
 
 	"github.com/ssgreg/logf"
+	"golang.org/x/term"
 
 	"github.com/pamburus/ansitty"
 )
@@ -12,8 +14,25 @@ import (
 // NewAppender returns a new logf.Appender with the given Writer and
 // optional custom configuration.
 func NewAppender(w io.Writer, options ...AppenderOption) logf.Appender {
-	o := defaultAppenderOptions().With(options)
-	o.color = o.color.resolved(o.env)
+	o := defaultAppenderOptions().With(options).resolved(w)
+
+	return logf.NewWriteAppender(w, newLogfEncoder(o.encoderOptions))
+}
+
+// NewWriterEncoder returns a new logf.Encoder configured for output to the given Writer the same way as NewAppender does it,
+// for example the line width is detected if the writer is a terminal.
+//
+// It is useful for adapters of other logging libraries that encode entries with logftxt but write them on their own.
+func NewWriterEncoder(w io.Writer, options ...AppenderOption) logf.Encoder {
+	o := defaultAppenderOptions().With(options).resolved(w)
+
+	return newLogfEncoder(o.encoderOptions)
+}
+
+// ---
+
+func (o appenderOptions) resolved(w io.Writer) appenderOptions {
+	o.color = o.markup.colorSetting(o.color).resolved(o.env)
 
 	if o.color == ColorAuto {
 		if ansitty.Enable(w) {
@@ -21,5 +40,27 @@ func NewAppender(w io.Writer, options ...AppenderOption) logf.Appender {
 		}
 	}
 
-	return logf.NewWriteAppender(w, newEncoder(o.encoderOptions))
+	o.terminalWidth = terminalWidth(w)
+
+	return o
+}
+
+// terminalWidth returns width of the terminal w refers to or zero if w is not a terminal.
+func terminalWidth(w io.Writer) int {
+	f, ok := w.(*os.File)
+	if !ok {
+		return 0
+	}
+
+	fd := int(f.Fd()) //nolint:gosec // G115: file descriptors fit into int
+	if !term.IsTerminal(fd) {
+		return 0
+	}
+
+	width, _, err := term.GetSize(fd)
+	if err != nil {
+		return 0
+	}
+
+	return width
 }
@@ -46,4 +46,11 @@ func TestAppender(tt *testing.T) {
 			})
 		})
 	})
+
+	t.Run("WriterEncoder", func(t tst.Test) {
+		buf := logf.NewBuffer()
+		enc := NewWriterEncoder(logf.NewBuffer(), envColor("never"), theme, config)
+		t.Expect(enc.Encode(buf, logf.Entry{Text: "msg"})).ToSucceed()
+		t.Expect(buf.String()).ToEqual("Jan  1 00:00:00.000 |ERR| msg\n")
+	})
 }
@@ -1,7 +1,10 @@
 package logftxt
 
 import (
+	"bytes"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/ssgreg/logf"
 )
@@ -23,9 +26,10 @@ func (f CallerEncodeFunc) toAppenderOptions(o *appenderOptions) {
 
 // CallerShort returns a CallerEncodeFunc that encodes caller
 // keeping only package name, base filename and line number.
+// Control characters in the filename are escaped.
 func CallerShort() CallerEncodeFunc {
 	return func(buf []byte, c logf.EntryCaller) []byte {
-		buf = append(buf, c.FileWithPackage()...)
+		buf = appendCallerFile(buf, c.FileWithPackage())
 		buf = append(buf, ':')
 		buf = strconv.AppendInt(buf, int64(c.Line), 10)
 
@@ -34,9 +38,10 @@ func CallerShort() CallerEncodeFunc {
 }
 
 // CallerLong returns a CallerEncodeFunc that encodes caller keeping full file path and line number.
+// Control characters in the file path are escaped.
 func CallerLong() CallerEncodeFunc {
 	return func(buf []byte, c logf.EntryCaller) []byte {
-		buf = append(buf, c.File...)
+		buf = appendCallerFile(buf, c.File)
 		buf = append(buf, ':')
 		buf = strconv.AppendInt(buf, int64(c.Line), 10)
 
@@ -44,6 +49,131 @@ func CallerLong() CallerEncodeFunc {
 	}
 }
 
+// CallerHyperlink returns a CallerEncodeFunc that encodes caller the same way as [CallerShort]
+// and wraps it into an OSC 8 terminal hyperlink.
+//
+// Target of the link is made from urlTemplate by replacing `{path}` placeholder with an absolute
+// file path using forward slashes and starting with a slash, and `{line}` placeholder with a line number.
+// For example, `vscode://file{path}:{line}` opens the file in Visual Studio Code.
+// A slash preceding `{path}` in urlTemplate is not duplicated, so `vscode://file/{path}:{line}` works the same way,
+// while `file://{path}` still gets the slash making it `file:///path`.
+// Empty urlTemplate means `file://{path}`.
+//
+// Unlike [CallerFormatHyperlink], the returned function does not check whether colors are enabled
+// or the terminal supports hyperlinks.
+func CallerHyperlink(urlTemplate string) CallerEncodeFunc {
+	if urlTemplate == "" {
+		urlTemplate = defaultCallerURLTemplate
+	}
+
+	short := CallerShort()
+
+	return func(buf []byte, c logf.EntryCaller) []byte {
+		buf = append(buf, osc8Begin...)
+		buf = appendCallerURL(buf, urlTemplate, c)
+		buf = append(buf, osc8Terminator...)
+		buf = short(buf, c)
+		buf = append(buf, osc8Begin...)
+		buf = append(buf, osc8Terminator...)
+
+		return buf
+	}
+}
+
+// appendCallerFile appends the file path escaping control characters,
+// so that the result cannot contain escape sequences other than those added by the caller encode function.
+func appendCallerFile(buf []byte, file string) []byte {
+	p := 0
+
+	for i := 0; i != len(file); i++ {
+		if c := file[i]; c < 0x20 || c == 0x7f {
+			buf = append(buf, file[p:i]...)
+			buf = append(buf, `\u00`...)
+			buf = append(buf, hex[c>>4], hex[c&0xf])
+			p = i + 1
+		}
+	}
+
+	return append(buf, file[p:]...)
+}
+
+func appendCallerURL(buf []byte, urlTemplate string, c logf.EntryCaller) []byte {
+	for urlTemplate != "" {
+		i := strings.IndexByte(urlTemplate, '{')
+		if i < 0 {
+			return append(buf, urlTemplate...)
+		}
+
+		buf = append(buf, urlTemplate[:i]...)
+		urlTemplate = urlTemplate[i:]
+
+		switch {
+		case strings.HasPrefix(urlTemplate, callerURLPath):
+			path := callerAbsPath(c.File)
+			if bytes.HasSuffix(buf, []byte("/")) && !bytes.HasSuffix(buf, []byte("//")) {
+				// Template already has a slash before the path, like in `vscode://file/{path}`.
+				path = path[1:]
+			}
+
+			buf = appendURLPath(buf, path)
+			urlTemplate = urlTemplate[len(callerURLPath):]
+		case strings.HasPrefix(urlTemplate, callerURLLine):
+			buf = strconv.AppendInt(buf, int64(c.Line), 10)
+			urlTemplate = urlTemplate[len(callerURLLine):]
+		default:
+			buf = append(buf, '{')
+			urlTemplate = urlTemplate[1:]
+		}
+	}
+
+	return buf
+}
+
+func callerAbsPath(file string) string {
+	if !filepath.IsAbs(file) {
+		if abs, err := filepath.Abs(file); err == nil {
+			file = abs
+		}
+	}
+
+	file = filepath.ToSlash(file)
+	if !strings.HasPrefix(file, "/") {
+		file = "/" + file
+	}
+
+	return file
+}
+
+// appendURLPath appends path escaping all characters that are not allowed in a URL path.
+// Control characters are escaped as well, so the result is safe to use inside escape sequences.
+func appendURLPath(buf []byte, path string) []byte {
+	for i := 0; i != len(path); i++ {
+		c := path[i]
+
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+			buf = append(buf, c)
+		case strings.IndexByte("-._~/:@!$&'()*+,;=", c) >= 0:
+			buf = append(buf, c)
+		default:
+			buf = append(buf, '%', hexUpper[c>>4], hexUpper[c&0xf])
+		}
+	}
+
+	return buf
+}
+
+// ---
+
+const (
+	osc8Begin                = "\x1b]8;;"
+	osc8Terminator           = "\x1b\\"
+	callerURLPath            = "{path}"
+	callerURLLine            = "{line}"
+	defaultCallerURLTemplate = "file://" + callerURLPath
+	hexUpper                 = "0123456789ABCDEF"
+)
+
 // ---
 
 var (
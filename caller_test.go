@@ -31,6 +31,25 @@ func TestCaller(tt *testing.T) {
 		)
 	})
 
+	t.Run("ControlCharacters", func(t tst.Test) {
+		caller := logf.EntryCaller{File: "/src/pkg/a\x1b]8;;x\x07.go", Line: 7, Specified: true}
+		t.Expect(string(CallerShort()(nil, caller))).ToEqual(`pkg/a\u001b]8;;x\u0007.go:7`)
+		t.Expect(string(CallerLong()(nil, caller))).ToEqual(`/src/pkg/a\u001b]8;;x\u0007.go:7`)
+	})
+
+	t.Run("Hyperlink", func(t tst.Test) {
+		caller := logf.EntryCaller{File: "/src/my pkg/test.go", Line: 42, Specified: true}
+		link := func(url string) string {
+			return "\x1b]8;;" + url + "\x1b\\my pkg/test.go:42\x1b]8;;\x1b\\"
+		}
+
+		t.Expect(string(CallerHyperlink("")(nil, caller))).ToEqual(link("file:///src/my%20pkg/test.go"))
+		t.Expect(string(CallerHyperlink("vscode://file{path}:{line}")(nil, caller))).ToEqual(link("vscode://file/src/my%20pkg/test.go:42"))
+		t.Expect(string(CallerHyperlink("vscode://file/{path}:{line}")(nil, caller))).ToEqual(link("vscode://file/src/my%20pkg/test.go:42"))
+		t.Expect(string(CallerHyperlink("file://{path}")(nil, caller))).ToEqual(link("file:///src/my%20pkg/test.go"))
+		t.Expect(string(CallerHyperlink("x://{p}/{line}{")(nil, caller))).ToEqual(link("x://{p}/42{"))
+	})
+
 	t.Run("Options", func(t tst.Test) {
 		f := CallerShort()
 		t.Expect(encoderOptions{}.With([]EncoderOption{f}).encodeCaller).ToNot(tst.BeZero())
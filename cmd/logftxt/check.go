@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/pamburus/logftxt"
+)
+
+func runCheck(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("logftxt check", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: logftxt check [file ...]\n\n")
+		fmt.Fprintf(flags.Output(), "Checks theme and configuration files and reports problems with their positions.\n")
+		fmt.Fprintf(flags.Output(), "Files having `theme` section are checked as themes, other files are checked as configuration.\n")
+	}
+
+	err := flags.Parse(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+
+		return 2
+	}
+
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	status := 0
+
+	for _, file := range files {
+		issues, err := checkFile(file, stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "logftxt: %v\n", err)
+
+			status = 1
+
+			continue
+		}
+
+		for _, issue := range issues {
+			fmt.Fprintf(stdout, "%s:%s\n", file, issue)
+
+			if issue.Severity == logftxt.SeverityError {
+				status = 1
+			}
+		}
+	}
+
+	return status
+}
+
+func checkFile(filename string, stdin io.Reader) ([]logftxt.ValidationIssue, error) {
+	var data []byte
+
+	var err error
+
+	if filename == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(filename)
+	}
+
+	if err != nil {
+		return nil, err //nolint:wrapcheck // error already contains file name if any
+	}
+
+	if isTheme(data) {
+		return logftxt.ValidateTheme(bytes.NewReader(data)) //nolint:wrapcheck // reading from memory does not fail
+	}
+
+	return logftxt.ValidateConfig(bytes.NewReader(data)) //nolint:wrapcheck // reading from memory does not fail
+}
+
+// isTheme returns true if the document looks like a theme, i.e. it has a `theme` section
+// unlike configuration that can only reference a theme by name.
+func isTheme(data []byte) bool {
+	var doc struct {
+		Theme yaml.Node `yaml:"theme"`
+	}
+
+	return yaml.Unmarshal(data, &doc) == nil && doc.Theme.Kind == yaml.MappingNode
+}
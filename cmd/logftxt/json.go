@@ -0,0 +1,32 @@
+package main
+
+import (
+	"github.com/ssgreg/logf"
+
+	"github.com/pamburus/logftxt/jsonlog"
+)
+
+// parseJSONEntry parses a single line containing JSON object into a log entry.
+// Well-known keys are mapped to the corresponding entry attributes and all other keys
+// become fields in the same order as they appear in the line.
+func parseJSONEntry(line []byte) (logf.Entry, bool) {
+	object, ok := jsonlog.Parse(line)
+	if !ok {
+		return logf.Entry{}, false
+	}
+
+	return jsonSchema.Entry(object, nil), true
+}
+
+// ---
+
+// jsonSchema describes well-known keys used by logf.JSONEncoder by default.
+var jsonSchema = jsonlog.Schema{
+	TimeKey:    logf.DefaultFieldKeyTime,
+	LevelKey:   logf.DefaultFieldKeyLevel,
+	MessageKey: logf.DefaultFieldKeyMsg,
+	LoggerKey:  logf.DefaultFieldKeyName,
+	CallerKey:  logf.DefaultFieldKeyCaller,
+	ParseTime:  jsonlog.ParseTime,
+	ParseLevel: logf.LevelFromString,
+}
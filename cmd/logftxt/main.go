@@ -0,0 +1,218 @@
+// Command logftxt reads JSON log lines produced by logf.NewJSONEncoder or similar
+// encoders from the standard input or files and writes them in a human-readable
+// text form using logftxt encoder.
+//
+// Usage:
+//
+//	logftxt [flags] [file ...]
+//	logftxt check [file ...]
+//	logftxt preview [flags] [theme ...]
+//
+// Lines that are not JSON objects are passed through unchanged.
+// Theme and configuration are resolved the same way as for logftxt.NewEncoder,
+// so LOGFTXT_THEME and LOGFTXT_CONFIG environment variables are respected.
+//
+// The check command validates theme and configuration files and reports problems
+// like unknown keys or invalid values with their positions in the files.
+//
+// The -markup flag requests HTML or SVG output instead of ANSI escape sequences,
+// that is handy for pasting logs into documents where escape sequences are not supported.
+//
+// The preview command renders sample log messages using the given themes or all built-in themes
+// to see how they look. Themes are rendered one after another, or side by side with the -columns flag
+// splitting the terminal width or the width given with the -width flag between the themes.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"os"
+
+	"github.com/ssgreg/logf"
+
+	"github.com/pamburus/logftxt"
+	"github.com/pamburus/logftxt/internal/pkg/env"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// ---
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) != 0 && args[0] == "check" {
+		return runCheck(args[1:], stdin, stdout, stderr)
+	}
+
+	if len(args) != 0 && args[0] == "preview" {
+		return runPreview(args[1:], stdout, stderr)
+	}
+
+	flags := flag.NewFlagSet("logftxt", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: logftxt [flags] [file ...]\n")
+		fmt.Fprintf(flags.Output(), "       logftxt check [file ...]\n")
+		fmt.Fprintf(flags.Output(), "       logftxt preview [flags] [theme ...]\n\n")
+		fmt.Fprintf(flags.Output(), "Converts JSON log lines to human-readable text, other lines are passed through unchanged.\n\n")
+		flags.PrintDefaults()
+	}
+
+	color := flags.String("color", string(env.ColorAuto), "whether to use colors: auto, always, never, 16, 256 or truecolor")
+	flatten := flags.Bool("flatten", true, "flatten nested objects")
+	markup := flags.String("markup", markupNone, "markup of the output: none, html or svg")
+
+	err := flags.Parse(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+
+		return 2
+	}
+
+	out, ok := newOutput(*markup, stdout)
+	if !ok {
+		fmt.Fprintf(stderr, "logftxt: invalid markup setting %q\n", *markup)
+
+		return 2
+	}
+
+	colorSetting, ok := parseColorSetting(*color)
+	if !ok {
+		fmt.Fprintf(stderr, "logftxt: invalid color setting %q\n", *color)
+
+		return 2
+	}
+
+	options := append([]logftxt.AppenderOption{out.colorSetting(colorSetting), logftxt.FlattenObjects(*flatten)}, out.options...)
+	c := newConverter(out, logftxt.NewWriterEncoder(out.Writer, options...))
+	c.escapeHTML = out.markup == markupHTML
+
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	status := 0
+
+	for _, file := range files {
+		err := convertFile(c, file, stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "logftxt: %v\n", err)
+
+			status = 1
+		}
+	}
+
+	err = out.Close()
+	if err != nil {
+		fmt.Fprintf(stderr, "logftxt: %v\n", err)
+
+		status = 1
+	}
+
+	return status
+}
+
+func convertFile(c *converter, filename string, stdin io.Reader) error {
+	if filename == "-" {
+		return c.Convert(stdin)
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		return err //nolint:wrapcheck // error already contains file name
+	}
+	defer f.Close()
+
+	err = c.Convert(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+
+	return nil
+}
+
+// parseColorSetting parses the color flag value.
+// Automatic setting is resolved by the encoder using the environment and the output the same way as for logftxt.NewAppender.
+func parseColorSetting(value string) (logftxt.ColorSetting, bool) {
+	switch env.Color(value) {
+	case env.ColorAuto:
+		return logftxt.ColorAuto, true
+	case env.ColorAlways:
+		return logftxt.ColorAlways, true
+	case env.ColorNever:
+		return logftxt.ColorNever, true
+	case env.Color16:
+		return logftxt.Color16, true
+	case env.Color256:
+		return logftxt.Color256, true
+	case env.ColorTrueColor:
+		return logftxt.ColorTrueColor, true
+	default:
+		return logftxt.ColorAuto, false
+	}
+}
+
+// ---
+
+func newConverter(w io.Writer, encoder logf.Encoder) *converter {
+	return &converter{w, encoder, logf.NewBufferWithCapacity(4096), false}
+}
+
+type converter struct {
+	w          io.Writer
+	enc        logf.Encoder
+	buf        *logf.Buffer
+	escapeHTML bool
+}
+
+// Convert reads lines from r and writes each of them to the output
+// either as converted log entry or unchanged if it is not a JSON object.
+func (c *converter) Convert(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLineSize)
+
+	for scanner.Scan() {
+		err := c.convertLine(scanner.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err() //nolint:wrapcheck // error is passed as is from the reader
+}
+
+func (c *converter) convertLine(line []byte) error {
+	c.buf.Reset()
+
+	entry, ok := parseJSONEntry(line)
+	if ok {
+		err := c.enc.Encode(c.buf, entry)
+		if err != nil {
+			return err //nolint:wrapcheck // error is passed as is from the encoder
+		}
+	} else {
+		if c.escapeHTML {
+			c.buf.AppendString(html.EscapeString(string(line)))
+		} else {
+			c.buf.AppendBytes(line)
+		}
+
+		c.buf.AppendByte('\n')
+	}
+
+	_, err := c.w.Write(c.buf.Bytes())
+
+	return err //nolint:wrapcheck // error is passed as is from the writer
+}
+
+// ---
+
+const maxLineSize = 16 * 1024 * 1024
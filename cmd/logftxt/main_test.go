@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ssgreg/logf"
+
+	"github.com/pamburus/go-tst/tst"
+	"github.com/pamburus/logftxt"
+	"github.com/pamburus/logftxt/internal/pkg/pathx"
+	"github.com/pamburus/logftxt/jsonlog"
+)
+
+func TestConverter(tt *testing.T) {
+	t := tst.New(tt)
+
+	config, err := logftxt.LoadConfig("../../encoder_test.config.yml")
+	t.Expect(err).ToNot(tst.HaveOccurred())
+
+	theme := logftxt.NewThemeRef(pathx.ExplicitlyRelative("../../encoder_test.theme.yml"))
+	enc := logftxt.NewEncoder(config, theme, logftxt.ColorNever, logftxt.FlattenObjects(true))
+	someTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	t.Run("JSONEncoder", func(t tst.Test) {
+		entries := []logf.Entry{
+			{
+				Level: logf.LevelDebug,
+				Time:  someTime,
+				Text:  "msg",
+			},
+			{
+				LoggerName: "main.sub",
+				Level:      logf.LevelWarn,
+				Time:       someTime,
+				Text:       "The quick brown fox jumps over a lazy dog",
+				Fields: []logf.Field{
+					logf.String("s", "a b"),
+					logf.Int("i", -42),
+					logf.Float64("f", 4.5),
+					logf.Bool("b", true),
+					logf.Any("z", nil),
+					logf.Strings("a", []string{"x", "y z"}),
+					logf.Object("o", jsonlog.Object{{Key: "x", Value: json.Number("1")}, {Key: "y", Value: jsonlog.Object{{Key: "z", Value: "w"}}}}),
+				},
+				Caller: logf.EntryCaller{File: "pkg/test.go", Line: 42, Specified: true},
+			},
+			{
+				Level: logf.LevelError,
+				Time:  someTime,
+				Text:  "failed",
+			},
+		}
+
+		input := logf.NewBuffer()
+		expected := logf.NewBuffer()
+		jsonEncoder := logf.NewJSONEncoder(logf.JSONEncoderConfig{})
+
+		for _, entry := range entries {
+			t.Expect(jsonEncoder.Encode(input, entry)).ToSucceed()
+			t.Expect(enc.Encode(expected, entry)).ToSucceed()
+		}
+
+		output := &bytes.Buffer{}
+		t.Expect(newConverter(output, enc).Convert(bytes.NewReader(input.Bytes()))).ToSucceed()
+		t.Expect(output.String()).ToEqual(expected.String())
+	})
+
+	t.Run("PassThrough", func(t tst.Test) {
+		input := strings.Join([]string{
+			"plain text",
+			"",
+			`{"broken":`,
+			`{"a":1} trailing`,
+			`["not","an","object"]`,
+		}, "\n") + "\n"
+
+		output := &bytes.Buffer{}
+		t.Expect(newConverter(output, enc).Convert(strings.NewReader(input))).ToSucceed()
+		t.Expect(output.String()).ToEqual(input)
+	})
+
+	t.Run("WellKnownKeys", func(t tst.Test) {
+		test := func(line, expected string) func(tst.Test) {
+			return func(t tst.Test) {
+				t.Helper()
+
+				output := &bytes.Buffer{}
+				t.Expect(newConverter(output, enc).Convert(strings.NewReader(line))).ToSucceed()
+				t.Expect(output.String()).ToEqual(expected + "\n")
+			}
+		}
+
+		t.Run("UnixTime", test(`{"ts":1577934245,"msg":"m"}`, "Jan  2 03:04:05.000 |INF| m"))
+		t.Run("UnixTimeMillis", test(`{"ts":1577934245123,"msg":"m"}`, "Jan  2 03:04:05.123 |INF| m"))
+		t.Run("UnknownLevel", test(`{"ts":1577934245,"level":"fatal","msg":"m"}`, "Jan  2 03:04:05.000 |INF| m level=fatal"))
+		t.Run("NonStringMessage", test(`{"ts":1577934245,"msg":42}`, "Jan  2 03:04:05.000 |INF| msg=42"))
+		t.Run("BadCaller", test(`{"ts":1577934245,"caller":"x"}`, "Jan  2 03:04:05.000 |INF| caller=x"))
+	})
+}
+
+func TestRun(tt *testing.T) {
+	t := tst.New(tt)
+
+	t.Run("PassThrough", func(t tst.Test) {
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		t.Expect(run([]string{"-color", "never"}, strings.NewReader("text\n"), stdout, stderr)).ToEqual(0)
+		t.Expect(stdout.String()).ToEqual("text\n")
+		t.Expect(stderr.String()).ToEqual("")
+	})
+
+	t.Run("InvalidColor", func(t tst.Test) {
+		stderr := &bytes.Buffer{}
+		t.Expect(run([]string{"-color", "sometimes"}, strings.NewReader(""), &bytes.Buffer{}, stderr)).ToEqual(2)
+		t.Expect(stderr.String()).ToEqual("logftxt: invalid color setting \"sometimes\"\n")
+	})
+
+	t.Run("AutoColor", func(t tst.Test) {
+		tt.Setenv("LOGFTXT_COLOR", "")
+		tt.Setenv("NO_COLOR", "")
+
+		stdout := &bytes.Buffer{}
+		t.Expect(run([]string{"-color", "auto"}, strings.NewReader("{\"msg\":\"a\"}\n"), stdout, &bytes.Buffer{})).ToEqual(0)
+		t.Expect(strings.Contains(stdout.String(), "\x1b[")).ToBeFalse()
+
+		tt.Setenv("LOGFTXT_COLOR", "always")
+
+		stdout.Reset()
+		t.Expect(run([]string{"-color", "auto"}, strings.NewReader("{\"msg\":\"a\"}\n"), stdout, &bytes.Buffer{})).ToEqual(0)
+		t.Expect(strings.Contains(stdout.String(), "\x1b[")).ToBeTrue()
+
+		tt.Setenv("LOGFTXT_COLOR", "")
+	})
+
+	t.Run("Markup", func(t tst.Test) {
+		t.Run("HTML", func(t tst.Test) {
+			stdout := &bytes.Buffer{}
+			input := "<text>\n{\"msg\":\"a&b\"}\n"
+			t.Expect(run([]string{"-markup", "html", "-color", "never"}, strings.NewReader(input), stdout, &bytes.Buffer{})).ToEqual(0)
+			t.Expect(strings.HasPrefix(stdout.String(), "&lt;text&gt;\n")).ToBeTrue()
+			t.Expect(strings.HasSuffix(stdout.String(), "a&amp;b\n")).ToBeTrue()
+		})
+
+		t.Run("SVG", func(t tst.Test) {
+			stdout := &bytes.Buffer{}
+			t.Expect(run([]string{"-markup", "svg"}, strings.NewReader("{\"msg\":\"a\"}\n"), stdout, &bytes.Buffer{})).ToEqual(0)
+			t.Expect(strings.HasPrefix(stdout.String(), "<svg ")).ToBeTrue()
+			t.Expect(strings.HasSuffix(stdout.String(), "</svg>\n")).ToBeTrue()
+		})
+
+		t.Run("Invalid", func(t tst.Test) {
+			stderr := &bytes.Buffer{}
+			t.Expect(run([]string{"-markup", "pdf"}, strings.NewReader(""), &bytes.Buffer{}, stderr)).ToEqual(2)
+			t.Expect(stderr.String()).ToEqual("logftxt: invalid markup setting \"pdf\"\n")
+		})
+	})
+
+	t.Run("MissingFile", func(t tst.Test) {
+		stderr := &bytes.Buffer{}
+		t.Expect(run([]string{"-color", "never", "non-existent.log"}, strings.NewReader(""), &bytes.Buffer{}, stderr)).ToEqual(1)
+		t.Expect(stderr.Len() != 0).ToBeTrue()
+	})
+
+	t.Run("Check", func(t tst.Test) {
+		t.Run("Valid", func(t tst.Test) {
+			stdout := &bytes.Buffer{}
+			args := []string{"check", "../../encoder_test.config.yml", "../../assets/theme/fancy.yml"}
+			t.Expect(run(args, strings.NewReader(""), stdout, &bytes.Buffer{})).ToEqual(0)
+			t.Expect(stdout.String()).ToEqual("")
+		})
+
+		t.Run("Theme", func(t tst.Test) {
+			stdout := &bytes.Buffer{}
+			input := "theme:\n  version: '1.0'\n  items: [message]\n  formatting:\n    message: {outer: {style: {foregound: red}}}\n"
+			t.Expect(run([]string{"check"}, strings.NewReader(input), stdout, &bytes.Buffer{})).ToEqual(1)
+			t.Expect(stdout.String()).ToEqual("-:5:31: error: unknown key \"foregound\", did you mean \"foreground\"?\n")
+		})
+
+		t.Run("Config", func(t tst.Test) {
+			stdout := &bytes.Buffer{}
+			t.Expect(run([]string{"check", "-"}, strings.NewReader("caller: {url: x}\n"), stdout, &bytes.Buffer{})).ToEqual(0)
+			t.Expect(stdout.String()).ToEqual("-:1:15: warning: setting is not used because `caller.format` is not `hyperlink`\n")
+		})
+
+		t.Run("MissingFile", func(t tst.Test) {
+			stderr := &bytes.Buffer{}
+			t.Expect(run([]string{"check", "non-existent.yml"}, strings.NewReader(""), &bytes.Buffer{}, stderr)).ToEqual(1)
+			t.Expect(stderr.Len() != 0).ToBeTrue()
+		})
+	})
+
+	t.Run("Preview", func(t tst.Test) {
+		t.Run("BuiltIn", func(t tst.Test) {
+			stdout := &bytes.Buffer{}
+			t.Expect(run([]string{"preview", "-color", "never"}, strings.NewReader(""), stdout, &bytes.Buffer{})).ToEqual(0)
+			t.Expect(strings.HasPrefix(stdout.String(), "@default:\n")).ToBeTrue()
+			t.Expect(strings.Contains(stdout.String(), "\n\n@fancy:\n")).ToBeTrue()
+		})
+
+		t.Run("Files", func(t tst.Test) {
+			stdout := &bytes.Buffer{}
+			args := []string{"preview", "-color", "always", "-mode", "light", "../../assets/theme/tint.yml"}
+			t.Expect(run(args, strings.NewReader(""), stdout, &bytes.Buffer{})).ToEqual(0)
+			t.Expect(strings.HasPrefix(stdout.String(), "../../assets/theme/tint.yml:\n")).ToBeTrue()
+			t.Expect(strings.Count(stdout.String(), "\n")).ToEqual(12)
+		})
+
+		t.Run("SVG", func(t tst.Test) {
+			stdout := &bytes.Buffer{}
+			t.Expect(run([]string{"preview", "-markup", "svg", "@fancy"}, strings.NewReader(""), stdout, &bytes.Buffer{})).ToEqual(0)
+			t.Expect(strings.Count(stdout.String(), "<text ")).ToEqual(12)
+			t.Expect(strings.Contains(stdout.String(), `fill="#cd00cd">DBG</tspan>`)).ToBeTrue()
+		})
+
+		t.Run("Columns", func(t tst.Test) {
+			stdout := &bytes.Buffer{}
+			stderr := &bytes.Buffer{}
+			args := []string{"preview", "-color", "never", "-columns", "2", "-width", "100", "@default", "@unknown", "@fancy"}
+			t.Expect(run(args, strings.NewReader(""), stdout, stderr)).ToEqual(1)
+			t.Expect(strings.HasPrefix(stdout.String(), "@default:"+strings.Repeat(" ", 42)+"@fancy:"+strings.Repeat(" ", 42)+"\n")).ToBeTrue()
+			t.Expect(stderr.String()).ToEqual("logftxt: @unknown: built-in theme \"unknown\" not found\n")
+		})
+
+		t.Run("ColumnsHTML", func(t tst.Test) {
+			stderr := &bytes.Buffer{}
+			t.Expect(run([]string{"preview", "-columns", "2", "-markup", "html"}, strings.NewReader(""), &bytes.Buffer{}, stderr)).ToEqual(2)
+			t.Expect(stderr.String()).ToEqual("logftxt: side by side layout is not supported with html markup\n")
+		})
+
+		t.Run("MissingTheme", func(t tst.Test) {
+			stderr := &bytes.Buffer{}
+			t.Expect(run([]string{"preview", "@unknown"}, strings.NewReader(""), &bytes.Buffer{}, stderr)).ToEqual(1)
+			t.Expect(stderr.String()).ToEqual("logftxt: @unknown: built-in theme \"unknown\" not found\n")
+		})
+
+		t.Run("InvalidMode", func(t tst.Test) {
+			stderr := &bytes.Buffer{}
+			t.Expect(run([]string{"preview", "-mode", "dim"}, strings.NewReader(""), &bytes.Buffer{}, stderr)).ToEqual(2)
+			t.Expect(stderr.String()).ToEqual("logftxt: invalid theme mode \"dim\"\n")
+		})
+	})
+}
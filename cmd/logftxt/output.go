@@ -0,0 +1,57 @@
+package main
+
+import (
+	"io"
+
+	"github.com/pamburus/logftxt"
+)
+
+// Valid values of the markup flag.
+const (
+	markupNone = "none"
+	markupHTML = "html"
+	markupSVG  = "svg"
+)
+
+// newOutput returns output producing the requested markup to w.
+func newOutput(markup string, w io.Writer) (*output, bool) {
+	switch markup {
+	case markupNone:
+		return &output{w, markup, nil, nil}, true
+	case markupHTML:
+		return &output{w, markup, []logftxt.AppenderOption{logftxt.MarkupHTML}, nil}, true
+	case markupSVG:
+		svg := logftxt.NewSVGWriter(w)
+
+		return &output{svg, markup, nil, svg}, true
+	default:
+		return nil, false
+	}
+}
+
+// output is a writer of converted log messages in the requested markup.
+type output struct {
+	io.Writer
+	markup  string
+	options []logftxt.AppenderOption
+	closer  io.Closer
+}
+
+// colorSetting returns color setting to be used with the output instead of s.
+// Colors are enabled by default for SVG output because the escape sequences are rendered by the SVG writer.
+func (o *output) colorSetting(s logftxt.ColorSetting) logftxt.ColorSetting {
+	if o.markup == markupSVG && s == logftxt.ColorAuto {
+		return logftxt.ColorTrueColor
+	}
+
+	return s
+}
+
+// Close finishes the output.
+func (o *output) Close() error {
+	if o.closer == nil {
+		return nil
+	}
+
+	return o.closer.Close() //nolint:wrapcheck // error already has enough context
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+
+	"github.com/pamburus/logftxt"
+	"github.com/pamburus/logftxt/internal/pkg/env"
+)
+
+func runPreview(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("logftxt preview", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: logftxt preview [flags] [theme ...]\n\n")
+		fmt.Fprintf(flags.Output(), "Renders sample log messages using each of the given themes, all built-in themes by default.\n")
+		fmt.Fprintf(flags.Output(), "Built-in themes are referenced with `@` prefix, other themes are loaded from files.\n")
+		fmt.Fprintf(flags.Output(), "Themes are rendered one after another or side by side in the given number of columns.\n\n")
+		flags.PrintDefaults()
+	}
+
+	color := flags.String("color", string(env.ColorAuto), "whether to use colors: auto, always, never, 16, 256 or truecolor")
+	markup := flags.String("markup", markupNone, "markup of the output: none, html or svg")
+	mode := flags.String("mode", "", "theme variant to use: dark or light, detected from the environment by default")
+	columns := flags.Int("columns", 1, "number of themes rendered side by side")
+	width := flags.Int("width", 0, "width of the side by side output, terminal width by default")
+
+	err := flags.Parse(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+
+		return 2
+	}
+
+	out, ok := newOutput(*markup, stdout)
+	if !ok {
+		fmt.Fprintf(stderr, "logftxt: invalid markup setting %q\n", *markup)
+
+		return 2
+	}
+
+	colorSetting, ok := parseColorSetting(*color)
+	if !ok {
+		fmt.Fprintf(stderr, "logftxt: invalid color setting %q\n", *color)
+
+		return 2
+	}
+
+	themeMode := logftxt.ThemeMode(*mode)
+	if themeMode != logftxt.ThemeModeDark && themeMode != logftxt.ThemeModeLight && themeMode != logftxt.ThemeModeAuto {
+		fmt.Fprintf(stderr, "logftxt: invalid theme mode %q\n", *mode)
+
+		return 2
+	}
+
+	if *columns < 1 {
+		fmt.Fprintf(stderr, "logftxt: invalid number of columns %d\n", *columns)
+
+		return 2
+	}
+
+	if *columns > 1 && out.markup == markupHTML {
+		fmt.Fprintf(stderr, "logftxt: side by side layout is not supported with html markup\n")
+
+		return 2
+	}
+
+	themes := flags.Args()
+	if len(themes) == 0 {
+		names, err := logftxt.ListBuiltInThemes()
+		if err != nil {
+			fmt.Fprintf(stderr, "logftxt: %v\n", err)
+
+			return 1
+		}
+
+		for _, name := range names {
+			themes = append(themes, "@"+name)
+		}
+	}
+
+	options := append([]logftxt.AppenderOption{out.colorSetting(colorSetting), themeMode}, out.options...)
+
+	var status int
+	if *columns > 1 {
+		status = previewColumns(themes, *columns, *width, out.Writer, stderr, options...)
+	} else {
+		status = previewThemes(themes, out, stderr, options...)
+	}
+
+	err = out.Close()
+	if err != nil {
+		fmt.Fprintf(stderr, "logftxt: %v\n", err)
+
+		status = 1
+	}
+
+	return status
+}
+
+// previewThemes renders the sample using each of the themes one after another.
+func previewThemes(themes []string, out *output, stderr io.Writer, options ...logftxt.AppenderOption) int {
+	status := 0
+
+	for i, name := range themes {
+		if i != 0 {
+			fmt.Fprintln(out)
+		}
+
+		if out.markup == markupHTML {
+			fmt.Fprintf(out, "%s:\n", html.EscapeString(name))
+		} else {
+			fmt.Fprintf(out, "%s:\n", name)
+		}
+
+		err := previewTheme(name, out.Writer, options...)
+		if err != nil {
+			fmt.Fprintf(stderr, "logftxt: %v\n", err)
+
+			status = 1
+		}
+	}
+
+	return status
+}
+
+// previewColumns renders the sample using the themes side by side, the given number of themes in a row.
+// Themes that fail to load are reported and skipped.
+func previewColumns(themes []string, n, width int, w, stderr io.Writer, options ...logftxt.AppenderOption) int {
+	status := 0
+
+	var columns []logftxt.SampleColumn
+
+	for _, name := range themes {
+		theme, err := logftxt.NewThemeRef(name).Load()
+		if err != nil {
+			fmt.Fprintf(stderr, "logftxt: %s: %v\n", name, err)
+
+			status = 1
+
+			continue
+		}
+
+		columns = append(columns, logftxt.SampleColumn{Title: name + ":", Theme: theme})
+	}
+
+	err := logftxt.RenderSampleColumns(columns, w, n, width, options...)
+	if err != nil {
+		fmt.Fprintf(stderr, "logftxt: %v\n", err)
+
+		return 1
+	}
+
+	return status
+}
+
+func previewTheme(name string, w io.Writer, options ...logftxt.AppenderOption) error {
+	theme, err := logftxt.NewThemeRef(name).Load()
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return logftxt.RenderSample(theme, w, options...) //nolint:wrapcheck // error is passed as is from the writer
+}
@@ -0,0 +1,129 @@
+package logftxt
+
+import (
+	"github.com/pamburus/go-ansi-esc/sgr"
+)
+
+// colorDepth is a number of colors supported by the terminal.
+type colorDepth int
+
+// Valid values for colorDepth.
+const (
+	colorDepthTrueColor colorDepth = iota
+	colorDepth256
+	colorDepth16
+)
+
+// convert returns the color that is the closest to c among the colors supported with depth d.
+func (d colorDepth) convert(c sgr.Color) sgr.Color {
+	if d == colorDepthTrueColor {
+		return c
+	}
+
+	if rgb, ok := c.RGBColor(); ok {
+		if d == colorDepth256 {
+			return rgbToPalette(rgb).Color()
+		}
+
+		return nearestBasicColor(rgb).Color()
+	}
+
+	if index, ok := c.PaletteColor(); ok && d == colorDepth16 && index >= 16 {
+		return nearestBasicColor(paletteToRGB(index)).Color()
+	}
+
+	return c
+}
+
+// ---
+
+// rgbToPalette returns the closest color of the 6x6x6 color cube or the grayscale ramp of the 256-color palette.
+func rgbToPalette(c sgr.RGBColor) sgr.PaletteColor {
+	r, g, b := cubeIndex(c.R()), cubeIndex(c.G()), cubeIndex(c.B())
+	cube := sgr.RGB(cubeLevels[r], cubeLevels[g], cubeLevels[b])
+
+	average := (int(c.R()) + int(c.G()) + int(c.B())) / 3
+	grayIndex := min(max((average-3)/10, 0), 23)
+	grayLevel := uint8(8 + grayIndex*10) //nolint:gosec // G115: the value is at most 238
+	gray := sgr.RGB(grayLevel, grayLevel, grayLevel)
+
+	if rgbDistance(c, gray) < rgbDistance(c, cube) {
+		return sgr.PaletteColor(232 + grayIndex) //nolint:gosec // G115: the value is at most 255
+	}
+
+	return sgr.PaletteColor(16 + 36*r + 6*g + b) //nolint:gosec // G115: the value is at most 231
+}
+
+// paletteToRGB returns the RGB value of a color of the 256-color palette.
+func paletteToRGB(c sgr.PaletteColor) sgr.RGBColor {
+	switch {
+	case c < 16:
+		return basicColors[c]
+	case c < 232:
+		i := int(c) - 16
+
+		return sgr.RGB(cubeLevels[i/36], cubeLevels[i/6%6], cubeLevels[i%6])
+	default:
+		level := uint8(8 + (int(c)-232)*10) //nolint:gosec // G115: the value is at most 238
+
+		return sgr.RGB(level, level, level)
+	}
+}
+
+// nearestBasicColor returns the closest of the 16 basic colors.
+func nearestBasicColor(c sgr.RGBColor) sgr.BasicColor {
+	result := sgr.Black
+	best := rgbDistance(c, basicColors[0])
+
+	for i, bc := range basicColors[1:] {
+		if distance := rgbDistance(c, bc); distance < best {
+			result = sgr.BasicColor(i + 1) //nolint:gosec // G115: the value is at most 15
+			best = distance
+		}
+	}
+
+	return result
+}
+
+func cubeIndex(v uint8) int {
+	switch {
+	case v < 48:
+		return 0
+	case v < 115:
+		return 1
+	default:
+		return (int(v) - 35) / 40
+	}
+}
+
+func rgbDistance(a, b sgr.RGBColor) int {
+	dr := int(a.R()) - int(b.R())
+	dg := int(a.G()) - int(b.G())
+	db := int(a.B()) - int(b.B())
+
+	return dr*dr + dg*dg + db*db
+}
+
+// ---
+
+var cubeLevels = [6]uint8{0, 95, 135, 175, 215, 255}
+
+// basicColors are the default xterm values of the 16 basic colors.
+var basicColors = [16]sgr.RGBColor{
+	sgr.RGB(0, 0, 0),
+	sgr.RGB(205, 0, 0),
+	sgr.RGB(0, 205, 0),
+	sgr.RGB(205, 205, 0),
+	sgr.RGB(0, 0, 238),
+	sgr.RGB(205, 0, 205),
+	sgr.RGB(0, 205, 205),
+	sgr.RGB(229, 229, 229),
+	sgr.RGB(127, 127, 127),
+	sgr.RGB(255, 0, 0),
+	sgr.RGB(0, 255, 0),
+	sgr.RGB(255, 255, 0),
+	sgr.RGB(92, 92, 255),
+	sgr.RGB(255, 0, 255),
+	sgr.RGB(0, 255, 255),
+	sgr.RGB(255, 255, 255),
+}
@@ -0,0 +1,31 @@
+package logftxt
+
+import (
+	"testing"
+
+	"github.com/pamburus/go-ansi-esc/sgr"
+	"github.com/pamburus/go-tst/tst"
+)
+
+func TestColorDepth(tt *testing.T) {
+	t := tst.New(tt)
+
+	t.Run("TrueColor", func(t tst.Test) {
+		t.Expect(colorDepthTrueColor.convert(sgr.RGB(1, 2, 3).Color())).ToEqual(sgr.RGB(1, 2, 3).Color())
+	})
+
+	t.Run("256", func(t tst.Test) {
+		t.Expect(colorDepth256.convert(sgr.RGB(255, 135, 0).Color())).ToEqual(sgr.PaletteColor(208).Color())
+		t.Expect(colorDepth256.convert(sgr.RGB(128, 128, 128).Color())).ToEqual(sgr.PaletteColor(244).Color())
+		t.Expect(colorDepth256.convert(sgr.PaletteColor(208).Color())).ToEqual(sgr.PaletteColor(208).Color())
+		t.Expect(colorDepth256.convert(sgr.Red.Color())).ToEqual(sgr.Red.Color())
+	})
+
+	t.Run("16", func(t tst.Test) {
+		t.Expect(colorDepth16.convert(sgr.RGB(250, 10, 10).Color())).ToEqual(sgr.BrightRed.Color())
+		t.Expect(colorDepth16.convert(sgr.PaletteColor(244).Color())).ToEqual(sgr.BrightBlack.Color())
+		t.Expect(colorDepth16.convert(sgr.PaletteColor(21).Color())).ToEqual(sgr.Blue.Color())
+		t.Expect(colorDepth16.convert(sgr.PaletteColor(9).Color())).ToEqual(sgr.PaletteColor(9).Color())
+		t.Expect(colorDepth16.convert(sgr.Default.Color())).ToEqual(sgr.Default.Color())
+	})
+}
@@ -12,6 +12,7 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/pamburus/logftxt/internal/pkg/env"
+	"github.com/pamburus/logftxt/internal/pkg/themecfg"
 )
 
 // LoadConfig loads configuration file with the given filename.
@@ -57,6 +58,7 @@ type Config struct {
 	} `yaml:"timestamp"`
 	Caller struct {
 		Format CallerFormat `yaml:"format"`
+		URL    string       `yaml:"url"`
 	} `yaml:"caller"`
 	Values struct {
 		Time struct {
@@ -67,9 +69,22 @@ type Config struct {
 			Precision Precision      `yaml:"precision"`
 		} `yaml:"duration"`
 		Error struct {
-			Format ErrorFormat `yaml:"format"`
+			Format   ErrorFormat `yaml:"format"`
+			MaxDepth int         `yaml:"max-depth"`
 		} `yaml:"error"`
+		Reflection struct {
+			MaxDepth  int `yaml:"max-depth"`
+			MaxCycles int `yaml:"max-cycles"`
+		} `yaml:"reflection"`
 	} `yaml:"values"`
+	Layout struct {
+		Multiline MultilineLayout `yaml:"multiline"`
+		LineWidth int             `yaml:"line-width"`
+		Overflow  LineOverflow    `yaml:"overflow"`
+	} `yaml:"layout"`
+	Redact struct {
+		Fields []string `yaml:"fields"`
+	} `yaml:"redact"`
 }
 
 // Validate checks whether c is valid.
@@ -89,6 +104,39 @@ func (c Config) Validate() error {
 		return fmt.Errorf("error format is invalid: %w", err)
 	}
 
+	if c.Values.Error.MaxDepth < 0 {
+		return fmt.Errorf("error max depth is invalid: negative value %d", c.Values.Error.MaxDepth)
+	}
+
+	if c.Values.Reflection.MaxDepth < 0 {
+		return fmt.Errorf("reflection max depth is invalid: negative value %d", c.Values.Reflection.MaxDepth)
+	}
+
+	if c.Values.Reflection.MaxCycles < 0 {
+		return fmt.Errorf("reflection max cycles is invalid: negative value %d", c.Values.Reflection.MaxCycles)
+	}
+
+	err = c.Layout.Multiline.Validate()
+	if err != nil {
+		return fmt.Errorf("multiline layout is invalid: %w", err)
+	}
+
+	if c.Layout.LineWidth < 0 {
+		return fmt.Errorf("line width is invalid: negative value %d", c.Layout.LineWidth)
+	}
+
+	err = c.Layout.Overflow.Validate()
+	if err != nil {
+		return fmt.Errorf("line overflow is invalid: %w", err)
+	}
+
+	for _, pattern := range c.Redact.Fields {
+		err = validateRedactPattern(pattern)
+		if err != nil {
+			return fmt.Errorf("redacted fields are invalid: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -179,12 +227,17 @@ func (v DurationFormat) Validate() error {
 
 // Valid values for CallerFormat.
 const (
-	CallerFormatDefault CallerFormat = ""
-	CallerFormatShort   CallerFormat = "short"
-	CallerFormatLong    CallerFormat = "long"
+	CallerFormatDefault   CallerFormat = ""
+	CallerFormatShort     CallerFormat = "short"
+	CallerFormatLong      CallerFormat = "long"
+	CallerFormatHyperlink CallerFormat = "hyperlink"
 )
 
-// CallerFormat defines duration output format.
+// CallerFormat defines caller output format.
+//
+// CallerFormatHyperlink makes caller output the same way as CallerFormatShort
+// but wrapped into an OSC 8 terminal hyperlink, see [CallerHyperlink].
+// It falls back to CallerFormatShort if colors are disabled or the terminal is not known to support hyperlinks.
 type CallerFormat string
 
 // Validate checks whether v has a valid value.
@@ -193,6 +246,7 @@ func (v CallerFormat) Validate() error {
 	case CallerFormatDefault:
 	case CallerFormatShort:
 	case CallerFormatLong:
+	case CallerFormatHyperlink:
 	default:
 		return fmt.Errorf("unknown caller format %q", v)
 	}
@@ -200,6 +254,14 @@ func (v CallerFormat) Validate() error {
 	return nil
 }
 
+func (v CallerFormat) toEncoderOptions(o *encoderOptions) {
+	o.callerFormat = v
+}
+
+func (v CallerFormat) toAppenderOptions(o *appenderOptions) {
+	o.callerFormat = v
+}
+
 // ---
 
 // Valid values for ErrorFormat.
@@ -207,9 +269,11 @@ const (
 	ErrorFormatDefault ErrorFormat = ""
 	ErrorFormatShort   ErrorFormat = "short"
 	ErrorFormatLong    ErrorFormat = "long"
+	ErrorFormatTrace   ErrorFormat = "trace"
+	ErrorFormatChain   ErrorFormat = "chain"
 )
 
-// ErrorFormat defines duration output format.
+// ErrorFormat defines error output format.
 type ErrorFormat string
 
 // Validate checks whether v has a valid value.
@@ -218,6 +282,8 @@ func (v ErrorFormat) Validate() error {
 	case ErrorFormatDefault:
 	case ErrorFormatShort:
 	case ErrorFormatLong:
+	case ErrorFormatTrace:
+	case ErrorFormatChain:
 	default:
 		return fmt.Errorf("unknown error format %q", v)
 	}
@@ -225,6 +291,82 @@ func (v ErrorFormat) Validate() error {
 	return nil
 }
 
+func (v ErrorFormat) toEncoderOptions(o *encoderOptions) {
+	o.errorFormat = v
+}
+
+func (v ErrorFormat) toAppenderOptions(o *appenderOptions) {
+	o.errorFormat = v
+}
+
+// ---
+
+// Valid values for MultilineLayout.
+const (
+	MultilineLayoutDefault    = MultilineLayout(themecfg.MultilineLayoutDefault)
+	MultilineLayoutSingleLine = MultilineLayout(themecfg.MultilineLayoutSingleLine)
+	MultilineLayoutBlock      = MultilineLayout(themecfg.MultilineLayoutBlock)
+)
+
+// MultilineLayout defines how messages and string values containing line feeds are laid out.
+//
+// Default value means the layout specified by the `layout.multiline` configuration setting
+// or the `settings.multiline` of the theme, which is single-line unless specified.
+type MultilineLayout string
+
+// Validate checks whether v has a valid value.
+func (v MultilineLayout) Validate() error {
+	return themecfg.MultilineLayout(v).Validate()
+}
+
+func (v MultilineLayout) toEncoderOptions(o *encoderOptions) {
+	o.multiline = v
+}
+
+func (v MultilineLayout) toAppenderOptions(o *appenderOptions) {
+	o.multiline = v
+}
+
+// ---
+
+// Valid values for LineOverflow.
+const (
+	LineOverflowDefault  LineOverflow = ""
+	LineOverflowNone     LineOverflow = "none"
+	LineOverflowTruncate LineOverflow = "truncate"
+	LineOverflowWrap     LineOverflow = "wrap"
+)
+
+// LineOverflow defines what to do with lines that do not fit into the line width.
+//
+// LineOverflowTruncate cuts string values that do not fit into the line width and marks them with an ellipsis,
+// top-level fields that still do not fit are omitted.
+// LineOverflowWrap moves top-level fields that do not fit into the line width to continuation lines.
+// The line width is detected by NewAppender if the writer is a terminal or can be set explicitly with [LineWidth].
+type LineOverflow string
+
+// Validate checks whether v has a valid value.
+func (v LineOverflow) Validate() error {
+	switch v {
+	case LineOverflowDefault:
+	case LineOverflowNone:
+	case LineOverflowTruncate:
+	case LineOverflowWrap:
+	default:
+		return fmt.Errorf("unknown line overflow %q", v)
+	}
+
+	return nil
+}
+
+func (v LineOverflow) toEncoderOptions(o *encoderOptions) {
+	o.overflow = v
+}
+
+func (v LineOverflow) toAppenderOptions(o *appenderOptions) {
+	o.overflow = v
+}
+
 // ---
 
 func loadConfig(filename string, fileSystem FS) (*Config, error) {
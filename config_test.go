@@ -65,6 +65,32 @@ func TestConfig(tt *testing.T) {
 				ReadConfig(strings.NewReader(`{"caller": {"format": "aaa"}}`)),
 			).ToFail()
 		})
+		t.Run("InvalidMultilineLayout", func(t tst.Test) {
+			t.Expect(
+				ReadConfig(strings.NewReader(`{"layout": {"multiline": "aaa"}}`)),
+			).ToFail()
+		})
+		t.Run("InvalidRedactFields", func(t tst.Test) {
+			t.Expect(
+				ReadConfig(strings.NewReader(`{"redact": {"fields": ["a..b"]}}`)),
+			).ToFail()
+			t.Expect(
+				ReadConfig(strings.NewReader(`{"redact": {"fields": ["["]}}`)),
+			).ToFail()
+		})
+		t.Run("InvalidErrorMaxDepth", func(t tst.Test) {
+			t.Expect(
+				ReadConfig(strings.NewReader(`{"values": {"error": {"max-depth": -1}}}`)),
+			).ToFail()
+		})
+		t.Run("InvalidLineOverflow", func(t tst.Test) {
+			t.Expect(
+				ReadConfig(strings.NewReader(`{"layout": {"overflow": "aaa"}}`)),
+			).ToFail()
+			t.Expect(
+				ReadConfig(strings.NewReader(`{"layout": {"line-width": -1}}`)),
+			).ToFail()
+		})
 	})
 
 	t.Run("Options", func(t tst.Test) {
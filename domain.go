@@ -19,18 +19,20 @@ func DefaultDomain() Domain {
 
 // NewDomain constructs a new Domain based on the provided base but with some values overridden by the given options.
 func NewDomain(base Domain, options ...domainOption) Domain {
-	return domain{
-		base.Environment(),
-		base.FS(),
-	}.with(options)
+	d := domain{env: base.Environment(), fs: base.FS()}
+	if base, ok := base.(domain); ok {
+		d.wrapFS = base.wrapFS
+	}
+
+	return d.with(options)
 }
 
 // ---
 
 func defaultDomain() domain {
 	return domain{
-		Environment(os.LookupEnv),
-		SystemFS(),
+		env: Environment(os.LookupEnv),
+		fs:  SystemFS(),
 	}
 }
 
@@ -39,6 +41,9 @@ func defaultDomain() domain {
 type domain struct {
 	env Environment
 	fs  FS
+
+	// wrapFS wraps file systems given with options, so that files opened through them are watched, see [WatchConfig].
+	wrapFS func(FS) FS
 }
 
 func (d domain) Environment() Environment {
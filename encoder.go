@@ -1,10 +1,14 @@
 package logftxt
 
 import (
+	"bytes"
 	"encoding/base64"
 	"fmt"
 	"math/rand"
 	"reflect"
+	"runtime"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 	"unicode/utf8"
@@ -12,24 +16,38 @@ import (
 
 	"github.com/ssgreg/logf"
 
+	"github.com/pamburus/logftxt/internal/pkg/env"
 	"github.com/pamburus/logftxt/internal/pkg/quoting"
 )
 
 // NewEncoder constructs a new logf.Encoder that encodes log messages in a human-readable text representation.
 func NewEncoder(options ...EncoderOption) logf.Encoder {
-	return newEncoder(defaultEncoderOptions().With(options))
+	return newLogfEncoder(defaultEncoderOptions().With(options))
 }
 
 // ---
 
 type encoder struct {
 	encoderOptions
+	encoderState
 	cfg   *Config
 	theme *Theme
 	pool  chan *entryEncoder
 	once  sync.Once
 }
 
+// encoderState holds the state derived from the options, configuration and theme when the encoder is set up.
+type encoderState struct {
+	colorDepth       colorDepth
+	levelItems       map[logf.Level]*fmtItem
+	linkSchemes      []string
+	keyColumns       *keyColumns
+	redactor         redactor
+	errorMaxDepth    int
+	reflectMaxDepth  int
+	reflectMaxCycles int
+}
+
 func (e *encoder) Encode(buf *logf.Buffer, entry logf.Entry) error {
 	e.once.Do(func() {
 		e.setup(buf, entry.Time)
@@ -53,16 +71,10 @@ func (e *encoder) getEntryEncoder() *entryEncoder {
 		return ee
 	default:
 		return &entryEncoder{
-			e.encoderOptions,
-			e.theme,
-			logf.Entry{},
-			nil,
-			logf.NewCache(100),
-			0,
-			nil,
-			0,
-			0,
-			newStyler().Disabled(e.color == ColorNever),
+			encoderOptions: e.encoderOptions,
+			encoderState:   e.encoderState,
+			theme:          e.theme,
+			styler:         newStyler().Disabled(e.color == ColorNever),
 		}
 	}
 }
@@ -78,9 +90,20 @@ func (e *encoder) putEntryEncoder(ee *entryEncoder) {
 }
 
 func (e *encoder) setup(buf *logf.Buffer, ts time.Time) {
+	messages, _ := e.configure()
+
+	e.report(buf, ts, messages)
+}
+
+// configure resolves configuration and theme and the options depending on them.
+// It returns warning messages to be reported and whether configuration or theme failed to load.
+func (e *encoder) configure() ([]logf.Entry, bool) {
 	var messages []logf.Entry
 
-	setupContext := domain{e.env, e.fs}
+	setupContext := domain{env: e.env, fs: e.fs}
+	if files, ok := e.fs.(*recordingFS); ok {
+		setupContext.wrapFS = files.with
+	}
 
 	for i := len(e.provideConfig) - 1; i >= 0; i-- {
 		cfg, err := e.provideConfig[i](setupContext)
@@ -122,6 +145,62 @@ func (e *encoder) setup(buf *logf.Buffer, ts time.Time) {
 		e.theme = DefaultTheme()
 	}
 
+	failed := len(messages) != 0
+
+	e.theme = e.theme.variant(e.themeMode.resolved(e.env))
+
+	if e.multiline == MultilineLayoutDefault {
+		e.multiline = e.cfg.Layout.Multiline
+	}
+
+	if e.multiline == MultilineLayoutDefault {
+		e.multiline = MultilineLayout(e.theme.settings.Multiline)
+	}
+
+	if e.overflow == LineOverflowDefault {
+		e.overflow = e.cfg.Layout.Overflow
+	}
+
+	if e.lineWidth == 0 {
+		e.lineWidth = e.cfg.Layout.LineWidth
+	}
+
+	if e.lineWidth == 0 {
+		e.lineWidth = e.terminalWidth
+	}
+
+	if e.outputMode == OutputModeDefault {
+		e.outputMode = OutputMode(e.theme.settings.OutputMode)
+	}
+
+	if e.outputMode == OutputModeLogfmt {
+		e.theme = e.theme.logfmt()
+		e.flattenObjects = true
+		e.multiline = MultilineLayoutSingleLine
+
+		if e.overflow == LineOverflowWrap {
+			e.overflow = LineOverflowNone
+		}
+	}
+
+	e.colorDepth = e.color.depth(e.env)
+	e.theme = e.theme.downgraded(e.colorDepth)
+
+	e.levelItems = e.theme.customLevelItems(e.levelNames)
+	for _, item := range e.levelItems {
+		item.downgrade(e.colorDepth)
+
+		if e.outputMode == OutputModeLogfmt {
+			item.strip()
+		}
+	}
+
+	e.linkSchemes = markupLinkSchemes(e.cfg.Caller.URL)
+
+	if memory := e.theme.settings.Alignment.KeyMemory; memory > 0 {
+		e.keyColumns = newKeyColumns(memory)
+	}
+
 	if e.encodeDuration == nil {
 		switch e.cfg.Values.Duration.Format {
 		case DurationFormatSeconds:
@@ -143,10 +222,20 @@ func (e *encoder) setup(buf *logf.Buffer, ts time.Time) {
 		e.encodeTimeValue = TimeValueEncodeFunc(TimeLayout(e.cfg.Timestamp.Format))
 	}
 
+	if e.callerFormat == CallerFormatDefault {
+		e.callerFormat = e.cfg.Caller.Format
+	}
+
 	if e.encodeCaller == nil {
-		switch e.cfg.Caller.Format {
+		switch e.callerFormat {
 		case CallerFormatLong:
 			e.encodeCaller = CallerLong()
+		case CallerFormatHyperlink:
+			if e.color != ColorNever && env.Hyperlinks(e.env) {
+				e.encodeCaller = CallerHyperlink(e.cfg.Caller.URL)
+			} else {
+				e.encodeCaller = CallerShort()
+			}
 		case CallerFormatShort, CallerFormatDefault:
 			fallthrough
 		default:
@@ -154,17 +243,53 @@ func (e *encoder) setup(buf *logf.Buffer, ts time.Time) {
 		}
 	}
 
+	if e.errorFormat == ErrorFormatDefault {
+		e.errorFormat = e.cfg.Values.Error.Format
+	}
+
+	if e.errorFormat == ErrorFormatTrace && e.outputMode == OutputModeLogfmt {
+		// Stack traces are output as separate lines that would break logfmt records.
+		e.errorFormat = ErrorFormatShort
+	}
+
+	e.errorMaxDepth = e.cfg.Values.Error.MaxDepth
+	if e.errorMaxDepth == 0 {
+		e.errorMaxDepth = defaultErrorMaxDepth
+	}
+
+	e.reflectMaxDepth = e.cfg.Values.Reflection.MaxDepth
+	if e.reflectMaxDepth == 0 {
+		e.reflectMaxDepth = defaultReflectMaxDepth
+	}
+
+	e.reflectMaxCycles = e.cfg.Values.Reflection.MaxCycles
+
 	if e.encodeError == nil {
-		switch e.cfg.Values.Error.Format {
+		switch e.errorFormat {
 		case ErrorFormatLong:
 			e.encodeError = ErrorLong()
-		case ErrorFormatShort, ErrorFormatDefault:
+		case ErrorFormatShort, ErrorFormatTrace, ErrorFormatChain, ErrorFormatDefault:
 			fallthrough
 		default:
 			e.encodeError = ErrorShort()
 		}
 	}
 
+	var errs []error
+
+	e.redactor, errs = newRedactor(slices.Concat(e.cfg.Redact.Fields, e.redactFields))
+	for _, err := range errs {
+		messages = append(messages, logf.Entry{
+			Text:   "ignoring invalid redacted field pattern",
+			Fields: []logf.Field{logf.Error(err)},
+		})
+	}
+
+	return messages, failed
+}
+
+// report logs the warning messages right before the entry with the given timestamp.
+func (e *encoder) report(buf *logf.Buffer, ts time.Time, messages []logf.Entry) {
 	for _, message := range messages {
 		message.Time = ts.Add(-time.Nanosecond)
 		message.Level = logf.LevelWarn
@@ -190,31 +315,104 @@ func (e *encoder) log(buf *logf.Buffer, entry logf.Entry) {
 
 type entryEncoder struct {
 	encoderOptions
-	theme       *Theme
-	entry       logf.Entry
-	buf         *logf.Buffer
-	cache       *logf.Cache
-	startBufLen int
-	objectKeys  []string
-	objectScope int
-	lastPos     int
+	encoderState
+	theme        *Theme
+	entry        logf.Entry
+	buf          *logf.Buffer
+	level        *fmtLevel
+	caches       [4]*logf.Cache
+	startBufLen  int
+	lineStart    int
+	columnPos    int
+	columnWidth  int
+	objectKeys   []string
+	objectScope  int
+	reflectPath  []reflectedRef
+	reflectDepth int
+	lastPos      int
+	depth        int
+	blocks       []block
+	scratch      []byte
+	alignTo      int
+	line         uint64
 
 	styler styler
 }
 
 func (e *entryEncoder) encode() error {
-	for _, item := range e.theme.items {
-		pos := e.appendSeparator()
-		item.encode(e)
-		e.confirmSeparator(pos)
+	start := e.buf.Len()
+	e.level = &e.theme.levels[levelIndex(e.entry.Level)]
+	e.alignTo = 0
+	e.styler.Reset()
+
+	if e.keyColumns != nil {
+		e.line = e.keyColumns.nextLine()
 	}
 
+	e.level.Line.encode(e, func() {
+		// Line prefix and style sequences are not part of the items, so do not let them produce a separator.
+		e.startBufLen = e.buf.Len()
+		e.lineStart = e.startBufLen
+		e.columnPos = e.lineStart
+		e.columnWidth = 0
+
+		e.appendItems()
+
+		for _, b := range e.blocks {
+			e.appendBlock(b)
+		}
+	})
+
+	clear(e.blocks)
+	e.blocks = e.blocks[:0]
+
 	e.buf.AppendByte('\n')
 
+	if e.markup == MarkupHTML {
+		e.scratch = append(e.scratch[:0], e.buf.Data[start:]...)
+		e.buf.Data = appendHTML(e.buf.Data[:start], e.scratch, e.linkSchemes)
+	}
+
 	return nil
 }
 
+// appendItems appends the items of the theme to the current line.
+// Values are truncated in truncate line overflow mode before the items following them are known,
+// so if the line does not fit into the line width because of those items, like the caller,
+// the items are appended once again with the line width reduced by the excess.
+func (e *entryEncoder) appendItems() {
+	start := e.buf.Len()
+	lineWidth := e.lineWidth
+
+	for {
+		for _, item := range e.theme.items {
+			pos := e.appendSeparator()
+			item.encode(e)
+			e.confirmSeparator(pos)
+		}
+
+		excess := e.column() - lineWidth
+		if e.overflow != LineOverflowTruncate || !e.lineLimited() || excess <= 0 || e.lineWidth != lineWidth || excess >= lineWidth {
+			break
+		}
+
+		e.truncate(start)
+		clear(e.blocks)
+		e.blocks = e.blocks[:0]
+		e.alignTo = 0
+		e.lineWidth = lineWidth - excess
+	}
+
+	e.lineWidth = lineWidth
+}
+
 func (e *entryEncoder) EncodeFieldAny(k string, v interface{}) {
+	if o, ok := e.reflectedObject(v); ok {
+		e.EncodeFieldObject(k, o)
+
+		return
+	}
+
 	e.appendField(k, func() {
 		e.EncodeTypeAny(v)
 	})
@@ -287,6 +485,10 @@ func (e *entryEncoder) EncodeFieldFloat32(k string, v float32) {
 }
 
 func (e *entryEncoder) EncodeFieldString(k string, v string) {
+	if e.deferMultiline(k, v) {
+		return
+	}
+
 	e.appendField(k, func() {
 		e.EncodeTypeString(v)
 	})
@@ -299,9 +501,14 @@ func (e *entryEncoder) EncodeFieldDuration(k string, v time.Duration) {
 }
 
 func (e *entryEncoder) EncodeFieldError(k string, v error) {
+	if isNilPointer(v) {
+		v = nil
+	}
+
 	e.appendField(k, func() {
 		e.EncodeTypeError(v)
 	})
+	e.deferStackTrace(k, v)
 }
 
 func (e *entryEncoder) EncodeFieldTime(k string, v time.Time) {
@@ -312,19 +519,26 @@ func (e *entryEncoder) EncodeFieldTime(k string, v time.Time) {
 
 func (e *entryEncoder) EncodeFieldArray(k string, v logf.ArrayEncoder) {
 	e.appendField(k, func() {
+		e.objectKeys = append(e.objectKeys, k)
 		e.EncodeTypeArray(v)
+		e.objectKeys = e.objectKeys[:len(e.objectKeys)-1]
 	})
 }
 
 func (e *entryEncoder) EncodeFieldObject(k string, v logf.ObjectEncoder) {
-	if e.flattenObjects {
+	switch {
+	case e.redacted(k):
+		e.appendField(k, nil)
+	case e.flattenObjects:
 		e.objectKeys = append(e.objectKeys, k)
 		oe := objectEncoder{e, 0}
 		_ = v.EncodeLogfObject(&oe)
 		e.objectKeys = e.objectKeys[:len(e.objectKeys)-1]
-	} else {
+	default:
 		e.appendField(k, func() {
+			e.objectKeys = append(e.objectKeys, k)
 			e.EncodeTypeObject(v)
+			e.objectKeys = e.objectKeys[:len(e.objectKeys)-1]
 		})
 	}
 }
@@ -491,7 +705,11 @@ func (e *entryEncoder) EncodeTypeAny(v interface{}) {
 	case string:
 		e.EncodeTypeString(v)
 	case fmt.Stringer:
-		e.EncodeTypeString(v.String())
+		if isNilPointer(v) {
+			e.EncodeTypeAny(nil)
+		} else {
+			e.EncodeTypeString(v.String())
+		}
 	case error:
 		e.EncodeTypeError(v)
 
@@ -526,10 +744,8 @@ func (e *entryEncoder) EncodeTypeAny(v interface{}) {
 			e.EncodeTypeFloat32(float32(rv.Float()))
 		case reflect.Float64:
 			e.EncodeTypeFloat64(rv.Float())
-		case reflect.Slice, reflect.Array:
-			e.EncodeTypeArray(anyArray{rv})
 		default:
-			_, _ = fmt.Fprintf(e.buf, "%v", v)
+			e.encodeReflected(rv)
 		}
 	}
 }
@@ -613,13 +829,14 @@ func (e *entryEncoder) EncodeTypeTime(v time.Time) {
 }
 
 func (e *entryEncoder) EncodeTypeString(v string) {
-	e.theme.fmt.String.encode(e, func() {
-		if v == "null" {
-			e.buf.AppendString(`"null"`)
-		} else {
-			e.appendAutoQuotedString(v)
+	start := e.buf.Len()
+	e.appendString(v)
+
+	if e.overflow == LineOverflowTruncate && e.lineLimited() {
+		if e.column() > e.lineWidth {
+			e.truncateString(start, v)
 		}
-	})
+	}
 }
 
 func (e *entryEncoder) EncodeTypeStrings(v []string) {
@@ -710,6 +927,7 @@ func (e *entryEncoder) EncodeTypeDurations(v []time.Duration) {
 func (e *entryEncoder) EncodeTypeArray(v logf.ArrayEncoder) {
 	old := e.objectScope
 	e.objectScope = len(e.objectKeys)
+	e.depth++
 
 	e.theme.fmt.Array.encode(e, func() {
 		e.buf.AppendString(e.theme.fmt.Array.inner.prefix)
@@ -717,23 +935,33 @@ func (e *entryEncoder) EncodeTypeArray(v logf.ArrayEncoder) {
 		_ = v.EncodeLogfArray(&ae)
 
 		if ae.n == 0 {
-			e.buf.Data = e.buf.Data[:e.buf.Len()-len(e.theme.fmt.Array.inner.prefix)]
+			e.truncate(e.buf.Len() - len(e.theme.fmt.Array.inner.prefix))
 		} else {
 			e.buf.AppendString(e.theme.fmt.Array.inner.suffix)
 		}
 	})
 
+	e.depth--
 	e.objectScope = old
 }
 
 func (e *entryEncoder) EncodeTypeObject(v logf.ObjectEncoder) {
+	old := e.objectScope
+	e.objectScope = len(e.objectKeys)
+	e.depth++
+
+	defer func() {
+		e.depth--
+		e.objectScope = old
+	}()
+
 	e.theme.fmt.Object.encode(e, func() {
 		e.buf.AppendString(e.theme.fmt.Object.inner.prefix)
 		oe := objectEncoder{e, 0}
 		_ = v.EncodeLogfObject(&oe)
 
 		if oe.n == 0 {
-			e.buf.Data = e.buf.Data[:e.buf.Len()-len(e.theme.fmt.Object.inner.prefix)]
+			e.truncate(e.buf.Len() - len(e.theme.fmt.Object.inner.prefix))
 		} else {
 			e.buf.AppendString(e.theme.fmt.Object.inner.suffix)
 		}
@@ -745,6 +973,18 @@ func (e *entryEncoder) EncodeTypeUnsafeBytes(v unsafe.Pointer) {
 }
 
 func (e *entryEncoder) EncodeTypeError(v error) {
+	if isNilPointer(v) {
+		v = nil
+	}
+
+	if e.errorFormat == ErrorFormatChain && v != nil {
+		if chain := newErrorChain(v, e.errorMaxDepth); len(chain) > 1 {
+			e.EncodeTypeArray(chain)
+
+			return
+		}
+	}
+
 	e.theme.fmt.Error.encode(e, func() {
 		e.buf.AppendString(e.theme.fmt.Error.inner.prefix)
 		e.appendError(v)
@@ -753,6 +993,9 @@ func (e *entryEncoder) EncodeTypeError(v error) {
 }
 
 func (e *entryEncoder) appendArray(n int, appendElement func(int)) {
+	e.depth++
+	defer func() { e.depth-- }()
+
 	e.theme.fmt.Array.encode(e, func() {
 		if n != 0 {
 			e.buf.AppendString(e.theme.fmt.Array.inner.prefix)
@@ -771,21 +1014,176 @@ func (e *entryEncoder) appendArray(n int, appendElement func(int)) {
 }
 
 func (e *entryEncoder) appendField(k string, appendValue func()) {
-	e.styler.Use(e.theme.fmt.Field.inner.style, e.buf, func() {
-		e.addKey(k)
-		e.theme.fmt.Field.separator.encode(e)
-		appendValue()
+	if e.redacted(k) {
+		appendValue = e.appendRedacted
+	}
+
+	start := e.buf.Len()
+	value := start
+
+	e.styler.Use(e.level.Field.inner.style, e.buf, func() {
+		rule := e.theme.fields.match(k)
+		if rule == nil {
+			e.addKey(k)
+			e.level.Field.separator.encode(e)
+			value = e.buf.Len()
+			appendValue()
+
+			return
+		}
+
+		rule.key.encodeOverriding(e, func() { e.addKey(k) })
+		e.level.Field.separator.encode(e)
+		value = e.buf.Len()
+		rule.value.encodeOverriding(e, appendValue)
+	})
+
+	if e.logfmt() {
+		e.quoteValue(value)
+	}
+
+	e.wrapOverflow(start)
+	e.dropOverflow(start)
+}
+
+// wrapOverflow moves a top-level field starting at the given position to a continuation line
+// if wrap line overflow is enabled and the field does not fit into the line width.
+// A field that is the first one on the current line is left as is.
+func (e *entryEncoder) wrapOverflow(start int) {
+	if e.overflow != LineOverflowWrap || !e.lineLimited() || e.depth != 0 || e.column() <= e.lineWidth {
+		return
+	}
+
+	end := start
+	for end > e.lineStart && e.buf.Data[end-1] == ' ' {
+		end--
+	}
+
+	if end == start || end == e.lineStart {
+		return
+	}
+
+	e.scratch = append(e.scratch[:0], e.buf.Data[start:]...)
+	e.truncate(end)
+	e.appendGutter()
+	e.lineStart = end + 1
+	e.buf.AppendBytes(e.scratch)
+}
+
+// dropOverflow removes a top-level field starting at the given position
+// if truncate line overflow is enabled and the field does not fit into the line width even after truncation.
+func (e *entryEncoder) dropOverflow(start int) {
+	if e.overflow != LineOverflowTruncate || !e.lineLimited() || e.depth != 0 || e.column() <= e.lineWidth {
+		return
+	}
+
+	e.truncate(start)
+}
+
+// lineLimited reports whether the output depends on the line width.
+func (e *entryEncoder) lineLimited() bool {
+	return e.lineWidth > 0 && (e.overflow == LineOverflowTruncate || e.overflow == LineOverflowWrap)
+}
+
+// levelItem returns formatting of the level item for the current entry.
+func (e *entryEncoder) levelItem() *fmtItem {
+	if item, ok := e.levelItems[e.entry.Level]; ok {
+		return item
+	}
+
+	return &e.theme.fmt.Level[levelIndex(e.entry.Level)]
+}
+
+// redacted reports whether value of the field with the given key in the current object should be redacted.
+func (e *entryEncoder) redacted(k string) bool {
+	return len(e.redactor) != 0 && e.redactor.matches(e.objectKeys, k)
+}
+
+func (e *entryEncoder) appendRedacted() {
+	e.theme.fmt.Redacted.encode(e, func() {
+		e.buf.AppendString(e.theme.fmt.Redacted.text)
 	})
 }
 
 func (e *entryEncoder) appendSeparator() int {
 	if !e.empty() && e.buf.Len() == e.lastPos {
 		e.buf.AppendByte(' ')
+		e.appendPadding()
 	}
 
 	return e.buf.Len()
 }
 
+// appendFieldSeparator appends a separator before a top-level field
+// and aligns the field to the column its key had in the recent lines.
+// It returns position and column the field starts at.
+func (e *entryEncoder) appendFieldSeparator(k string) (int, int) {
+	if e.keyColumns == nil {
+		return e.appendSeparator(), 0
+	}
+
+	e.alignTo = max(e.alignTo, e.keyColumns.get(k))
+
+	pos := e.appendSeparator()
+	if !e.empty() {
+		e.appendPadding()
+		pos = e.buf.Len()
+	}
+
+	return pos, e.column()
+}
+
+// confirmFieldSeparator does the same as confirmSeparator and remembers the column of the field key.
+func (e *entryEncoder) confirmFieldSeparator(start, column int, k string) {
+	// A field moved to a continuation line does not start at the remembered position anymore.
+	if e.confirmSeparator(start) && e.keyColumns != nil && start >= e.lineStart {
+		e.keyColumns.set(k, column, e.line)
+	}
+}
+
+// appendPadding appends spaces up to the alignment column if the current column is less than that.
+func (e *entryEncoder) appendPadding() {
+	if e.alignTo == 0 {
+		return
+	}
+
+	for column := e.column(); column < e.alignTo; column++ {
+		e.buf.AppendByte(' ')
+	}
+}
+
+// column returns current column of the line in terminal cells.
+// Width of the line is remembered, so only the text appended since the previous call is measured.
+func (e *entryEncoder) column() int {
+	if e.columnPos < e.lineStart || e.columnPos > e.buf.Len() {
+		e.columnPos = e.lineStart
+		e.columnWidth = 0
+	}
+
+	e.columnWidth += displayWidth(e.buf.Data[e.columnPos:])
+	e.columnPos = e.buf.Len()
+
+	return e.columnWidth
+}
+
+// rewind makes the remembered width of the line, see [entryEncoder.column],
+// not include the text starting at the given position because it is going to be replaced.
+func (e *entryEncoder) rewind(n int) {
+	if n >= e.columnPos {
+		return
+	}
+
+	if n < e.lineStart {
+		e.columnPos = e.lineStart
+		e.columnWidth = 0
+
+		return
+	}
+
+	e.columnWidth -= displayWidth(e.buf.Data[n:e.columnPos])
+	e.columnPos = n
+}
+
 func (e *entryEncoder) appendCustomSeparator(fn func()) int {
 	if !e.empty() && e.buf.Len() == e.lastPos {
 		fn()
@@ -796,7 +1194,7 @@ func (e *entryEncoder) appendCustomSeparator(fn func()) int {
 
 func (e *entryEncoder) confirmSeparator(start int) bool {
 	if e.buf.Len() == start && !e.empty() {
-		e.buf.Data = e.buf.Data[:e.lastPos]
+		e.truncate(e.lastPos)
 
 		return false
 	}
@@ -806,23 +1204,232 @@ func (e *entryEncoder) confirmSeparator(start int) bool {
 	return true
 }
 
+// truncate shortens the buffer to the given length.
+// Positions of the escape sequences that are cut off are forgotten, see [styler.Marks].
+func (e *entryEncoder) truncate(n int) {
+	e.rewind(n)
+	e.buf.Data = e.buf.Data[:n]
+	e.styler.Forget(n)
+}
+
 func (e *entryEncoder) empty() bool {
 	return e.buf.Len() == e.startBufLen
 }
 
 func (e *entryEncoder) addKey(k string) {
+	e.appendKey(e.objectKeys[e.objectScope:], k)
+}
+
+func (e *entryEncoder) appendKey(prefixes []string, k string) {
 	e.theme.fmt.Key.encode(e, func() {
-		for _, prefix := range e.objectKeys[e.objectScope:] {
-			e.appendAutoQuotedString(prefix)
+		for _, prefix := range prefixes {
+			e.appendKeyPart(prefix)
 			e.theme.fmt.Key.separator.encode(e)
 		}
 
+		e.appendKeyPart(k)
+	})
+}
+
+func (e *entryEncoder) appendKeyPart(k string) {
+	if e.outputMode == OutputModeLogfmt {
+		e.appendLogfmtKey(k)
+	} else {
 		e.appendAutoQuotedString(k)
+	}
+}
+
+// derivedFieldsCache returns a cache of encoded logger's fields suitable for the current entry.
+// A separate cache is used for each level if output of fields depends on the level.
+// There is no cache if keys are aligned or the line width is limited
+// because output of the fields depends on the preceding output in these cases.
+func (e *entryEncoder) derivedFieldsCache() *logf.Cache {
+	if e.keyColumns != nil || e.lineLimited() {
+		return nil
+	}
+
+	index := logf.LevelError
+	if e.theme.levelDependentFields {
+		index = levelIndex(e.entry.Level)
+	}
+
+	if e.caches[index] == nil {
+		e.caches[index] = logf.NewCache(100)
+	}
+
+	return e.caches[index]
+}
+
+func (e *entryEncoder) multilineBlocks() bool {
+	return e.multiline == MultilineLayoutBlock
+}
+
+// deferMultiline postpones output of a top-level multi-line string field value
+// until the main line is complete if block multiline layout is enabled.
+func (e *entryEncoder) deferMultiline(k, v string) bool {
+	if !e.multilineBlocks() || e.depth != 0 || e.redacted(k) {
+		return false
+	}
+
+	text, ok := multilineText(v)
+	if !ok {
+		return false
+	}
+
+	e.blocks = append(e.blocks, block{keys: e.blockKeys(k), text: text})
+
+	return true
+}
+
+// deferStackTrace postpones output of stack trace frames of a top-level error field value
+// until the main line is complete if trace error format is enabled.
+func (e *entryEncoder) deferStackTrace(k string, v error) {
+	if e.errorFormat != ErrorFormatTrace || e.depth != 0 || v == nil || e.redacted(k) {
+		return
+	}
+
+	frames := errorStackTrace(v)
+	if len(frames) == 0 {
+		return
+	}
+
+	e.blocks = append(e.blocks, block{keys: e.blockKeys(k), frames: frames})
+}
+
+func (e *entryEncoder) blockKeys(k string) []string {
+	keys := make([]string, 0, len(e.objectKeys)-e.objectScope+1)
+	keys = append(keys, e.objectKeys[e.objectScope:]...)
+
+	return append(keys, k)
+}
+
+func (e *entryEncoder) appendBlock(b block) {
+	valueFormat := &e.level.Message
+	indent := ""
+
+	if b.keys != nil {
+		e.appendGutter()
+		e.appendKey(b.keys[:len(b.keys)-1], b.keys[len(b.keys)-1])
+		e.level.Field.separator.encode(e)
+
+		valueFormat = &e.theme.fmt.String
+		indent = blockIndent
+	}
+
+	for i := range b.frames {
+		e.appendGutter()
+		e.buf.AppendString(indent)
+		e.appendStackFrame(&b.frames[i])
+	}
+
+	text := b.text
+	for text != "" {
+		var line string
+		line, text, _ = strings.Cut(text, "\n")
+
+		e.appendGutter()
+		e.buf.AppendString(indent)
+		e.styler.Use(valueFormat.outer.style, e.buf, func() {
+			e.styler.Use(valueFormat.inner.style, e.buf, func() {
+				e.appendBlockLine(strings.TrimSuffix(line, "\r"))
+			})
+		})
+	}
+}
+
+func (e *entryEncoder) appendStackFrame(frame *runtime.Frame) {
+	ff := &e.theme.fmt.Frame
+
+	ff.encode(e, func() {
+		ff.Function.encode(e, func() {
+			e.buf.AppendString(frame.Function)
+		})
+		ff.separator.encode(e)
+		ff.File.encode(e, func() {
+			e.buf.AppendString(frame.File)
+		})
+		ff.Line.encode(e, func() {
+			logf.AppendInt(e.buf, int64(frame.Line))
+		})
+	})
+}
+
+func (e *entryEncoder) appendGutter() {
+	e.buf.AppendByte('\n')
+	e.theme.fmt.Gutter.encode(e, func() {
+		e.buf.AppendString(e.theme.fmt.Gutter.text)
+	})
+}
+
+// appendBlockLine appends a line of a multi-line block as is
+// except for control characters other than tab that are escaped.
+func (e *entryEncoder) appendBlockLine(s string) {
+	p := 0
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 0x20 && c != 0x7f) || c == '\t' {
+			continue
+		}
+
+		e.buf.AppendString(s[p:i])
+		e.theme.fmt.Special.encode(e, func() {
+			e.buf.AppendString(`\u00`)
+			e.buf.AppendByte(hex[c>>4])
+			e.buf.AppendByte(hex[c&0xf])
+		})
+
+		p = i + 1
+	}
+
+	e.buf.AppendString(s[p:])
+}
+
+func (e *entryEncoder) appendString(v string) {
+	e.theme.fmt.String.encode(e, func() {
+		if v == "null" && !e.logfmt() {
+			e.buf.AppendString(`"null"`)
+		} else {
+			e.appendAutoQuotedString(v)
+		}
+	})
+}
+
+// truncateString replaces the string value written starting at the given position by its longest prefix
+// followed by an ellipsis that fits into the rest of the line.
+// Width is measured in the output form of the prefix, so quotes and escaped characters are taken into account.
+// If not even an empty prefix fits, the field is dropped later by dropOverflow.
+func (e *entryEncoder) truncateString(start int, v string) {
+	e.truncate(start)
+
+	f := &e.theme.fmt
+	room := e.lineWidth - e.column() - f.String.outer.width() - f.Ellipsis.outer.width() - stringWidth(f.Ellipsis.text)
+
+	prefix := truncateString(v, room)
+	if len(prefix) == 0 || e.logfmt() || quoting.IsNeeded(prefix) {
+		prefix = truncateEscapedString(v, room-2)
+	}
+
+	e.appendTruncatedString(start, prefix)
+}
+
+// appendTruncatedString replaces text written starting at the given position by the prefix of a string value followed by an ellipsis.
+func (e *entryEncoder) appendTruncatedString(start int, prefix string) {
+	e.truncate(start)
+	e.theme.fmt.String.encode(e, func() {
+		e.appendAutoQuotedString(prefix)
+		e.theme.fmt.Ellipsis.encode(e, func() {
+			e.buf.AppendString(e.theme.fmt.Ellipsis.text)
+		})
 	})
 }
 
+// appendAutoQuotedString appends the string quoted and escaped if needed.
+// Top-level values in logfmt output mode are appended as is because they are quoted as a whole by quoteValue.
 func (e *entryEncoder) appendAutoQuotedString(v string) {
 	switch {
+	case e.logfmt():
+		e.buf.AppendString(v)
 	case len(v) == 0:
 		e.theme.fmt.Quotes.encode(e, func() {
 			e.buf.AppendString(`""`)
@@ -849,17 +1456,57 @@ func (e *entryEncoder) appendTimeValue(t time.Time) {
 }
 
 func (e *entryEncoder) appendCaller(caller logf.EntryCaller) {
+	start := e.buf.Len()
 	e.buf.Data = e.encodeCaller(e.buf.Data, caller)
+
+	// Hyperlinks made by the caller encode function are trusted the same way as styles.
+	e.styler.Mark(e.buf.Data, start)
 }
 
 func (e *entryEncoder) appendError(v error) {
 	if v == nil {
 		e.buf.AppendString("<nil>")
 	} else {
+		start := e.buf.Len()
 		e.buf.Data = e.encodeError(e.buf.Data, v)
+		e.escapeText(start)
 	}
 }
 
+// appendText appends a text like message or logger name as is except for escape characters,
+// so that the text cannot inject escape sequences into the output, see escapeText.
+func (e *entryEncoder) appendText(s string) {
+	start := e.buf.Len()
+	e.buf.AppendString(s)
+	e.escapeText(start)
+}
+
+// escapeText escapes escape characters in the text written starting at the given position.
+// Values in logfmt output mode are left as is because they are escaped by quoteValue.
+func (e *entryEncoder) escapeText(start int) {
+	if e.outputMode == OutputModeLogfmt || bytes.IndexByte(e.buf.Data[start:], esc) < 0 {
+		return
+	}
+
+	e.scratch = append(e.scratch[:0], e.buf.Data[start:]...)
+	e.truncate(start)
+
+	p := 0
+
+	for i, c := range e.scratch {
+		if c == esc {
+			e.buf.AppendBytes(e.scratch[p:i])
+			e.theme.fmt.Special.encode(e, func() {
+				e.buf.AppendString(`\u001b`)
+			})
+
+			p = i + 1
+		}
+	}
+
+	e.buf.AppendBytes(e.scratch[p:])
+}
+
 func (e *entryEncoder) appendEscapedString(s string) {
 	p := 0
 
@@ -928,6 +1575,23 @@ func (e *entryEncoder) appendEscapedString(s string) {
 
 // ---
 
+// block is a deferred multi-line message remainder, a multi-line string field value
+// or stack trace frames of an error field value.
+type block struct {
+	keys   []string
+	text   string
+	frames []runtime.Frame
+}
+
+// multilineText returns s without trailing line feeds if it still contains line feeds.
+func multilineText(s string) (string, bool) {
+	s = strings.TrimRight(s, "\r\n")
+
+	return s, strings.Contains(s, "\n")
+}
+
+// ---
+
 type arrayEncoder struct {
 	e *entryEncoder
 	n int
@@ -1353,35 +2017,31 @@ func (e *objectEncoder) encode(encodeField func()) {
 
 // ---
 
-type anyArray struct {
-	v reflect.Value
-}
-
-func (a anyArray) EncodeLogfArray(enc logf.TypeEncoder) error {
-	for i := 0; i != a.v.Len(); i++ {
-		enc.EncodeTypeAny(a.v.Index(i).Interface())
+// newLogfEncoder constructs a new encoder that reloads configuration and theme files if requested by the options.
+func newLogfEncoder(options encoderOptions) logf.Encoder {
+	if options.watchInterval > 0 {
+		return newWatchingEncoder(options)
 	}
 
-	return nil
+	return newEncoder(options)
 }
 
-// ---
-
 func newEncoder(options encoderOptions) *encoder {
-	options.color = options.color.resolved(options.env)
+	options.color = options.markup.colorSetting(options.color).resolved(options.env)
 
 	return &encoder{
-		options,
-		nil,
-		nil,
-		make(chan *entryEncoder, options.poolSizeLimit),
-		sync.Once{},
+		encoderOptions: options,
+		pool:           make(chan *entryEncoder, options.poolSizeLimit),
 	}
 }
 
 // ---
 
 const (
-	loggerName = "logftxt"
-	hex        = "0123456789abcdef"
+	loggerName  = "logftxt"
+	hex         = "0123456789abcdef"
+	blockIndent = "  "
+
+	defaultErrorMaxDepth   = 8
+	defaultReflectMaxDepth = 8
 )
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/ssgreg/logf"
 
@@ -101,6 +102,22 @@ func TestEncoder(tt *testing.T) {
 		})
 	})
 
+	t.Run("CachedDerivedFields", func(t tst.Test) {
+		enc := logftxt.NewEncoder(&logftxt.Config{}, logftxt.ColorNever, logftxt.FlattenObjects(true))
+		entry := logf.Entry{
+			LoggerID:      1,
+			Text:          "msg",
+			DerivedFields: []logf.Field{logf.Int("d", 1)},
+			Fields:        []logf.Field{logf.Int("f", 2)},
+		}
+
+		for range 2 {
+			buf := logf.NewBuffer()
+			t.Expect(enc.Encode(buf, entry)).ToSucceed()
+			t.Expect(strings.HasSuffix(buf.String(), " d=1 f=2\n")).ToBeTrue()
+		}
+	})
+
 	t.Run("OccasionalComposite", func(t tst.Test) {
 		testEntry := logf.Entry{
 			LoggerName: "ml",
@@ -618,6 +635,691 @@ func TestEncoder(tt *testing.T) {
 			})
 		})
 	})
+
+	t.Run("Multiline", func(t tst.Test) {
+		entry := logf.Entry{
+			DerivedFields: []logf.Field{
+				logf.String("stack", "a.go:1\nb.go:2\n"),
+			},
+			Fields: []logf.Field{
+				logf.Int("n", 1),
+				logf.String("query", "SELECT *\r\n\tFROM t\x00"),
+				logf.Object("o", asObject(logf.String("s", "x\ny"), logf.Strings("a", []string{"p\nq"}))),
+				logf.String("s", "single\n"),
+			},
+			Text: "request failed\nsee details\n\nbelow",
+		}
+
+		test := func(t tst.Test, expected string, options ...logftxt.EncoderOption) {
+			t.Helper()
+
+			enc := logftxt.NewEncoder(append([]logftxt.EncoderOption{config, envColor(false), theme}, options...)...)
+
+			for range 2 {
+				buf := logf.NewBuffer()
+				t.Expect(enc.Encode(buf, entry)).ToSucceed()
+				t.Expect(buf.String()).ToEqual(expected)
+			}
+		}
+
+		singleLine := "Jan  1 00:00:00.000 |ERR| request failed\nsee details\n\nbelow" +
+			` stack="a.go:1\nb.go:2\n" n=1 query="SELECT *\r\n\tFROM t\u0000" o.s="x\ny" o.a=[ "p\nq" ] s="single\n"` + "\n"
+		block := strings.Join([]string{
+			`Jan  1 00:00:00.000 |ERR| request failed n=1 o.a=[ "p\nq" ] s="single\n"`,
+			"  | see details",
+			"  | ",
+			"  | below",
+			"  | stack=",
+			"  |   a.go:1",
+			"  |   b.go:2",
+			"  | query=",
+			"  |   SELECT *",
+			"  |   \tFROM t\\u0000",
+			"  | o.s=",
+			"  |   x",
+			"  |   y",
+		}, "\n") + "\n"
+
+		t.Run("Default", func(t tst.Test) {
+			test(t, singleLine)
+		})
+
+		t.Run("Option", func(t tst.Test) {
+			test(t, block, logftxt.MultilineLayoutBlock)
+		})
+
+		t.Run("Config", func(t tst.Test) {
+			cfg := *config
+			cfg.Layout.Multiline = logftxt.MultilineLayoutBlock
+			test(t, block, cfg)
+		})
+
+		t.Run("Theme", func(t tst.Test) {
+			theme, err := logftxt.ReadTheme(strings.NewReader(
+				"theme: {version: '1.0', items: [level, message, fields], settings: {multiline: block}}",
+			))
+			t.Expect(err).ToNot(tst.HaveOccurred())
+
+			enc := logftxt.NewEncoder(&logftxt.Config{}, envColor(false), theme)
+			buf := logf.NewBuffer()
+			t.Expect(enc.Encode(buf, logf.Entry{Text: "a\nb"})).ToSucceed()
+			t.Expect(buf.String()).ToEqual("a\n  | b\n")
+
+			cfg := logftxt.Config{}
+			cfg.Layout.Multiline = logftxt.MultilineLayoutSingleLine
+			enc = logftxt.NewEncoder(cfg, envColor(false), theme)
+			buf = logf.NewBuffer()
+			t.Expect(enc.Encode(buf, logf.Entry{Text: "a\nb"})).ToSucceed()
+			t.Expect(buf.String()).ToEqual("a\nb\n")
+		})
+
+		t.Run("NoFlatten", func(t tst.Test) {
+			enc := logftxt.NewEncoder(config, envColor(false), theme, logftxt.MultilineLayoutBlock, logftxt.FlattenObjects(false))
+			buf := logf.NewBuffer()
+			t.Expect(enc.Encode(buf, logf.Entry{Fields: []logf.Field{
+				logf.Object("o", asObject(logf.String("s", "x\ny"))),
+			}})).ToSucceed()
+			t.Expect(buf.String()).ToEqual(`Jan  1 00:00:00.000 |ERR| o={ s="x\ny" }` + "\n")
+		})
+
+		t.Run("Colors", func(t tst.Test) {
+			enc := logftxt.NewEncoder(config, logftxt.ColorAlways, theme, logftxt.MultilineLayoutBlock)
+			buf := logf.NewBuffer()
+			t.Expect(enc.Encode(buf, logf.Entry{Text: "a\nb", Fields: []logf.Field{logf.String("s", "c\nd")}})).ToSucceed()
+			t.Expect(buf.String()).ToEqual(
+				"\x1b[2mJan  1 00:00:00.000\x1b[0m \x1b[91;7m|ERR|\x1b[0m \x1b[1ma\x1b[0m\n" +
+					"  | \x1b[1mb\x1b[0m\n" +
+					"  | \x1b[32ms\x1b[0m\x1b[2m=\x1b[0m\n" +
+					"  |   c\n" +
+					"  |   d\n",
+			)
+		})
+	})
+
+	t.Run("ErrorTrace", func(t tst.Test) {
+		err := newMockTracedError("oops", nil)
+		entry := logf.Entry{
+			Text: "msg",
+			Fields: []logf.Field{
+				logf.Error(err),
+				logf.Object("o", asObject(logf.NamedError("e", fmt.Errorf("wrapped: %w", err)))),
+				logf.NamedError("plain", errors.New("plain")),
+				logf.Strings("a", []string{"x"}),
+			},
+		}
+		frames := mockTrace(err, "\n  |   %s %s:%d")
+
+		t.Run("Config", func(t tst.Test) {
+			cfg := logftxt.Config{}
+			cfg.Values.Error.Format = logftxt.ErrorFormatTrace
+
+			enc := logftxt.NewEncoder(cfg, envColor(false), theme)
+			buf := logf.NewBuffer()
+			t.Expect(enc.Encode(buf, entry)).ToSucceed()
+			t.Expect(buf.String()).ToEqual(
+				"|ERR| msg error={{ oops }} o.e={{ wrapped: oops }} plain={{ plain }} a=[ x ]" +
+					"\n  | error=" + frames +
+					"\n  | o.e=" + frames +
+					"\n",
+			)
+		})
+
+		t.Run("Option", func(t tst.Test) {
+			enc := logftxt.NewEncoder(&logftxt.Config{}, envColor(false), theme, logftxt.ErrorFormatTrace, logftxt.FlattenObjects(false))
+			buf := logf.NewBuffer()
+			t.Expect(enc.Encode(buf, entry)).ToSucceed()
+			t.Expect(buf.String()).ToEqual(
+				"|ERR| msg error={{ oops }} o={ e={{ wrapped: oops }} } plain={{ plain }} a=[ x ]" +
+					"\n  | error=" + frames +
+					"\n",
+			)
+		})
+
+		t.Run("Colors", func(t tst.Test) {
+			theme, err := logftxt.LoadBuiltInTheme("default")
+			t.Expect(err).ToNot(tst.HaveOccurred())
+
+			traced := newMockTracedError("oops", nil)
+			enc := logftxt.NewEncoder(&logftxt.Config{}, logftxt.ColorAlways, theme, logftxt.ErrorFormatTrace)
+			buf := logf.NewBuffer()
+			t.Expect(enc.Encode(buf, logf.Entry{Fields: []logf.Field{logf.Error(traced)}})).ToSucceed()
+			t.Expect(buf.String()).ToEqual(
+				"\x1b[2m\x1b[0m \x1b[91;7m[ERR]\x1b[0m \x1b[32merror\x1b[0m\x1b[2m=\x1b[0m\x1b[1m'\x1b[91moops\x1b[39m'\x1b[0m\n" +
+					"\x1b[2m  | \x1b[0m\x1b[32merror\x1b[0m\x1b[2m=\x1b[0m" +
+					mockTrace(traced, "\n\x1b[2m  | \x1b[0m  \x1b[2mat \x1b[0m%s\x1b[2m \x1b[36m(%s\x1b[39m\x1b[94m:%d)\x1b[39m\x1b[0m") +
+					"\n",
+			)
+		})
+	})
+
+	t.Run("LevelFormatting", func(t tst.Test) {
+		theme, err := logftxt.ReadTheme(strings.NewReader(strings.Join([]string{
+			"theme:",
+			"  version: '1.0'",
+			"  items: [logger, message, fields]",
+			"  formatting:",
+			"    line: {levels: {debug: {outer: {style: {modes: [faint]}}}}}",
+			"    logger: {outer: {suffix: ':'}, levels: {warning: {outer: {suffix: '!'}}}}",
+			"    message: {levels: {error: {outer: {style: {foreground: red, modes: [bold]}}}}}",
+			"    field: {separator: {text: '='}, levels: {debug: {separator: {text: ':'}}}}",
+		}, "\n")))
+		t.Expect(err).ToNot(tst.HaveOccurred())
+
+		entry := func(level logf.Level) logf.Entry {
+			return logf.Entry{
+				LoggerID:      1,
+				LoggerName:    "main",
+				Level:         level,
+				Text:          "msg",
+				DerivedFields: []logf.Field{logf.Int("d", 1)},
+				Fields:        []logf.Field{logf.Int("f", 2)},
+			}
+		}
+
+		t.Run("Plain", func(t tst.Test) {
+			enc := logftxt.NewEncoder(&logftxt.Config{}, envColor(false), theme)
+			buf := logf.NewBuffer()
+			t.Expect(enc.Encode(buf, entry(logf.LevelInfo))).ToSucceed()
+			t.Expect(enc.Encode(buf, entry(logf.LevelDebug))).ToSucceed()
+			t.Expect(enc.Encode(buf, entry(logf.LevelWarn))).ToSucceed()
+			t.Expect(buf.String()).ToEqual(strings.Join([]string{
+				"main: msg d=1 f=2",
+				"main: msg d:1 f:2",
+				"main! msg d=1 f=2",
+			}, "\n") + "\n")
+		})
+
+		t.Run("Colors", func(t tst.Test) {
+			enc := logftxt.NewEncoder(&logftxt.Config{}, logftxt.ColorAlways, theme)
+			buf := logf.NewBuffer()
+			t.Expect(enc.Encode(buf, entry(logf.LevelDebug))).ToSucceed()
+			t.Expect(enc.Encode(buf, entry(logf.LevelError))).ToSucceed()
+			t.Expect(buf.String()).ToEqual(
+				"\x1b[2mmain: msg d:1 f:2\x1b[0m\n" +
+					"main: \x1b[31;1mmsg\x1b[0m d=1 f=2\n",
+			)
+		})
+	})
+
+	t.Run("FieldRules", func(t tst.Test) {
+		theme, err := logftxt.ReadTheme(strings.NewReader(strings.Join([]string{
+			"theme:",
+			"  version: '1.0'",
+			"  items: [fields]",
+			"  formatting:",
+			"    field: {separator: {text: '='}}",
+			"    key: {separator: {text: '.'}}",
+			"    types: {number: {outer: {style: {foreground: blue}}}}",
+			"    fields:",
+			"      - match: {names: [request_id], globs: ['*_id']}",
+			"        value: {prefix: '<', suffix: '>', style: {foreground: yellow, modes: [+bold]}}",
+			"      - match: {names: [user_id], prefixes: [pass]}",
+			"        key: {style: {modes: [+faint]}}",
+			"        value: {style: {modes: [+faint]}}",
+		}, "\n")))
+		t.Expect(err).ToNot(tst.HaveOccurred())
+
+		entry := logf.Entry{Fields: []logf.Field{
+			logf.Int("request_id", 1),
+			logf.Int("user_id", 2),
+			logf.String("password", "x"),
+			logf.Int("n", 3),
+			logf.Object("o", asObject(logf.Int("trace_id", 4))),
+		}}
+
+		t.Run("Plain", func(t tst.Test) {
+			enc := logftxt.NewEncoder(&logftxt.Config{}, envColor(false), theme, logftxt.FlattenObjects(true))
+			buf := logf.NewBuffer()
+			t.Expect(enc.Encode(buf, entry)).ToSucceed()
+			t.Expect(buf.String()).ToEqual("request_id=<1> user_id=<2> password=x n=3 o.trace_id=<4>\n")
+		})
+
+		t.Run("Colors", func(t tst.Test) {
+			enc := logftxt.NewEncoder(&logftxt.Config{}, logftxt.ColorAlways, theme, logftxt.FlattenObjects(true))
+			buf := logf.NewBuffer()
+			t.Expect(enc.Encode(buf, logf.Entry{Fields: []logf.Field{entry.Fields[0], entry.Fields[2], entry.Fields[3]}})).ToSucceed()
+			t.Expect(buf.String()).ToEqual(
+				"request_id=\x1b[33;1m<1>\x1b[0m \x1b[2mpassword\x1b[0m=\x1b[2mx\x1b[0m n=\x1b[34m3\x1b[0m\n",
+			)
+		})
+	})
+
+	t.Run("Alignment", func(t tst.Test) {
+		newTheme := func(settings string) *logftxt.Theme {
+			theme, err := logftxt.ReadTheme(strings.NewReader(strings.Join([]string{
+				"theme:",
+				"  version: '1.0'",
+				"  items: [level, message, fields]",
+				"  settings: {alignment: " + settings + "}",
+				"  formatting:",
+				"    level: {debug: {text: D}, info: {text: I}, warning: {text: W}, error: {text: E}}",
+				"    message: {outer: {style: {modes: [bold]}}}",
+				"    field: {separator: {text: '='}}",
+			}, "\n")))
+			t.Expect(err).ToNot(tst.HaveOccurred())
+
+			return theme
+		}
+
+		encode := func(enc logf.Encoder, entries ...logf.Entry) string {
+			buf := logf.NewBuffer()
+			for _, entry := range entries {
+				t.Expect(enc.Encode(buf, entry)).ToSucceed()
+			}
+
+			return buf.String()
+		}
+
+		fields := func(keys ...string) []logf.Field {
+			result := make([]logf.Field, len(keys))
+			for i, key := range keys {
+				result[i] = logf.Int(key, i)
+			}
+
+			return result
+		}
+
+		t.Run("MessageWidth", func(t tst.Test) {
+			theme := newTheme("{message-width: 8}")
+			entries := []logf.Entry{
+				{Level: logf.LevelInfo, Text: "short", Fields: fields("a")},
+				{Level: logf.LevelInfo, Text: "very long message", Fields: fields("a")},
+				{Level: logf.LevelInfo, Fields: fields("a")},
+				{Level: logf.LevelInfo, Text: "no fields"},
+				{Level: logf.LevelInfo, Text: "x"},
+			}
+			expected := strings.Join([]string{
+				"I short    a=0",
+				"I very long message a=0",
+				"I          a=0",
+				"I no fields",
+				"I x",
+			}, "\n") + "\n"
+
+			t.Expect(encode(logftxt.NewEncoder(&logftxt.Config{}, envColor(false), theme), entries...)).ToEqual(expected)
+
+			colored := encode(logftxt.NewEncoder(&logftxt.Config{}, logftxt.ColorAlways, theme), entries...)
+			t.Expect(strings.Contains(colored, "\x1b[1mshort\x1b[0m    a")).ToBeTrue()
+		})
+
+		t.Run("KeyMemory", func(t tst.Test) {
+			enc := logftxt.NewEncoder(&logftxt.Config{}, envColor(false), newTheme("{key-memory: 2}"))
+			t.Expect(encode(enc,
+				logf.Entry{Level: logf.LevelInfo, Text: "long message", Fields: fields("a", "b")},
+				logf.Entry{Level: logf.LevelInfo, Text: "msg", Fields: fields("a", "b")},
+				logf.Entry{Level: logf.LevelInfo, Text: "msg", Fields: fields("b", "c")},
+				logf.Entry{Level: logf.LevelInfo, Text: "msg", Fields: fields("c")},
+				logf.Entry{Level: logf.LevelInfo, Text: "msg", Fields: fields("c")},
+				logf.Entry{Level: logf.LevelInfo, Text: "msg", Fields: fields("a", "b")},
+			)).ToEqual(strings.Join([]string{
+				"I long message a=0 b=1",
+				"I msg          a=0 b=1",
+				"I msg              b=0 c=1",
+				"I msg                  c=0",
+				"I msg                  c=0",
+				"I msg a=0 b=1",
+			}, "\n") + "\n")
+		})
+
+		t.Run("DerivedFields", func(t tst.Test) {
+			enc := logftxt.NewEncoder(&logftxt.Config{}, envColor(false), newTheme("{key-memory: 8}"))
+			entry := func(text string) logf.Entry {
+				return logf.Entry{LoggerID: 1, Level: logf.LevelInfo, Text: text, DerivedFields: fields("d"), Fields: fields("a")}
+			}
+			t.Expect(encode(enc, entry("long message"), entry("msg"))).ToEqual(
+				"I long message d=0 a=0\nI msg          d=0 a=0\n",
+			)
+		})
+
+		t.Run("Invalid", func(t tst.Test) {
+			t.Expect(logftxt.ReadTheme(strings.NewReader(
+				"theme: {version: '1.0', items: [message], settings: {alignment: {message-width: -1}}}",
+			))).ToFail()
+			t.Expect(logftxt.ReadTheme(strings.NewReader(
+				"theme: {version: '1.0', items: [message], settings: {alignment: {key-memory: -1}}}",
+			))).ToFail()
+		})
+	})
+
+	t.Run("CallerHyperlink", func(t tst.Test) {
+		theme, err := logftxt.ReadTheme(strings.NewReader("theme: {version: '1.0', items: [message, caller]}"))
+		t.Expect(err).ToNot(tst.HaveOccurred())
+
+		cfg := logftxt.Config{}
+		cfg.Caller.Format = logftxt.CallerFormatHyperlink
+		cfg.Caller.URL = "editor://{path}:{line}"
+
+		entry := logf.Entry{Text: "msg", Caller: logf.EntryCaller{File: "/src/pkg/test.go", Line: 42, Specified: true}}
+		environment := func(hyperlinks string) logftxt.Environment {
+			return func(name string) (string, bool) {
+				if name == "FORCE_HYPERLINK" {
+					return hyperlinks, true
+				}
+
+				return "", false
+			}
+		}
+
+		t.Run("Enabled", func(t tst.Test) {
+			enc := logftxt.NewEncoder(cfg, environment("1"), logftxt.ColorAlways, theme)
+			buf := logf.NewBuffer()
+			t.Expect(enc.Encode(buf, entry)).ToSucceed()
+			t.Expect(buf.String()).ToEqual("msg \x1b]8;;editor:///src/pkg/test.go:42\x1b\\pkg/test.go:42\x1b]8;;\x1b\\\n")
+		})
+
+		t.Run("Option", func(t tst.Test) {
+			enc := logftxt.NewEncoder(&logftxt.Config{}, environment("1"), logftxt.ColorAlways, theme, logftxt.CallerFormatHyperlink)
+			buf := logf.NewBuffer()
+			t.Expect(enc.Encode(buf, entry)).ToSucceed()
+			t.Expect(buf.String()).ToEqual("msg \x1b]8;;file:///src/pkg/test.go\x1b\\pkg/test.go:42\x1b]8;;\x1b\\\n")
+		})
+
+		t.Run("NoColors", func(t tst.Test) {
+			enc := logftxt.NewEncoder(cfg, environment("1"), logftxt.ColorNever, theme)
+			buf := logf.NewBuffer()
+			t.Expect(enc.Encode(buf, entry)).ToSucceed()
+			t.Expect(buf.String()).ToEqual("msg pkg/test.go:42\n")
+		})
+
+		t.Run("NotSupported", func(t tst.Test) {
+			enc := logftxt.NewEncoder(cfg, environment("0"), logftxt.ColorAlways, theme)
+			buf := logf.NewBuffer()
+			t.Expect(enc.Encode(buf, entry)).ToSucceed()
+			t.Expect(buf.String()).ToEqual("msg pkg/test.go:42\n")
+		})
+	})
+
+	t.Run("LineWidth", func(t tst.Test) {
+		theme, err := logftxt.ReadTheme(strings.NewReader(strings.Join([]string{
+			"theme:",
+			"  version: '1.0'",
+			"  items: [message, fields]",
+			"  formatting:",
+			"    field: {separator: {text: '='}}",
+			"    types: {ellipsis: {outer: {style: {modes: [faint]}}}}",
+		}, "\n")))
+		t.Expect(err).ToNot(tst.HaveOccurred())
+
+		encode := func(enc logf.Encoder, fields ...logf.Field) string {
+			buf := logf.NewBuffer()
+			t.Expect(enc.Encode(buf, logf.Entry{Text: "msg", Fields: fields})).ToSucceed()
+
+			return buf.String()
+		}
+
+		t.Run("Truncate", func(t tst.Test) {
+			enc := logftxt.NewEncoder(&logftxt.Config{}, envColor(false), theme, logftxt.LineWidth(20), logftxt.LineOverflowTruncate)
+			t.Expect(encode(enc, logf.String("s", "abcdefghijklmnopqrstuvwxyz"))).ToEqual("msg s=abcdefghijklm…\n")
+			t.Expect(encode(enc, logf.String("s", "abcdefghijklm"))).ToEqual("msg s=abcdefghijklm\n")
+			t.Expect(encode(enc, logf.String("s", "日本語日本語日本語"))).ToEqual("msg s=日本語日本語…\n")
+			t.Expect(encode(enc, logf.String("a", "abcdefgh"), logf.String("b", "abcdefghijklmn"))).ToEqual("msg a=abcdefgh b=ab…\n")
+			t.Expect(encode(enc, logf.String("a", "abcdefghijk"), logf.String("b", "abcdefghijklmn"))).ToEqual("msg a=abcdefghijk\n")
+			t.Expect(encode(enc, logf.Int("i", 1234567890), logf.Int("j", 1234567890))).ToEqual("msg i=1234567890\n")
+		})
+
+		t.Run("TruncateEscaped", func(t tst.Test) {
+			enc := logftxt.NewEncoder(&logftxt.Config{}, envColor(false), theme, logftxt.LineWidth(30), logftxt.LineOverflowTruncate)
+			t.Expect(encode(enc, logf.String("s", strings.Repeat("\x01", 10)))).ToEqual(`msg s="` + strings.Repeat(`\u0001`, 3) + `"…` + "\n")
+			t.Expect(encode(enc, logf.String("s", "a b c d e f g h i j k l m n o p"))).ToEqual(`msg s="a b c d e f g h i j k"…` + "\n")
+			t.Expect(encode(enc, logf.String("s", "ab\ncd\nef\ngh\nij\nkl\nmn\nop"))).ToEqual(`msg s="ab\ncd\nef\ngh\nij\nk"…` + "\n")
+		})
+
+		t.Run("TruncateHardLimit", func(t tst.Test) {
+			enc := logftxt.NewEncoder(&logftxt.Config{}, envColor(false), theme, logftxt.LineWidth(40), logftxt.LineOverflowTruncate)
+			line := encode(enc,
+				logf.String("a", "first value"),
+				logf.String("b", "\x01\x02\x03\x04\x05\x06\x07\x08"),
+				logf.Ints("c", []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}),
+				logf.String("d", "日本語日本語日本語"),
+			)
+			t.Expect(line).ToEqual(`msg a="first value" b="\u0001\u0002"…` + "\n")
+			t.Expect(utf8.RuneCountInString(strings.TrimSuffix(line, "\n")) <= 40).ToBeTrue()
+		})
+
+		t.Run("TruncateWithCaller", func(t tst.Test) {
+			theme, err := logftxt.ReadTheme(strings.NewReader(strings.Join([]string{
+				"theme:",
+				"  version: '1.0'",
+				"  items: [message, fields, caller]",
+				"  formatting:",
+				"    field: {separator: {text: '='}}",
+				"    caller: {outer: {prefix: '@ '}}",
+			}, "\n")))
+			t.Expect(err).ToNot(tst.HaveOccurred())
+
+			enc := logftxt.NewEncoder(&logftxt.Config{}, envColor(false), theme, logftxt.LineWidth(30), logftxt.LineOverflowTruncate)
+			buf := logf.NewBuffer()
+			t.Expect(enc.Encode(buf, logf.Entry{
+				Text:   "msg",
+				Fields: []logf.Field{logf.String("s", strings.Repeat("a", 40))},
+				Caller: logf.EntryCaller{File: "/src/pkg/file.go", Line: 7, Specified: true},
+			})).ToSucceed()
+			t.Expect(buf.String()).ToEqual("msg s=aaaaaaa… @ pkg/file.go:7\n")
+		})
+
+		t.Run("TruncateColors", func(t tst.Test) {
+			enc := logftxt.NewEncoder(&logftxt.Config{}, logftxt.ColorAlways, theme, logftxt.LineWidth(20), logftxt.LineOverflowTruncate)
+			t.Expect(encode(enc, logf.String("s", "abcdefghijklmnopqrstuvwxyz"))).ToEqual("msg s=abcdefghijklm\x1b[2m…\x1b[0m\n")
+		})
+
+		t.Run("Wrap", func(t tst.Test) {
+			cfg := logftxt.Config{}
+			cfg.Layout.LineWidth = 20
+			cfg.Layout.Overflow = logftxt.LineOverflowWrap
+
+			enc := logftxt.NewEncoder(cfg, envColor(false), theme)
+			t.Expect(encode(enc,
+				logf.String("a", "aaaaaaaa"),
+				logf.String("b", "bbbbbbbb"),
+				logf.String("c", "cc"),
+				logf.String("d", strings.Repeat("d", 30)),
+				logf.String("e", "e"),
+			)).ToEqual(strings.Join([]string{
+				"msg a=aaaaaaaa",
+				"  | b=bbbbbbbb c=cc",
+				"  | d=" + strings.Repeat("d", 30),
+				"  | e=e",
+			}, "\n") + "\n")
+		})
+
+		t.Run("WrapWide", func(t tst.Test) {
+			enc := logftxt.NewEncoder(&logftxt.Config{}, envColor(false), theme, logftxt.LineWidth(16), logftxt.LineOverflowWrap)
+			t.Expect(encode(enc, logf.String("a", "日本語"), logf.String("b", "🚀"))).ToEqual("msg a=日本語\n  | b=🚀\n")
+			t.Expect(encode(enc, logf.String("a", "abcdef"), logf.String("b", "a"))).ToEqual("msg a=abcdef b=a\n")
+		})
+
+		t.Run("OptionOverridesConfig", func(t tst.Test) {
+			cfg := logftxt.Config{}
+			cfg.Layout.LineWidth = 10
+			cfg.Layout.Overflow = logftxt.LineOverflowWrap
+
+			enc := logftxt.NewEncoder(cfg, envColor(false), theme, logftxt.LineWidth(80), logftxt.LineOverflowNone)
+			t.Expect(encode(enc, logf.String("a", "aaaaaaaa"), logf.String("b", "bbbbbbbb"))).ToEqual("msg a=aaaaaaaa b=bbbbbbbb\n")
+		})
+
+		t.Run("NotTerminal", func(t tst.Test) {
+			buf := logf.NewBuffer()
+			appender := logftxt.NewAppender(buf, envColor(false), theme, logftxt.LineOverflowWrap)
+			t.Expect(appender.Append(logf.Entry{Text: "msg", Fields: []logf.Field{logf.String("a", strings.Repeat("a", 100))}})).ToSucceed()
+			t.Expect(appender.Flush()).ToSucceed()
+			t.Expect(buf.String()).ToEqual("msg a=" + strings.Repeat("a", 100) + "\n")
+		})
+	})
+
+	t.Run("CustomLevels", func(t tst.Test) {
+		theme, err := logftxt.ReadTheme(strings.NewReader(strings.Join([]string{
+			"theme:",
+			"  version: '1.0'",
+			"  items: [level, message]",
+			"  formatting:",
+			"    level:",
+			"      all: {outer: {prefix: '[', suffix: ']'}}",
+			"      debug: {text: DBG}",
+			"      info: {text: INF}",
+			"      warning: {text: WRN}",
+			"      error: {text: ERR}",
+			"      trace: {text: TRC, inner: {style: {foreground: bright-black}}}",
+			"      -1: {text: FTL}",
+		}, "\n")))
+		t.Expect(err).ToNot(tst.HaveOccurred())
+
+		encode := func(enc logf.Encoder, levels ...logf.Level) string {
+			buf := logf.NewBuffer()
+			for _, level := range levels {
+				t.Expect(enc.Encode(buf, logf.Entry{Level: level, Text: "msg"})).ToSucceed()
+			}
+
+			return buf.String()
+		}
+
+		const levelTrace = logf.LevelDebug + 1
+
+		t.Run("Names", func(t tst.Test) {
+			enc := logftxt.NewEncoder(&logftxt.Config{}, envColor(false), theme, logftxt.LevelNames{levelTrace: "trace"})
+			t.Expect(encode(enc, levelTrace, logf.LevelDebug, logf.LevelError, logf.LevelError-1, logf.LevelDebug+2)).ToEqual(
+				"[TRC] msg\n[DBG] msg\n[ERR] msg\n[FTL] msg\n[DBG] msg\n",
+			)
+		})
+
+		t.Run("NoNames", func(t tst.Test) {
+			enc := logftxt.NewEncoder(&logftxt.Config{}, envColor(false), theme)
+			t.Expect(encode(enc, levelTrace, logf.LevelError-1)).ToEqual("[DBG] msg\n[FTL] msg\n")
+		})
+
+		t.Run("Rename", func(t tst.Test) {
+			enc := logftxt.NewEncoder(&logftxt.Config{}, envColor(false), theme, logftxt.LevelNames{logf.LevelDebug: "trace"})
+			t.Expect(encode(enc, logf.LevelDebug, logf.LevelInfo)).ToEqual("[TRC] msg\n[INF] msg\n")
+		})
+
+		t.Run("Colors", func(t tst.Test) {
+			enc := logftxt.NewEncoder(&logftxt.Config{}, logftxt.ColorAlways, theme, logftxt.LevelNames{levelTrace: "trace"})
+			t.Expect(encode(enc, levelTrace)).ToEqual("[\x1b[90mTRC\x1b[0m] msg\n")
+		})
+
+		t.Run("BuiltInThemes", func(t tst.Test) {
+			for _, name := range []string{"default", "fancy"} {
+				theme, err := logftxt.LoadBuiltInTheme(name)
+				t.Expect(err).ToNot(tst.HaveOccurred())
+
+				enc := logftxt.NewEncoder(&logftxt.Config{}, envColor(false), theme, logftxt.LevelNames{levelTrace: "trace"})
+				t.Expect(strings.Contains(encode(enc, levelTrace), "TRC")).ToBeTrue()
+			}
+		})
+
+		t.Run("Invalid", func(t tst.Test) {
+			t.Expect(logftxt.ReadTheme(strings.NewReader(
+				"theme: {version: '1.0', items: [level], formatting: {level: {200: {text: X}}}}",
+			))).ToFail()
+		})
+	})
+
+	t.Run("RedactFields", func(t tst.Test) {
+		entry := logf.Entry{
+			LoggerID:      1,
+			DerivedFields: []logf.Field{logf.String("token", "t1")},
+			Fields: []logf.Field{
+				logf.Object("http", asObject(
+					logf.Object("headers", asObject(logf.String("authorization", "a1"), logf.String("accept", "*/*"))),
+					logf.String("user.password", "p1"),
+				)),
+				logf.String("secret_key", "s1\ns2"),
+				logf.Object("credentials", asObject(logf.String("user", "u1"))),
+				logf.Array("list", asArray(asObject(logf.String("token", "t2")))),
+			},
+		}
+
+		t.Run("Flatten", func(t tst.Test) {
+			cfg := logftxt.Config{}
+			cfg.Redact.Fields = []string{"token", "headers.authorization"}
+			cfg.Layout.Multiline = logftxt.MultilineLayoutBlock
+
+			enc := logftxt.NewEncoder(cfg, envColor(false), theme, logftxt.RedactFields("*.password", "*secret*"), logftxt.RedactFields("credentials"))
+			buf := logf.NewBuffer()
+			t.Expect(enc.Encode(buf, entry)).ToSucceed()
+			t.Expect(enc.Encode(buf, entry)).ToSucceed()
+			expected := "|ERR| token=*** http.headers.authorization=*** http.headers.accept=*/* http.user.password=***" +
+				" secret_key=*** credentials=*** list=[ { token=*** } ]\n"
+			t.Expect(buf.String()).ToEqual(expected + expected)
+		})
+
+		t.Run("NoFlatten", func(t tst.Test) {
+			enc := logftxt.NewEncoder(&logftxt.Config{}, envColor(false), theme,
+				logftxt.RedactFields("http.headers.authorization", "list.token"), logftxt.FlattenObjects(false))
+			buf := logf.NewBuffer()
+			t.Expect(enc.Encode(buf, entry)).ToSucceed()
+			t.Expect(buf.String()).ToEqual(
+				"|ERR| token=t1 http={ headers={ authorization=***, accept=*/* }, user.password=p1 }" +
+					` secret_key="s1\ns2" credentials={ user=u1 } list=[ { token=*** } ]` + "\n",
+			)
+		})
+
+		t.Run("Colors", func(t tst.Test) {
+			theme, err := logftxt.ReadTheme(strings.NewReader(
+				"theme: {version: '1.0', items: [fields], formatting: {" +
+					"field: {separator: {text: '='}}, types: {redacted: {text: '<hidden>', outer: {style: {modes: [faint]}}}}}}",
+			))
+			t.Expect(err).ToNot(tst.HaveOccurred())
+
+			enc := logftxt.NewEncoder(&logftxt.Config{}, logftxt.ColorAlways, theme, logftxt.RedactFields("token"))
+			buf := logf.NewBuffer()
+			t.Expect(enc.Encode(buf, logf.Entry{Fields: []logf.Field{logf.String("token", "t")}})).ToSucceed()
+			t.Expect(buf.String()).ToEqual("token=\x1b[2m<hidden>\x1b[0m\n")
+		})
+
+		t.Run("InvalidPattern", func(t tst.Test) {
+			enc := logftxt.NewEncoder(&logftxt.Config{}, envColor(false), theme, logftxt.RedactFields("a..b", "[", "token"))
+			buf := logf.NewBuffer()
+			t.Expect(enc.Encode(buf, logf.Entry{Fields: []logf.Field{logf.String("token", "t")}})).ToSucceed()
+			t.Expect(strings.Count(buf.String(), "ignoring invalid redacted field pattern")).ToEqual(2)
+			t.Expect(strings.HasSuffix(buf.String(), "|ERR| token=***\n")).ToBeTrue()
+		})
+	})
+
+	t.Run("ErrorChain", func(t tst.Test) {
+		base := errors.New("no such file")
+		wrapped := fmt.Errorf("open config: %w", base)
+		joined := errors.Join(wrapped, errors.New("timeout"))
+		entry := logf.Entry{
+			Text: "msg",
+			Fields: []logf.Field{
+				logf.NamedError("single", base),
+				logf.NamedError("wrapped", fmt.Errorf("load: %w", wrapped)),
+				logf.NamedError("joined", fmt.Errorf("start: %w", joined)),
+				logf.NamedError("renamed", fmt.Errorf("failed (%w)", base)),
+			},
+		}
+
+		t.Run("Config", func(t tst.Test) {
+			cfg := logftxt.Config{}
+			cfg.Values.Error.Format = logftxt.ErrorFormatChain
+
+			enc := logftxt.NewEncoder(cfg, envColor(false), theme)
+			buf := logf.NewBuffer()
+			t.Expect(enc.Encode(buf, entry)).ToSucceed()
+			t.Expect(buf.String()).ToEqual(
+				"|ERR| msg single={{ no such file }}" +
+					" wrapped=[ {{ load }}, {{ open config }}, {{ no such file }} ]" +
+					" joined=[ {{ start }}, [ {{ open config }}, {{ no such file }} ], {{ timeout }} ]" +
+					" renamed=[ {{ failed (no such file) }}, {{ no such file }} ]\n",
+			)
+		})
+
+		t.Run("MaxDepth", func(t tst.Test) {
+			cfg := logftxt.Config{}
+			cfg.Values.Error.MaxDepth = 2
+
+			enc := logftxt.NewEncoder(cfg, envColor(false), theme, logftxt.ErrorFormatChain)
+			buf := logf.NewBuffer()
+			t.Expect(enc.Encode(buf, logf.Entry{Fields: []logf.Field{
+				logf.NamedError("wrapped", fmt.Errorf("run: %w", fmt.Errorf("load: %w", wrapped))),
+				entry.Fields[2],
+			}})).ToSucceed()
+			t.Expect(buf.String()).ToEqual(
+				"|ERR| wrapped=[ {{ run }}, {{ load }}, {{ open config: no such file }} ]" +
+					" joined=[ {{ start }}, {{ open config: no such file }}, {{ timeout }} ]\n",
+			)
+		})
+	})
 }
 
 // ---
@@ -700,6 +1402,24 @@ func (f *fieldsAsObject) EncodeLogfObject(enc logf.FieldEncoder) error {
 
 // ---
 
+func asArray(objects ...logf.ObjectEncoder) logf.ArrayEncoder {
+	return objectsAsArray(objects)
+}
+
+// ---
+
+type objectsAsArray []logf.ObjectEncoder
+
+func (a objectsAsArray) EncodeLogfArray(enc logf.TypeEncoder) error {
+	for _, object := range a {
+		enc.EncodeTypeObject(object)
+	}
+
+	return nil
+}
+
+// ---
+
 type anyStruct struct {
 	a int
 }
@@ -1,6 +1,9 @@
 package logftxt
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // ---
 
@@ -30,3 +33,15 @@ func (e ErrFileNotFound) Is(err error) bool {
 func (e ErrFileNotFound) Unwrap() error {
 	return e.cause
 }
+
+// ---
+
+// ThemeCycleError is an error that is returned in case themes extend each other in a cycle.
+type ThemeCycleError struct {
+	Chain []string
+}
+
+// Error returns error message.
+func (e ThemeCycleError) Error() string {
+	return fmt.Sprintf("theme inheritance cycle %s", strings.Join(e.Chain, " -> "))
+}
@@ -0,0 +1,114 @@
+package logftxt
+
+import (
+	"path"
+	"strings"
+
+	"github.com/pamburus/logftxt/internal/pkg/themecfg"
+)
+
+func newFieldRules(cfg []themecfg.FieldRule) fieldRules {
+	var rules fieldRules
+
+	for i, rule := range cfg {
+		for _, name := range rule.Match.Names {
+			if rules.names == nil {
+				rules.names = make(map[string]int)
+			}
+
+			if _, ok := rules.names[name]; !ok {
+				rules.names[name] = i
+			}
+		}
+
+		for _, glob := range rule.Match.Globs {
+			rules.patterns = append(rules.patterns, fieldPattern{i, glob, true})
+		}
+
+		for _, prefix := range rule.Match.Prefixes {
+			rules.patterns = append(rules.patterns, fieldPattern{i, prefix, false})
+		}
+
+		rules.items = append(rules.items, fmtFieldRule{
+			key:   newFormat(rule.Key),
+			value: newFormat(rule.Value),
+		})
+	}
+
+	return rules
+}
+
+// ---
+
+// fieldRules is a compiled list of field formatting rules.
+// If several rules match the same key, the first one wins.
+type fieldRules struct {
+	names    map[string]int
+	patterns []fieldPattern
+	items    []fmtFieldRule
+}
+
+func (r *fieldRules) match(key string) *fmtFieldRule {
+	if len(r.items) == 0 {
+		return nil
+	}
+
+	index, ok := r.names[key]
+	if !ok {
+		index = len(r.items)
+	}
+
+	// Patterns are ordered by rule index, so there is no need to check patterns of rules following the already matched one.
+	for i := range r.patterns {
+		if r.patterns[i].rule >= index {
+			break
+		}
+
+		if r.patterns[i].matches(key) {
+			index = r.patterns[i].rule
+
+			break
+		}
+	}
+
+	if index == len(r.items) {
+		return nil
+	}
+
+	return &r.items[index]
+}
+
+// ---
+
+type fieldPattern struct {
+	rule    int
+	pattern string
+	glob    bool
+}
+
+func (p *fieldPattern) matches(key string) bool {
+	if p.glob {
+		ok, _ := path.Match(p.pattern, key)
+
+		return ok
+	}
+
+	return strings.HasPrefix(key, p.pattern)
+}
+
+// ---
+
+type fmtFieldRule struct {
+	key   format
+	value format
+}
+
+// ---
+
+func (f *format) encodeOverriding(e *entryEncoder, encodeInner func()) {
+	e.styler.Override(f.style, e.buf, func() {
+		e.buf.AppendString(f.prefix)
+		encodeInner()
+		e.buf.AppendString(f.suffix)
+	})
+}
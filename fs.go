@@ -48,6 +48,9 @@ func (v FSOption) toEncoderOptions(o *encoderOptions) {
 
 func (v FSOption) toDomain(d *domain) {
 	d.fs = v.value
+	if d.wrapFS != nil {
+		d.fs = d.wrapFS(d.fs)
+	}
 }
 
 // ---
@@ -7,6 +7,7 @@ require (
 	github.com/pamburus/go-ansi-esc v0.5.0
 	github.com/pamburus/go-tst v0.6.0
 	github.com/ssgreg/logf v1.4.2
+	golang.org/x/term v0.26.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
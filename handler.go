@@ -0,0 +1,280 @@
+package logftxt
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ssgreg/logf"
+)
+
+// NewHandler returns a new slog.Handler that writes log records to w
+// in the same textual form as the logf.Appender returned by NewAppender.
+//
+// Handler options are interpreted the same way as by slog.NewTextHandler
+// except that ReplaceAttr is applied only to the record and handler attributes,
+// built-in time, level, message and source values are rendered by the theme.
+// Passing nil opts is the same as passing empty slog.HandlerOptions.
+//
+// Levels below slog.LevelDebug are mapped to [LevelTrace], levels starting at slog.LevelError+4
+// and slog.LevelError+8 are mapped to [LevelFatal] and [LevelPanic], all of them are named with [DefaultLevelNames],
+// so they are displayed using the theme's formatting for these levels if it has one
+// or as the nearest standard level otherwise.
+// Time is not output for records with zero time as slog.Handler requires.
+func NewHandler(w io.Writer, opts *slog.HandlerOptions, options ...AppenderOption) slog.Handler {
+	if opts == nil {
+		opts = &slog.HandlerOptions{}
+	}
+
+	o := defaultAppenderOptions().With(append([]AppenderOption{DefaultLevelNames()}, options...)).resolved(w)
+	o.omitZeroTime = true
+
+	return &handler{
+		shared: &handlerShared{
+			w:    w,
+			enc:  newLogfEncoder(o.encoderOptions),
+			opts: *opts,
+		},
+	}
+}
+
+// ---
+
+type handler struct {
+	shared   *handlerShared
+	loggerID int32
+	fields   []logf.Field
+	groups   []string
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.shared.opts.Level != nil {
+		minLevel = h.shared.opts.Level.Level()
+	}
+
+	return level >= minLevel
+}
+
+func (h *handler) Handle(_ context.Context, record slog.Record) error {
+	entry := logf.Entry{
+		LoggerID:      h.loggerID,
+		DerivedFields: h.fields,
+		Level:         slogLevelToLogf(record.Level),
+		Time:          record.Time,
+		Text:          record.Message,
+	}
+
+	if record.NumAttrs() != 0 {
+		fields := make([]logf.Field, 0, record.NumAttrs())
+		record.Attrs(func(attr slog.Attr) bool {
+			fields = h.shared.appendAttr(fields, h.groups, attr)
+
+			return true
+		})
+		entry.Fields = h.shared.wrapFields(fields, h.groups)
+	}
+
+	if h.shared.opts.AddSource && record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		entry.Caller = logf.EntryCaller{
+			PC:        frame.PC,
+			File:      frame.File,
+			Line:      frame.Line,
+			Specified: true,
+		}
+	}
+
+	return h.shared.write(entry)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	fields := make([]logf.Field, 0, len(attrs))
+	for _, attr := range attrs {
+		fields = h.shared.appendAttr(fields, h.groups, attr)
+	}
+
+	result := *h
+	result.loggerID = nextHandlerID.Add(1)
+	result.fields = append(h.fields[:len(h.fields):len(h.fields)], h.shared.wrapFields(fields, h.groups)...)
+
+	return &result
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	result := *h
+	result.groups = append(h.groups[:len(h.groups):len(h.groups)], name)
+
+	return &result
+}
+
+// ---
+
+type handlerShared struct {
+	w    io.Writer
+	enc  logf.Encoder
+	opts slog.HandlerOptions
+	mu   sync.Mutex
+}
+
+func (s *handlerShared) write(entry logf.Entry) error {
+	buf := handlerBufferPool.Get().(*logf.Buffer) //nolint:forcetypeassert // pool contains only buffers
+	defer func() {
+		buf.Reset()
+		handlerBufferPool.Put(buf)
+	}()
+
+	err := s.enc.Encode(buf, entry)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.w.Write(buf.Bytes())
+
+	return err //nolint:wrapcheck // error is passed as is from the writer
+}
+
+func (s *handlerShared) appendAttr(fields []logf.Field, groups []string, attr slog.Attr) []logf.Field {
+	attr.Value = attr.Value.Resolve()
+
+	if s.opts.ReplaceAttr != nil && attr.Value.Kind() != slog.KindGroup {
+		attr = s.opts.ReplaceAttr(groups, attr)
+		attr.Value = attr.Value.Resolve()
+	}
+
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	value := attr.Value
+
+	switch value.Kind() {
+	case slog.KindString:
+		return append(fields, logf.String(attr.Key, value.String()))
+	case slog.KindInt64:
+		return append(fields, logf.Int64(attr.Key, value.Int64()))
+	case slog.KindUint64:
+		return append(fields, logf.Uint64(attr.Key, value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, logf.Float64(attr.Key, value.Float64()))
+	case slog.KindBool:
+		return append(fields, logf.Bool(attr.Key, value.Bool()))
+	case slog.KindDuration:
+		return append(fields, logf.Duration(attr.Key, value.Duration()))
+	case slog.KindTime:
+		return append(fields, logf.Time(attr.Key, value.Time()))
+	case slog.KindGroup:
+		attrs := value.Group()
+		if len(attrs) == 0 {
+			return fields
+		}
+
+		if attr.Key == "" {
+			for _, attr := range attrs {
+				fields = s.appendAttr(fields, groups, attr)
+			}
+
+			return fields
+		}
+
+		inner := make([]logf.Field, 0, len(attrs))
+		for _, a := range attrs {
+			inner = s.appendAttr(inner, append(groups[:len(groups):len(groups)], attr.Key), a)
+		}
+
+		if len(inner) == 0 {
+			return fields
+		}
+
+		return append(fields, logf.Object(attr.Key, fieldsObject(inner)))
+	case slog.KindAny, slog.KindLogValuer:
+		fallthrough
+	default:
+		return append(fields, logf.Any(attr.Key, value.Any()))
+	}
+}
+
+func (s *handlerShared) wrapFields(fields []logf.Field, groups []string) []logf.Field {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	for i := len(groups) - 1; i >= 0; i-- {
+		fields = []logf.Field{logf.Object(groups[i], fieldsObject(fields))}
+	}
+
+	return fields
+}
+
+// ---
+
+type fieldsObject []logf.Field
+
+func (o fieldsObject) EncodeLogfObject(enc logf.FieldEncoder) error {
+	for _, field := range o {
+		field.Accept(enc)
+	}
+
+	return nil
+}
+
+// ---
+
+func slogLevelToLogf(level slog.Level) logf.Level {
+	switch {
+	case level >= slogLevelPanic:
+		return LevelPanic
+	case level >= slogLevelFatal:
+		return LevelFatal
+	case level >= slog.LevelError:
+		return logf.LevelError
+	case level >= slog.LevelWarn:
+		return logf.LevelWarn
+	case level >= slog.LevelInfo:
+		return logf.LevelInfo
+	case level >= slog.LevelDebug:
+		return logf.LevelDebug
+	default:
+		return LevelTrace
+	}
+}
+
+// ---
+
+// Levels of slog that are mapped to the custom levels, see [NewHandler].
+const (
+	slogLevelFatal = slog.LevelError + 4
+	slogLevelPanic = slog.LevelError + 8
+)
+
+// ---
+
+var (
+	nextHandlerID     atomic.Int32
+	handlerBufferPool = sync.Pool{
+		New: func() any {
+			return logf.NewBufferWithCapacity(1024)
+		},
+	}
+)
+
+// ---
+
+var (
+	_ slog.Handler       = (*handler)(nil)
+	_ logf.ObjectEncoder = fieldsObject(nil)
+)
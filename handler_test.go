@@ -0,0 +1,204 @@
+package logftxt_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/slogtest"
+	"time"
+
+	"github.com/pamburus/go-tst/tst"
+	"github.com/pamburus/logftxt"
+	"github.com/pamburus/logftxt/internal/pkg/pathx"
+)
+
+func TestHandler(tt *testing.T) {
+	t := tst.New(tt)
+
+	config, err := logftxt.LoadConfig("./encoder_test.config.yml")
+	t.Expect(err).ToNot(tst.HaveOccurred())
+
+	theme := logftxt.NewThemeRef(pathx.ExplicitlyRelative("encoder_test.theme.yml"))
+	someTime := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+
+	test := func(opts *slog.HandlerOptions, f func(*slog.Logger)) string {
+		buf := &bytes.Buffer{}
+		handler := logftxt.NewHandler(buf, opts, config, theme, logftxt.ColorNever, logftxt.FlattenObjects(true))
+		f(slog.New(fixedTimeHandler{handler, someTime}))
+
+		return buf.String()
+	}
+
+	t.Run("Levels", func(t tst.Test) {
+		output := test(&slog.HandlerOptions{Level: slog.LevelDebug - 4}, func(logger *slog.Logger) {
+			logger.Log(context.Background(), slog.LevelDebug-4, "trace")
+			logger.Debug("debug")
+			logger.Info("info")
+			logger.Warn("warn")
+			logger.Error("error")
+			logger.Log(context.Background(), slog.LevelError+4, "fatal")
+		})
+		t.Expect(output).ToEqual(strings.Join([]string{
+			"Jan  2 03:04:05.000 |DBG| trace",
+			"Jan  2 03:04:05.000 |DBG| debug",
+			"Jan  2 03:04:05.000 |INF| info",
+			"Jan  2 03:04:05.000 |WRN| warn",
+			"Jan  2 03:04:05.000 |ERR| error",
+			"Jan  2 03:04:05.000 |ERR| fatal",
+		}, "\n") + "\n")
+	})
+
+	t.Run("CustomLevels", func(t tst.Test) {
+		theme, err := logftxt.ReadTheme(strings.NewReader(strings.Join([]string{
+			"theme:",
+			"  version: '1.0'",
+			"  items: [level, message]",
+			"  formatting:",
+			"    level: {panic: {text: PNC}, fatal: {text: FTL}, error: {text: ERR}, debug: {text: DBG}, trace: {text: TRC}}",
+		}, "\n")))
+		t.Expect(err).ToNot(tst.HaveOccurred())
+
+		buf := &bytes.Buffer{}
+		logger := slog.New(logftxt.NewHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug - 8}, config, theme, logftxt.ColorNever))
+		for _, level := range []slog.Level{slog.LevelDebug - 8, slog.LevelDebug, slog.LevelError + 3, slog.LevelError + 4, slog.LevelError + 8, slog.LevelError + 12} {
+			logger.Log(context.Background(), level, "msg")
+		}
+		t.Expect(buf.String()).ToEqual("TRC msg\nDBG msg\nERR msg\nFTL msg\nPNC msg\nPNC msg\n")
+	})
+
+	t.Run("ZeroTime", func(t tst.Test) {
+		buf := &bytes.Buffer{}
+		handler := logftxt.NewHandler(buf, nil, config, theme, logftxt.ColorNever)
+		t.Expect(handler.Handle(context.Background(), slog.NewRecord(time.Time{}, slog.LevelInfo, "msg", 0))).ToSucceed()
+		t.Expect(buf.String()).ToEqual("|INF| msg\n")
+	})
+
+	t.Run("Enabled", func(t tst.Test) {
+		output := test(nil, func(logger *slog.Logger) {
+			logger.Debug("debug")
+			logger.Info("info")
+		})
+		t.Expect(output).ToEqual("Jan  2 03:04:05.000 |INF| info\n")
+	})
+
+	t.Run("Attrs", func(t tst.Test) {
+		output := test(nil, func(logger *slog.Logger) {
+			logger.Info("msg",
+				slog.String("s", "a b"),
+				slog.Int("i", 42),
+				slog.Uint64("u", 43),
+				slog.Float64("f", 4.5),
+				slog.Bool("b", true),
+				slog.Duration("d", time.Second),
+				slog.Time("t", someTime),
+				slog.Any("e", errors.New("oops")),
+				slog.Any("n", nil),
+				slog.Attr{},
+			)
+		})
+		t.Expect(output).ToEqual(
+			`Jan  2 03:04:05.000 |INF| msg s="a b" i=42 u=43 f=4.5 b=true d=00:00:01 t=[[Jan  2 03:04:05.000]] e={{ oops }} n=null` + "\n",
+		)
+	})
+
+	t.Run("Groups", func(t tst.Test) {
+		output := test(nil, func(logger *slog.Logger) {
+			logger = logger.With("a", 1).WithGroup("g").With("b", 2).WithGroup("h")
+			logger.Info("msg", "c", 3, slog.Group("i", "d", 4), slog.Group("", "e", 5), slog.Group("j"))
+			logger.Info("msg")
+		})
+		t.Expect(output).ToEqual(strings.Join([]string{
+			"Jan  2 03:04:05.000 |INF| msg a=1 g.b=2 g.h.c=3 g.h.i.d=4 g.h.e=5",
+			"Jan  2 03:04:05.000 |INF| msg a=1 g.b=2",
+		}, "\n") + "\n")
+	})
+
+	t.Run("ReplaceAttr", func(t tst.Test) {
+		output := test(&slog.HandlerOptions{ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == "secret" {
+				return slog.String(a.Key, strings.Join(append(groups, "***"), "/"))
+			}
+
+			return a
+		}}, func(logger *slog.Logger) {
+			logger.WithGroup("g").Info("msg", "secret", "value", "other", "value")
+		})
+		t.Expect(output).ToEqual("Jan  2 03:04:05.000 |INF| msg g.secret=g/*** g.other=value\n")
+	})
+
+	t.Run("AddSource", func(t tst.Test) {
+		output := test(&slog.HandlerOptions{AddSource: true}, func(logger *slog.Logger) {
+			logger.Info("msg")
+		})
+		t.Expect(strings.Contains(output, "/handler_test.go:")).ToBeTrue()
+	})
+}
+
+func TestHandlerConformance(tt *testing.T) {
+	t := tst.New(tt)
+
+	theme, err := logftxt.ReadTheme(strings.NewReader(strings.Join([]string{
+		"theme:",
+		"  version: '1.0'",
+		"  items: [timestamp, level, message, fields]",
+		"  settings: {output-mode: logfmt}",
+	}, "\n")))
+	t.Expect(err).ToNot(tst.HaveOccurred())
+
+	var buf bytes.Buffer
+
+	slogtest.Run(tt, func(*testing.T) slog.Handler {
+		buf.Reset()
+
+		return logftxt.NewHandler(&buf, nil, &logftxt.Config{}, theme, logftxt.ColorNever, logftxt.TimeLayout(time.RFC3339).Timestamp())
+	}, func(tt *testing.T) map[string]any {
+		t := tst.New(tt)
+
+		pairs := parseLogfmt(buf.String())
+		t.Expect(pairs).ToNot(tst.BeNil())
+
+		// Attributes of groups are flattened, so they are unflattened to nested maps.
+		result := map[string]any{}
+		for _, pair := range pairs {
+			m := result
+			path := strings.Split(pair[0], ".")
+			for _, key := range path[:len(path)-1] {
+				inner, ok := m[key].(map[string]any)
+				if !ok {
+					inner = map[string]any{}
+					m[key] = inner
+				}
+
+				m = inner
+			}
+
+			m[path[len(path)-1]] = pair[1]
+		}
+
+		return result
+	})
+}
+
+// ---
+
+type fixedTimeHandler struct {
+	slog.Handler
+	ts time.Time
+}
+
+func (h fixedTimeHandler) Handle(ctx context.Context, record slog.Record) error {
+	record.Time = h.ts
+
+	return h.Handler.Handle(ctx, record) //nolint:wrapcheck // error wrapping is not needed here
+}
+
+func (h fixedTimeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return fixedTimeHandler{h.Handler.WithAttrs(attrs), h.ts}
+}
+
+func (h fixedTimeHandler) WithGroup(name string) slog.Handler {
+	return fixedTimeHandler{h.Handler.WithGroup(name), h.ts}
+}
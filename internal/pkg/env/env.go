@@ -3,6 +3,8 @@ package env
 
 import (
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -20,6 +22,12 @@ func ColorSetting(lookup LookupFunc) Color {
 			return ColorAlways
 		case strings.EqualFold(setting, string(ColorNever)):
 			return ColorNever
+		case strings.EqualFold(setting, string(Color16)):
+			return Color16
+		case strings.EqualFold(setting, string(Color256)):
+			return Color256
+		case strings.EqualFold(setting, string(ColorTrueColor)):
+			return ColorTrueColor
 		}
 	}
 
@@ -30,6 +38,73 @@ func ColorSetting(lookup LookupFunc) Color {
 	return ColorAuto
 }
 
+// ColorDepth checks COLORTERM and TERM environment variables to find out how many colors the terminal supports.
+// It returns ColorTrueColor, Color256, Color16 or ColorAuto if TERM is not set.
+//
+// Terminals supporting true color usually set COLORTERM, so other terminals are assumed to support
+// 256 colors if they are known to do so, like xterm, screen or tmux, and 16 colors otherwise.
+func ColorDepth(lookup LookupFunc) Color {
+	if value, ok := lookup(envColorTerm); ok && (value == "truecolor" || value == "24bit") {
+		return ColorTrueColor
+	}
+
+	value, ok := lookup(envTerm)
+	if !ok || value == "" {
+		return ColorAuto
+	}
+
+	switch {
+	case strings.HasSuffix(value, "-direct"), slices.Contains(modernTerms, value):
+		return ColorTrueColor
+	case strings.HasSuffix(value, "-256color"):
+		return Color256
+	case strings.HasSuffix(value, "-16color"), strings.HasSuffix(value, "-color"):
+		return Color16
+	}
+
+	family, _, _ := strings.Cut(value, "-")
+	switch family {
+	case "xterm", "screen", "tmux", "putty":
+		return Color256
+	}
+
+	return Color16
+}
+
+// Hyperlinks checks environment variables to find out whether the terminal supports OSC 8 hyperlinks.
+//
+// FORCE_HYPERLINK environment variable overrides the detection, a value of `0` disables hyperlinks
+// and any other value enables them.
+func Hyperlinks(lookup LookupFunc) bool {
+	if value, ok := lookup(envForceHyperlink); ok {
+		return value != "0"
+	}
+
+	if isSome(lookup(envWindowsTerminalSession)) || isSome(lookup(envKonsoleVersion)) || isSome(lookup(envDomTerm)) {
+		return true
+	}
+
+	if value, ok := lookup(envVTEVersion); ok {
+		version, err := strconv.Atoi(value)
+		if err == nil && version >= 5000 {
+			return true
+		}
+	}
+
+	if value, ok := lookup(envTermProgram); ok {
+		switch value {
+		case "iTerm.app", "WezTerm", "vscode", "Hyper", "ghostty", "Tabby":
+			return true
+		}
+	}
+
+	if value, ok := lookup(envTerm); ok && slices.Contains(modernTerms, value) {
+		return true
+	}
+
+	return false
+}
+
 // Config returns configuration file path specified via environment variables.
 func Config(lookup LookupFunc) (string, bool) {
 	return lookup(envConfig)
@@ -40,16 +115,62 @@ func Theme(lookup LookupFunc) (string, bool) {
 	return lookup(envTheme)
 }
 
+// ThemeMode checks LOGFTXT_THEME_MODE and COLORFGBG environment variables to find out
+// whether the terminal has dark or light background.
+//
+// COLORFGBG is set by some terminals like rxvt or Konsole in `fg;bg` or `fg;default;bg` format
+// where `bg` is the index of the background color among the 16 basic colors.
+func ThemeMode(lookup LookupFunc) Mode {
+	if value, ok := lookup(envThemeMode); ok {
+		switch {
+		case strings.EqualFold(value, string(ModeDark)):
+			return ModeDark
+		case strings.EqualFold(value, string(ModeLight)):
+			return ModeLight
+		}
+	}
+
+	if value, ok := lookup(envColorFgBg); ok {
+		bg, err := strconv.Atoi(value[strings.LastIndexByte(value, ';')+1:])
+		if err == nil {
+			switch {
+			case bg == 7 || (bg >= 9 && bg <= 15):
+				return ModeLight
+			case bg >= 0 && bg <= 8:
+				return ModeDark
+			}
+		}
+	}
+
+	return ModeAuto
+}
+
 // ---
 
 // Color is a color setting that can be specified via environment variables.
 type Color string
 
 // Valid values for Color setting.
+// Values Color16, Color256 and ColorTrueColor mean ColorAlways with the given color depth.
+const (
+	ColorAuto      Color = "auto"
+	ColorAlways    Color = "always"
+	ColorNever     Color = "never"
+	Color16        Color = "16"
+	Color256       Color = "256"
+	ColorTrueColor Color = "truecolor"
+)
+
+// ---
+
+// Mode is a theme mode setting that can be specified via environment variables.
+type Mode string
+
+// Valid values for Mode setting.
 const (
-	ColorAuto   Color = "auto"
-	ColorAlways Color = "always"
-	ColorNever  Color = "never"
+	ModeAuto  Mode = "auto"
+	ModeDark  Mode = "dark"
+	ModeLight Mode = "light"
 )
 
 // ---
@@ -57,7 +178,7 @@ const (
 // Unset removes all known environment variables from the current process.
 // Can be useful for unit tests to avoid dependency on environment.
 func Unset() {
-	for _, v := range []string{envNoColor, envColorSetting, envConfig, envTheme} {
+	for _, v := range []string{envNoColor, envColorSetting, envConfig, envTheme, envThemeMode} {
 		err := os.Unsetenv(v)
 		if err != nil {
 			panic(err)
@@ -83,4 +204,18 @@ const (
 	envColorSetting = "LOGFTXT_COLOR"
 	envConfig       = "LOGFTXT_CONFIG"
 	envTheme        = "LOGFTXT_THEME"
+	envThemeMode    = "LOGFTXT_THEME_MODE"
+
+	envForceHyperlink         = "FORCE_HYPERLINK"
+	envWindowsTerminalSession = "WT_SESSION"
+	envKonsoleVersion         = "KONSOLE_VERSION"
+	envDomTerm                = "DOMTERM"
+	envVTEVersion             = "VTE_VERSION"
+	envTermProgram            = "TERM_PROGRAM"
+	envTerm                   = "TERM"
+	envColorTerm              = "COLORTERM"
+	envColorFgBg              = "COLORFGBG"
 )
+
+// modernTerms lists TERM values specific to terminals supporting both true color and hyperlinks.
+var modernTerms = []string{"xterm-kitty", "xterm-ghostty", "wezterm", "alacritty", "foot", "foot-extra"}
@@ -0,0 +1,40 @@
+package env_test
+
+import (
+	"testing"
+
+	"github.com/pamburus/go-tst/tst"
+
+	"github.com/pamburus/logftxt/internal/pkg/env"
+)
+
+func TestColorDepth(tt *testing.T) {
+	t := tst.New(tt)
+
+	depth := func(vars ...string) env.Color {
+		return env.ColorDepth(func(name string) (string, bool) {
+			for i := 0; i+1 < len(vars); i += 2 {
+				if vars[i] == name {
+					return vars[i+1], true
+				}
+			}
+
+			return "", false
+		})
+	}
+
+	t.Expect(depth()).ToEqual(env.ColorAuto)
+	t.Expect(depth("TERM", "")).ToEqual(env.ColorAuto)
+	t.Expect(depth("TERM", "xterm")).ToEqual(env.Color256)
+	t.Expect(depth("TERM", "screen")).ToEqual(env.Color256)
+	t.Expect(depth("TERM", "tmux")).ToEqual(env.Color256)
+	t.Expect(depth("TERM", "screen.xterm-new")).ToEqual(env.Color16)
+	t.Expect(depth("TERM", "xterm-256color")).ToEqual(env.Color256)
+	t.Expect(depth("TERM", "xterm-color")).ToEqual(env.Color16)
+	t.Expect(depth("TERM", "xterm-direct")).ToEqual(env.ColorTrueColor)
+	t.Expect(depth("TERM", "xterm-kitty")).ToEqual(env.ColorTrueColor)
+	t.Expect(depth("TERM", "linux")).ToEqual(env.Color16)
+	t.Expect(depth("TERM", "vt100")).ToEqual(env.Color16)
+	t.Expect(depth("TERM", "xterm", "COLORTERM", "truecolor")).ToEqual(env.ColorTrueColor)
+	t.Expect(depth("TERM", "screen", "COLORTERM", "24bit")).ToEqual(env.ColorTrueColor)
+}
@@ -0,0 +1,71 @@
+// Package layout describes textual layout of log lines produced with a logftxt theme,
+// so that packages of the module other than logftxt itself can read such lines back.
+// The layout of a theme is returned by its Layout method.
+package layout
+
+import (
+	"github.com/ssgreg/logf"
+
+	"github.com/pamburus/logftxt/internal/pkg/themecfg"
+)
+
+// ---
+
+// Layout describes prefixes, suffixes and separators of items of log lines.
+type Layout struct {
+	Items     []themecfg.Item
+	Timestamp Item
+	Key       Item
+	Caller    Item
+	Array     Item
+	Object    Item
+	Error     Item
+	Time      Item
+	Null      Item
+
+	// Gutter starts each line following the first one of an entry, like lines of multi-line blocks,
+	// stack trace frames and wrapped fields.
+	Gutter Item
+
+	// BlockIndent is indentation of the lines of field values and stack trace frames in multi-line blocks.
+	BlockIndent string
+
+	// Frame holds formatting of stack trace frames.
+	Frame Frame
+
+	// Levels holds formatting that can be overridden for particular levels, indexed by the standard levels.
+	Levels [4]Level
+
+	// LevelItems holds formatting of level items of the standard levels and levels having custom formatting.
+	LevelItems map[logf.Level]Item
+}
+
+// Level holds formatting of items that can be overridden for particular log levels.
+type Level struct {
+	Line    Item
+	Logger  Item
+	Message Item
+	Field   Item
+}
+
+// Frame holds formatting of a stack trace frame and its parts.
+type Frame struct {
+	Item
+	Function Item
+	File     Item
+	Line     Item
+}
+
+// Item holds formatting of an item.
+type Item struct {
+	Outer     Format
+	Inner     Format
+	Separator string
+	Text      string
+}
+
+// Format holds prefix and suffix surrounding an item.
+type Format struct {
+	Prefix string
+	Suffix string
+}
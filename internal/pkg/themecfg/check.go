@@ -0,0 +1,168 @@
+package themecfg
+
+import (
+	"fmt"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/pamburus/logftxt/internal/pkg/yamlcheck"
+)
+
+// Check checks the theme source for unknown keys, invalid values and references to unknown palette colors.
+//
+// The palette contains colors of the theme it extends, if any.
+// Items are the items of the complete theme, if known, formatting sections of other items are reported as unused.
+func (s *Source) Check(palette Palette, items []Item) []yamlcheck.Issue {
+	root := s.node.Content[0]
+	theme := mappingValue(root, "theme")
+	used := make(map[string]bool)
+	r := resolver{palette.UpdatedBy(s.Palette), used, nil}
+
+	resolved := *theme
+	resolved.Content = append([]*yaml.Node(nil), theme.Content...)
+
+	for i := 0; i+1 < len(resolved.Content); i += 2 {
+		key, value := resolved.Content[i], *resolved.Content[i+1]
+		resolved.Content[i+1] = &value
+
+		switch key.Value {
+		case "palette":
+		case "variants":
+			if value.Kind != yaml.MappingNode {
+				continue
+			}
+
+			value.Content = append([]*yaml.Node(nil), value.Content...)
+
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				mode, variant := value.Content[j], *value.Content[j+1]
+				value.Content[j+1] = &variant
+
+				vr := resolver{r.palette.UpdatedBy(s.variants[Mode(mode.Value)]), used, nil}
+				vr.resolve(&variant)
+				r.errs = append(r.errs, vr.errs...)
+			}
+		default:
+			r.resolve(&value)
+		}
+	}
+
+	doc := s.node
+	doc.Content = []*yaml.Node{withValue(root, "theme", &resolved)}
+
+	issues := yamlcheck.Check(&doc, reflect.TypeFor[document]())
+
+	for _, err := range r.errs {
+		issues = append(issues, yamlcheck.At(err.node, "", fmt.Sprintf("unknown palette color %q", err.name)))
+	}
+
+	issues = append(issues, s.unusedColors(theme, palette, used)...)
+
+	if items != nil {
+		issues = append(issues, unusedSections(theme, items)...)
+	}
+
+	yamlcheck.Sort(issues)
+
+	return issues
+}
+
+// Locate returns position of the value located by the given path of mapping keys in the theme document.
+// If there is no such value, position of the closest existing parent is returned.
+func (s *Source) Locate(keys ...string) (int, int) {
+	node := s.node.Content[0]
+
+	for i := range keys {
+		value := yamlcheck.Lookup(&s.node, keys[:i+1]...)
+		if value == nil {
+			break
+		}
+
+		node = value
+	}
+
+	return node.Line, node.Column
+}
+
+// unusedColors reports palette colors that are neither referenced by the theme nor override colors of the base palette.
+func (s *Source) unusedColors(theme *yaml.Node, base Palette, used map[string]bool) []yamlcheck.Issue {
+	var issues []yamlcheck.Issue
+
+	check := func(node *yaml.Node, path string) {
+		if node == nil || node.Kind != yaml.MappingNode {
+			return
+		}
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			name := node.Content[i].Value
+			if _, ok := base[name]; !ok && !used[name] {
+				message := fmt.Sprintf("palette color %q is not used", name)
+				issues = append(issues, yamlcheck.Warning(node.Content[i], yamlcheck.Join(path, name), message))
+			}
+		}
+	}
+
+	check(mappingValue(theme, "palette"), "theme.palette")
+
+	if variants := mappingValue(theme, "variants"); variants != nil && variants.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(variants.Content); i += 2 {
+			mode := variants.Content[i].Value
+			check(mappingValue(variants.Content[i+1], "palette"), "theme.variants."+mode+".palette")
+		}
+	}
+
+	return issues
+}
+
+// unusedSections reports formatting sections of items that are not displayed.
+func unusedSections(theme *yaml.Node, items []Item) []yamlcheck.Issue {
+	formatting := mappingValue(theme, "formatting")
+	if formatting == nil || formatting.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	displayed := make(map[Item]bool, len(items))
+	for _, item := range items {
+		displayed[item] = true
+	}
+
+	var issues []yamlcheck.Issue
+
+	for i := 0; i+1 < len(formatting.Content); i += 2 {
+		key := formatting.Content[i]
+
+		item, ok := sectionItems[key.Value]
+		if ok && !displayed[item] {
+			issues = append(issues, yamlcheck.Warning(
+				key,
+				"theme.formatting."+key.Value,
+				fmt.Sprintf("section is not used because %q is not in `items`", item),
+			))
+		}
+	}
+
+	return issues
+}
+
+// ---
+
+// document describes structure of a theme configuration file.
+type document struct {
+	Theme struct {
+		Theme    Theme            `yaml:",inline"`
+		Variants map[Mode]Variant `yaml:"variants"`
+	} `yaml:"theme"`
+}
+
+// sectionItems maps formatting sections to the items they are used by.
+var sectionItems = map[string]Item{
+	"timestamp": ItemTimestamp,
+	"level":     ItemLevel,
+	"logger":    ItemLogger,
+	"message":   ItemMessage,
+	"field":     ItemFields,
+	"key":       ItemFields,
+	"fields":    ItemFields,
+	"caller":    ItemCaller,
+}
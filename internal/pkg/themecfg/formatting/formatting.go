@@ -2,7 +2,12 @@
 package formatting
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
 
 	"github.com/pamburus/go-ansi-esc/sgr"
 )
@@ -75,15 +80,114 @@ func (f Format) UpdatedBy(other Format) Format {
 
 // ---
 
-// Level is a log level formatting configuration.
-type Level struct {
-	All     Item `yaml:"all"`
+// LevelItem is an item that can be overridden for particular log levels.
+type LevelItem struct {
+	Item   `yaml:",inline"`
+	Levels Levels `yaml:"levels"`
+}
+
+// UpdatedBy returns a copy of i updated by other.
+func (i LevelItem) UpdatedBy(other LevelItem) LevelItem {
+	i.Item = i.Item.UpdatedBy(other.Item)
+	i.Levels = i.Levels.UpdatedBy(other.Levels)
+
+	return i
+}
+
+// ---
+
+// Levels contains overrides of an item for particular log levels.
+type Levels struct {
 	Debug   Item `yaml:"debug"`
 	Info    Item `yaml:"info"`
 	Warning Item `yaml:"warning"`
 	Error   Item `yaml:"error"`
 }
 
+// UpdatedBy returns a copy of l updated by other.
+func (l Levels) UpdatedBy(other Levels) Levels {
+	l.Debug = l.Debug.UpdatedBy(other.Debug)
+	l.Info = l.Info.UpdatedBy(other.Info)
+	l.Warning = l.Warning.UpdatedBy(other.Warning)
+	l.Error = l.Error.UpdatedBy(other.Error)
+
+	return l
+}
+
+// ---
+
+// Level is a log level formatting configuration.
+//
+// Keys are level names like `debug` or `trace` or numeric level values like `4`.
+// The item with key `all` is applied to all levels before the level specific one.
+type Level map[string]Item
+
+// LevelAll is a key of the item in Level that is applied to all levels.
+const LevelAll = "all"
+
+// For returns the item for a level with the given name and numeric value updated by the common item.
+// The item is looked up by the name first and then by the numeric value.
+// The second returned value is false if there is no level specific item.
+func (l Level) For(name string, value int) (Item, bool) {
+	item, ok := Item{}, false
+	if name != "" {
+		item, ok = l[name]
+	}
+
+	if !ok {
+		item, ok = l[strconv.Itoa(value)]
+	}
+
+	return l[LevelAll].UpdatedBy(item), ok
+}
+
+// UpdatedBy returns a copy of l with items updated by items of other having the same keys.
+func (l Level) UpdatedBy(other Level) Level {
+	if len(other) == 0 {
+		return l
+	}
+
+	result := make(Level, len(l)+len(other))
+	maps.Copy(result, l)
+
+	for key, item := range other {
+		result[key] = result[key].UpdatedBy(item)
+	}
+
+	return result
+}
+
+// Values returns numeric values of the levels that have items with numeric keys.
+func (l Level) Values() []int {
+	var values []int
+
+	for key := range l {
+		if value, err := strconv.Atoi(key); err == nil {
+			values = append(values, value)
+		}
+	}
+
+	slices.Sort(values)
+
+	return values
+}
+
+// Validate checks that l is valid.
+func (l Level) Validate() error {
+	for key := range l {
+		if key == "" {
+			return errors.New("empty level key")
+		}
+
+		// Log levels are 8-bit signed integers.
+		if value, err := strconv.Atoi(key); err == nil && (value < math.MinInt8 || value > math.MaxInt8) {
+			return fmt.Errorf("level %q is out of range", key)
+		}
+	}
+
+	return nil
+}
+
 // ---
 
 // Style includes background color, foreground color and a set of modes.
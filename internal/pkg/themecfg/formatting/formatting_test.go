@@ -321,3 +321,61 @@ func TestItem(tt *testing.T) {
 		))
 	})
 }
+
+func TestLevel(tt *testing.T) {
+	t := tst.New(tt)
+
+	level := formatting.Level{
+		"all":   {Outer: formatting.Format{Prefix: "[", Suffix: "]"}},
+		"info":  {Text: "INF"},
+		"trace": {Text: "TRC"},
+		"4":     {Text: "L4"},
+		"-1":    {Text: "FTL"},
+	}
+
+	t.Run("For", func(t tst.Test) {
+		item, ok := level.For("info", 2)
+		t.Expect(ok).To(tst.BeTrue())
+		t.Expect(item).To(tst.Equal(formatting.Item{Outer: formatting.Format{Prefix: "[", Suffix: "]"}, Text: "INF"}))
+
+		item, ok = level.For("trace", 4)
+		t.Expect(ok).To(tst.BeTrue())
+		t.Expect(item.Text).To(tst.Equal("TRC"))
+
+		item, ok = level.For("verbose", 4)
+		t.Expect(ok).To(tst.BeTrue())
+		t.Expect(item.Text).To(tst.Equal("L4"))
+
+		item, ok = level.For("", -1)
+		t.Expect(ok).To(tst.BeTrue())
+		t.Expect(item.Text).To(tst.Equal("FTL"))
+
+		item, ok = level.For("debug", 3)
+		t.Expect(ok).To(tst.BeFalse())
+		t.Expect(item).To(tst.Equal(formatting.Item{Outer: formatting.Format{Prefix: "[", Suffix: "]"}}))
+	})
+
+	t.Run("Values", func(t tst.Test) {
+		t.Expect(level.Values()).To(tst.Equal([]int{-1, 4}))
+		t.Expect(formatting.Level{}.Values()).To(tst.BeZero())
+	})
+
+	t.Run("UpdatedBy", func(t tst.Test) {
+		updated := level.UpdatedBy(formatting.Level{
+			"info":  {Outer: formatting.Format{Prefix: "<"}},
+			"debug": {Text: "DBG"},
+		})
+		t.Expect(updated["info"]).To(tst.Equal(formatting.Item{Outer: formatting.Format{Prefix: "<"}, Text: "INF"}))
+		t.Expect(updated["debug"]).To(tst.Equal(formatting.Item{Text: "DBG"}))
+		t.Expect(updated["trace"]).To(tst.Equal(level["trace"]))
+		t.Expect(level["debug"]).To(tst.BeZero())
+		t.Expect(formatting.Level(nil).UpdatedBy(nil)).To(tst.BeZero())
+	})
+
+	t.Run("Validate", func(t tst.Test) {
+		t.Expect(level.Validate()).ToSucceed()
+		t.Expect(formatting.Level{"": {}}.Validate()).ToFail()
+		t.Expect(formatting.Level{"128": {}}.Validate()).ToFail()
+		t.Expect(formatting.Level{"-129": {}}.Validate()).ToFail()
+	})
+}
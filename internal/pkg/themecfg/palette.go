@@ -0,0 +1,168 @@
+package themecfg
+
+import (
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/pamburus/go-ansi-esc/sgr"
+)
+
+// Palette is a palette configuration section.
+// It defines named colors that can be referenced in `foreground` and `background` styles as `$name`.
+type Palette map[string]Color
+
+// UpdatedBy returns a copy of p updated by colors of other.
+func (p Palette) UpdatedBy(other Palette) Palette {
+	if len(other) == 0 {
+		return p
+	}
+
+	result := make(Palette, len(p)+len(other))
+	maps.Copy(result, p)
+	maps.Copy(result, other)
+
+	return result
+}
+
+// resolve replaces references to palette colors in styles of the node with the colors.
+func (p Palette) resolve(node *yaml.Node) error {
+	r := resolver{palette: p}
+	r.resolve(node)
+
+	if len(r.errs) != 0 {
+		return r.errs[0]
+	}
+
+	return nil
+}
+
+// ---
+
+// resolver replaces references to palette colors in styles with the colors.
+// It makes copies of the nodes it changes, so the original nodes are left intact.
+// References to unknown colors are replaced with the default color and reported as errors.
+// Names of the referenced colors are collected if used is not nil.
+type resolver struct {
+	palette Palette
+	used    map[string]bool
+	errs    []*colorRefError
+}
+
+func (r *resolver) resolve(node *yaml.Node) {
+	if node.Kind == yaml.DocumentNode || node.Kind == yaml.SequenceNode {
+		r.resolveAll(node)
+
+		return
+	}
+
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+
+	node.Content = append([]*yaml.Node(nil), node.Content...)
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		if name, ok := colorRef(key, value); ok {
+			resolved := *value
+			resolved.Value = sgr.Default.String()
+			resolved.Tag = "!!str"
+			node.Content[i+1] = &resolved
+
+			color, ok := r.palette[name]
+			if !ok {
+				r.errs = append(r.errs, &colorRefError{value, name})
+
+				continue
+			}
+
+			if r.used != nil {
+				r.used[name] = true
+			}
+
+			text, err := color.MarshalText()
+			if err == nil {
+				resolved.Value = string(text)
+			}
+
+			continue
+		}
+
+		if key.Value == "palette" {
+			continue
+		}
+
+		resolved := *value
+		node.Content[i+1] = &resolved
+
+		r.resolve(&resolved)
+	}
+}
+
+func (r *resolver) resolveAll(node *yaml.Node) {
+	node.Content = append([]*yaml.Node(nil), node.Content...)
+
+	for i, item := range node.Content {
+		resolved := *item
+		node.Content[i] = &resolved
+
+		r.resolve(&resolved)
+	}
+}
+
+// ---
+
+type colorRefError struct {
+	node *yaml.Node
+	name string
+}
+
+func (e *colorRefError) Error() string {
+	return fmt.Sprintf("line %d: unknown palette color %q", e.node.Line, e.name)
+}
+
+// colorRef returns name of the palette color if the value of the key is a reference to a palette color.
+func colorRef(key, value *yaml.Node) (string, bool) {
+	if value.Kind != yaml.ScalarNode || (key.Value != "foreground" && key.Value != "background") {
+		return "", false
+	}
+
+	return strings.CutPrefix(value.Value, "$")
+}
+
+// ---
+
+// Color is a color defined in the palette.
+// Besides formats supported by sgr.Color like `bright-blue`, `#rrggbb` or `#xx`,
+// it can be specified as a decimal 256-color palette index like `208`.
+type Color sgr.Color
+
+// MarshalText implements encoding.TextMarshaler interface.
+func (c Color) MarshalText() ([]byte, error) {
+	return sgr.Color(c).MarshalText()
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler interface.
+func (c *Color) UnmarshalText(text []byte) error {
+	if index, err := strconv.ParseUint(string(text), 10, 8); err == nil {
+		*c = Color(sgr.PaletteColor(index).Color())
+
+		return nil
+	}
+
+	var color sgr.Color
+
+	err := color.UnmarshalText(text)
+	if err != nil {
+		return err
+	}
+
+	*c = Color(color)
+
+	return nil
+}
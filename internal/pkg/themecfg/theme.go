@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"path"
+	"slices"
 
 	"gopkg.in/yaml.v3"
 
@@ -14,45 +16,176 @@ import (
 
 // ---
 
-// Load loads Theme from the given reader.
-func Load(reader io.Reader) (*Theme, error) {
-	fail := func(err error) (*Theme, error) {
+// Parse parses theme Source from the given reader.
+func Parse(reader io.Reader) (*Source, error) {
+	fail := func(err error) (*Source, error) {
 		return nil, err
 	}
 
-	var content struct {
-		Theme *Theme `yaml:"theme"`
+	var src Source
+
+	err := yaml.NewDecoder(reader).Decode(&src.node)
+	if err != nil {
+		return fail(fmt.Errorf("failed to parse theme: %w", err))
+	}
+
+	var header struct {
+		Theme *struct {
+			Version  string  `yaml:"version"`
+			Extends  string  `yaml:"extends"`
+			Palette  Palette `yaml:"palette"`
+			Variants map[Mode]struct {
+				Palette Palette `yaml:"palette"`
+			} `yaml:"variants"`
+		} `yaml:"theme"`
 	}
 
-	err := yaml.NewDecoder(reader).Decode(&content)
+	err = src.node.Decode(&header)
 	if err != nil {
 		return fail(fmt.Errorf("failed to parse theme: %w", err))
 	}
 
-	if content.Theme == nil {
+	if header.Theme == nil {
 		return fail(errors.New("invalid theme format"))
 	}
 
-	if content.Theme.Version != "1.0" {
+	if header.Theme.Version != "1.0" {
 		return fail(errors.New("unsupported theme version"))
 	}
 
-	err = content.Theme.Validate()
+	src.Extends = header.Theme.Extends
+	src.Palette = header.Theme.Palette
+	src.variants = make(map[Mode]Palette, len(header.Theme.Variants))
+
+	for mode, variant := range header.Theme.Variants {
+		src.variants[mode] = variant.Palette
+	}
+
+	return &src, nil
+}
+
+// ---
+
+// Source is a parsed theme configuration file that is not decoded yet
+// because references to palette colors in its styles can be resolved only after the palette is complete,
+// i.e. merged with palettes of the themes extending it and palette of the theme it extends.
+type Source struct {
+	Extends string
+	Palette Palette
+
+	node     yaml.Node
+	variants map[Mode]Palette
+}
+
+// Modes returns sorted list of modes the theme has variants for.
+func (s *Source) Modes() []Mode {
+	modes := make([]Mode, 0, len(s.variants))
+	for mode := range s.variants {
+		modes = append(modes, mode)
+	}
+
+	slices.Sort(modes)
+
+	return modes
+}
+
+// PaletteFor returns palette of the theme updated by palette of its variant for the given mode.
+func (s *Source) PaletteFor(mode Mode) Palette {
+	return s.Palette.UpdatedBy(s.variants[mode])
+}
+
+// Decode decodes Theme resolving references to palette colors in its styles using the given palette
+// and applies the variant for the given mode, if any.
+//
+// The theme is not validated because it can be incomplete if it extends another theme,
+// so it should be validated after it is merged on top of the base theme, see [Theme.UpdatedBy].
+func (s *Source) Decode(palette Palette, mode Mode) (*Theme, error) {
+	for variant := range s.variants {
+		if variant == ModeDefault || variant.Validate() != nil {
+			return nil, fmt.Errorf("theme is invalid: `variants` has invalid mode %q", variant)
+		}
+	}
+
+	node := mappingValue(s.node.Content[0], "theme")
+
+	var theme Theme
+
+	err := decode(withoutKey(node, "variants"), palette, &theme)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &theme
+
+	if node := mappingValue(mappingValue(node, "variants"), string(mode)); mode != ModeDefault && node != nil {
+		var variant Variant
+
+		err := decode(node, palette, &variant)
+		if err != nil {
+			return nil, fmt.Errorf("variant %q: %w", mode, err)
+		}
+
+		result = variant.applyTo(result)
+	}
+
+	result.Palette = palette
+	result.Modes = s.Modes()
+
+	return result, nil
+}
+
+func decode(node *yaml.Node, palette Palette, target any) error {
+	resolved := *node
+
+	err := palette.resolve(&resolved)
+	if err != nil {
+		return fmt.Errorf("failed to resolve palette colors: %w", err)
+	}
+
+	err = resolved.Decode(target)
 	if err != nil {
-		return fail(fmt.Errorf("theme is invalid: %w", err))
+		return fmt.Errorf("failed to parse theme: %w", err)
 	}
 
-	return content.Theme, nil
+	return nil
 }
 
 // ---
 
 // Theme contains theme configuration that can be described in a YAML file.
+//
+// Extends references a base theme the same way as the theme is referenced in the configuration,
+// i.e. by '@' prefix following a built-in theme name or by a path to a theme file.
+// Palette defines named colors that can be referenced in styles as `$name`.
+// Modes lists modes the theme or any theme it extends has variants for, the variants are applied by [Source.Decode].
 type Theme struct {
 	Version    string     `yaml:"version"`
+	Extends    string     `yaml:"extends"`
+	Palette    Palette    `yaml:"palette"`
 	Items      []Item     `yaml:"items"`
 	Settings   Settings   `yaml:"settings"`
 	Formatting Formatting `yaml:"formatting"`
+	Modes      []Mode     `yaml:"-"`
+}
+
+// UpdatedBy returns a copy of t deeply updated by other.
+// Items are replaced if other has them, field rules of other take precedence over field rules of t.
+func (t Theme) UpdatedBy(other *Theme) *Theme {
+	t.Version = other.Version
+	t.Extends = other.Extends
+	t.Palette = t.Palette.UpdatedBy(other.Palette)
+	t.Modes = slices.Concat(t.Modes, other.Modes)
+	slices.Sort(t.Modes)
+	t.Modes = slices.Compact(t.Modes)
+
+	if other.Items != nil {
+		t.Items = other.Items
+	}
+
+	t.Settings = t.Settings.UpdatedBy(other.Settings)
+	t.Formatting = t.Formatting.UpdatedBy(other.Formatting)
+
+	return &t
 }
 
 // Validate check that t is valid.
@@ -68,6 +201,33 @@ func (t *Theme) Validate() error {
 		}
 	}
 
+	err := t.Settings.Multiline.Validate()
+	if err != nil {
+		return fmt.Errorf("`settings.multiline` is invalid: %w", err)
+	}
+
+	err = t.Settings.Alignment.Validate()
+	if err != nil {
+		return fmt.Errorf("`settings.alignment` is invalid: %w", err)
+	}
+
+	err = t.Settings.OutputMode.Validate()
+	if err != nil {
+		return fmt.Errorf("`settings.output-mode` is invalid: %w", err)
+	}
+
+	err = t.Formatting.Level.Validate()
+	if err != nil {
+		return fmt.Errorf("`formatting.level` is invalid: %w", err)
+	}
+
+	for i, rule := range t.Formatting.Fields {
+		err := rule.Validate()
+		if err != nil {
+			return fmt.Errorf("`formatting.fields.%d` is invalid: %w", i, err)
+		}
+	}
+
 	return nil
 }
 
@@ -106,21 +266,195 @@ func (i Item) Validate() error {
 
 // Settings is a settings configuration section.
 type Settings struct {
-	TimeFormat string `yaml:"time-format"`
+	TimeFormat string          `yaml:"time-format"`
+	Multiline  MultilineLayout `yaml:"multiline"`
+	Alignment  Alignment       `yaml:"alignment"`
+	OutputMode OutputMode      `yaml:"output-mode"`
+}
+
+// UpdatedBy returns a copy of s updated by non-zero values of other.
+func (s Settings) UpdatedBy(other Settings) Settings {
+	if other.TimeFormat != "" {
+		s.TimeFormat = other.TimeFormat
+	}
+
+	if other.Multiline != MultilineLayoutDefault {
+		s.Multiline = other.Multiline
+	}
+
+	if other.Alignment.MessageWidth != 0 {
+		s.Alignment.MessageWidth = other.Alignment.MessageWidth
+	}
+
+	if other.Alignment.KeyMemory != 0 {
+		s.Alignment.KeyMemory = other.Alignment.KeyMemory
+	}
+
+	if other.OutputMode != OutputModeDefault {
+		s.OutputMode = other.OutputMode
+	}
+
+	return s
+}
+
+// ---
+
+// Alignment is a settings.alignment configuration section.
+//
+// MessageWidth is a minimum width of a message, shorter messages are padded with spaces.
+// KeyMemory is a number of lines during which column of a recurring top-level field key is remembered
+// to align the key at the same column in the following lines, zero disables key alignment.
+type Alignment struct {
+	MessageWidth int `yaml:"message-width"`
+	KeyMemory    int `yaml:"key-memory"`
+}
+
+// Validate checks that a is valid.
+func (a Alignment) Validate() error {
+	if a.MessageWidth < 0 {
+		return fmt.Errorf("`message-width` is invalid: negative value %d", a.MessageWidth)
+	}
+
+	if a.KeyMemory < 0 {
+		return fmt.Errorf("`key-memory` is invalid: negative value %d", a.KeyMemory)
+	}
+
+	return nil
+}
+
+// ---
+
+// Valid values for MultilineLayout.
+const (
+	MultilineLayoutDefault    MultilineLayout = ""
+	MultilineLayoutSingleLine MultilineLayout = "single-line"
+	MultilineLayoutBlock      MultilineLayout = "block"
+)
+
+// MultilineLayout defines how messages and string values containing line feeds are laid out.
+type MultilineLayout string
+
+// Validate checks if l has a valid value.
+func (l MultilineLayout) Validate() error {
+	switch l {
+	case MultilineLayoutDefault:
+	case MultilineLayoutSingleLine:
+	case MultilineLayoutBlock:
+	default:
+		return fmt.Errorf("invalid value %q", l)
+	}
+
+	return nil
+}
+
+// ---
+
+// Valid values for OutputMode.
+const (
+	OutputModeDefault OutputMode = ""
+	OutputModeText    OutputMode = "text"
+	OutputModeLogfmt  OutputMode = "logfmt"
+)
+
+// OutputMode defines whether output is a free-form text or strict logfmt.
+type OutputMode string
+
+// Validate checks if m has a valid value.
+func (m OutputMode) Validate() error {
+	switch m {
+	case OutputModeDefault:
+	case OutputModeText:
+	case OutputModeLogfmt:
+	default:
+		return fmt.Errorf("invalid value %q", m)
+	}
+
+	return nil
 }
 
 // ---
 
 // Formatting is a formatting configuration section.
+// Items `line`, `logger`, `message` and `field` can be overridden for particular log levels.
 type Formatting struct {
-	Timestamp formatting.Item  `yaml:"timestamp"`
-	Level     formatting.Level `yaml:"level"`
-	Logger    formatting.Item  `yaml:"logger"`
-	Message   formatting.Item  `yaml:"message"`
-	Field     formatting.Item  `yaml:"field"`
-	Key       formatting.Item  `yaml:"key"`
-	Caller    formatting.Item  `yaml:"caller"`
-	Types     FormattingTypes  `yaml:"types"`
+	Line      formatting.LevelItem `yaml:"line"`
+	Timestamp formatting.Item      `yaml:"timestamp"`
+	Level     formatting.Level     `yaml:"level"`
+	Logger    formatting.LevelItem `yaml:"logger"`
+	Message   formatting.LevelItem `yaml:"message"`
+	Field     formatting.LevelItem `yaml:"field"`
+	Key       formatting.Item      `yaml:"key"`
+	Caller    formatting.Item      `yaml:"caller"`
+	Gutter    formatting.Item      `yaml:"gutter"`
+	Types     FormattingTypes      `yaml:"types"`
+	Fields    []FieldRule          `yaml:"fields"`
+}
+
+// UpdatedBy returns a copy of f deeply updated by other.
+func (f Formatting) UpdatedBy(other Formatting) Formatting {
+	f.Line = f.Line.UpdatedBy(other.Line)
+	f.Timestamp = f.Timestamp.UpdatedBy(other.Timestamp)
+	f.Level = f.Level.UpdatedBy(other.Level)
+	f.Logger = f.Logger.UpdatedBy(other.Logger)
+	f.Message = f.Message.UpdatedBy(other.Message)
+	f.Field = f.Field.UpdatedBy(other.Field)
+	f.Key = f.Key.UpdatedBy(other.Key)
+	f.Caller = f.Caller.UpdatedBy(other.Caller)
+	f.Gutter = f.Gutter.UpdatedBy(other.Gutter)
+	f.Types = f.Types.UpdatedBy(other.Types)
+
+	if len(other.Fields) != 0 {
+		f.Fields = slices.Concat(other.Fields, f.Fields)
+	}
+
+	return f
+}
+
+// ---
+
+// FieldRule is an item of formatting.fields configuration section.
+// It defines formatting of keys and values of fields matching the rule.
+// Keys are matched without prefixes of enclosing objects.
+type FieldRule struct {
+	Match FieldMatch        `yaml:"match"`
+	Key   formatting.Format `yaml:"key"`
+	Value formatting.Format `yaml:"value"`
+}
+
+// Validate checks that r is valid.
+func (r FieldRule) Validate() error {
+	err := r.Match.Validate()
+	if err != nil {
+		return fmt.Errorf("`match` is invalid: %w", err)
+	}
+
+	return nil
+}
+
+// ---
+
+// FieldMatch defines a set of keys a FieldRule applies to.
+// A key matches if it is equal to any of the names, matches any of the glob patterns or starts with any of the prefixes.
+type FieldMatch struct {
+	Names    []string `yaml:"names"`
+	Globs    []string `yaml:"globs"`
+	Prefixes []string `yaml:"prefixes"`
+}
+
+// Validate checks that m is valid.
+func (m FieldMatch) Validate() error {
+	if len(m.Names) == 0 && len(m.Globs) == 0 && len(m.Prefixes) == 0 {
+		return errors.New("at least one of `names`, `globs` or `prefixes` should be specified")
+	}
+
+	for i, glob := range m.Globs {
+		_, err := path.Match(glob, "")
+		if err != nil {
+			return fmt.Errorf("`globs.%d` is invalid: %w", i, err)
+		}
+	}
+
+	return nil
 }
 
 // ---
@@ -137,5 +471,63 @@ type FormattingTypes struct {
 	Time     formatting.Item `yaml:"time"`
 	Duration formatting.Item `yaml:"duration"`
 	Null     formatting.Item `yaml:"null"`
-	Error    formatting.Item `yaml:"error"`
+	Error    FormattingError `yaml:"error"`
+	Redacted formatting.Item `yaml:"redacted"`
+	Ellipsis formatting.Item `yaml:"ellipsis"`
+}
+
+// UpdatedBy returns a copy of t deeply updated by other.
+func (t FormattingTypes) UpdatedBy(other FormattingTypes) FormattingTypes {
+	t.Array = t.Array.UpdatedBy(other.Array)
+	t.Object = t.Object.UpdatedBy(other.Object)
+	t.String = t.String.UpdatedBy(other.String)
+	t.Quotes = t.Quotes.UpdatedBy(other.Quotes)
+	t.Special = t.Special.UpdatedBy(other.Special)
+	t.Number = t.Number.UpdatedBy(other.Number)
+	t.Boolean = t.Boolean.UpdatedBy(other.Boolean)
+	t.Time = t.Time.UpdatedBy(other.Time)
+	t.Duration = t.Duration.UpdatedBy(other.Duration)
+	t.Null = t.Null.UpdatedBy(other.Null)
+	t.Error = t.Error.UpdatedBy(other.Error)
+	t.Redacted = t.Redacted.UpdatedBy(other.Redacted)
+	t.Ellipsis = t.Ellipsis.UpdatedBy(other.Ellipsis)
+
+	return t
+}
+
+// ---
+
+// FormattingError is a formatting.types.error configuration section.
+type FormattingError struct {
+	formatting.Item `yaml:",inline"`
+	Frame           FormattingErrorFrame `yaml:"frame"`
+}
+
+// UpdatedBy returns a copy of e deeply updated by other.
+func (e FormattingError) UpdatedBy(other FormattingError) FormattingError {
+	e.Item = e.Item.UpdatedBy(other.Item)
+	e.Frame = e.Frame.UpdatedBy(other.Frame)
+
+	return e
+}
+
+// ---
+
+// FormattingErrorFrame is a formatting.types.error.frame configuration section.
+// It defines formatting of a single stack trace frame.
+type FormattingErrorFrame struct {
+	formatting.Item `yaml:",inline"`
+	Function        formatting.Item `yaml:"function"`
+	File            formatting.Item `yaml:"file"`
+	Line            formatting.Item `yaml:"line"`
+}
+
+// UpdatedBy returns a copy of f deeply updated by other.
+func (f FormattingErrorFrame) UpdatedBy(other FormattingErrorFrame) FormattingErrorFrame {
+	f.Item = f.Item.UpdatedBy(other.Item)
+	f.Function = f.Function.UpdatedBy(other.Function)
+	f.File = f.File.UpdatedBy(other.File)
+	f.Line = f.Line.UpdatedBy(other.Line)
+
+	return f
 }
@@ -0,0 +1,97 @@
+package themecfg
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Valid values for Mode.
+const (
+	ModeDefault Mode = ""
+	ModeDark    Mode = "dark"
+	ModeLight   Mode = "light"
+)
+
+// Mode is a terminal background mode a theme variant is tuned for.
+type Mode string
+
+// Validate checks if m has a valid value.
+func (m Mode) Validate() error {
+	switch m {
+	case ModeDefault:
+	case ModeDark:
+	case ModeLight:
+	default:
+		return fmt.Errorf("invalid value %q", m)
+	}
+
+	return nil
+}
+
+// ---
+
+// Variant is an item of variants configuration section.
+// It overrides palette, items, settings and formatting of the theme for a particular mode.
+type Variant struct {
+	Palette    Palette    `yaml:"palette"`
+	Items      []Item     `yaml:"items"`
+	Settings   Settings   `yaml:"settings"`
+	Formatting Formatting `yaml:"formatting"`
+}
+
+func (v *Variant) applyTo(t *Theme) *Theme {
+	return t.UpdatedBy(&Theme{
+		Version:    t.Version,
+		Extends:    t.Extends,
+		Palette:    v.Palette,
+		Items:      v.Items,
+		Settings:   v.Settings,
+		Formatting: v.Formatting,
+	})
+}
+
+// ---
+
+// mappingValue returns value of the key in the mapping node or nil if there is no such key.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// withoutKey returns a shallow copy of the mapping node without the key.
+func withoutKey(node *yaml.Node, key string) *yaml.Node {
+	result := *node
+	result.Content = make([]*yaml.Node, 0, len(node.Content))
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != key {
+			result.Content = append(result.Content, node.Content[i], node.Content[i+1])
+		}
+	}
+
+	return &result
+}
+
+// withValue returns a shallow copy of the mapping node with value of the key replaced.
+func withValue(node *yaml.Node, key string, value *yaml.Node) *yaml.Node {
+	result := *node
+	result.Content = append([]*yaml.Node(nil), node.Content...)
+
+	for i := 0; i+1 < len(result.Content); i += 2 {
+		if result.Content[i].Value == key {
+			result.Content[i+1] = value
+		}
+	}
+
+	return &result
+}
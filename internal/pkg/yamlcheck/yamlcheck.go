@@ -0,0 +1,337 @@
+// Package yamlcheck checks YAML documents against Go types they are decoded to
+// reporting problems with their positions in the document.
+package yamlcheck
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Check checks that all mapping keys of the node are known to the type t,
+// that all values can be decoded to the corresponding types and that they are valid according to their Validate methods.
+//
+// Validate method of a value is called only if no problems were found in its nested values.
+func Check(node *yaml.Node, t reflect.Type) []Issue {
+	var c checker
+
+	c.check(node, t, "")
+
+	return c.issues
+}
+
+// Sort sorts issues by their positions.
+func Sort(issues []Issue) {
+	slices.SortStableFunc(issues, func(a, b Issue) int {
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+
+		return a.Column - b.Column
+	})
+}
+
+// ---
+
+// Issue is a problem found in a YAML document.
+type Issue struct {
+	Line    int
+	Column  int
+	Path    string
+	Message string
+	Warning bool
+}
+
+// At returns an Issue located at the given node.
+func At(node *yaml.Node, path, message string) Issue {
+	return Issue{node.Line, node.Column, path, message, false}
+}
+
+// Warning returns a warning Issue located at the given node.
+func Warning(node *yaml.Node, path, message string) Issue {
+	return Issue{node.Line, node.Column, path, message, true}
+}
+
+// FromError returns an Issue for the error that occurred while parsing a document.
+// Line number is extracted from the error message if present.
+func FromError(err error) Issue {
+	message := err.Error()
+	issue := Issue{Message: message}
+
+	if m := linePattern.FindStringSubmatchIndex(message); m != nil {
+		issue.Line, _ = strconv.Atoi(message[m[2]:m[3]])
+		issue.Message = message[:m[0]] + message[m[1]:]
+	}
+
+	return issue
+}
+
+// ---
+
+// Lookup returns a value in the node located by the given path of mapping keys.
+// It returns nil if there is no such value.
+func Lookup(node *yaml.Node, keys ...string) *yaml.Node {
+	node = resolve(node)
+	if node != nil && node.Kind == yaml.DocumentNode && len(node.Content) != 0 {
+		node = resolve(node.Content[0])
+	}
+
+	for _, key := range keys {
+		if node == nil || node.Kind != yaml.MappingNode {
+			return nil
+		}
+
+		var value *yaml.Node
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				value = resolve(node.Content[i+1])
+			}
+		}
+
+		node = value
+	}
+
+	return node
+}
+
+// Key returns the key node of the given key in the mapping node or nil if there is no such key.
+func Key(node *yaml.Node, key string) *yaml.Node {
+	node = resolve(node)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i]
+		}
+	}
+
+	return nil
+}
+
+// Join joins path elements with dots.
+func Join(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+// Message returns error message without position information that is reported separately.
+func Message(err error) string {
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) && len(typeErr.Errors) != 0 {
+		message := typeErr.Errors[0]
+		if _, after, ok := strings.Cut(message, ": "); ok && strings.HasPrefix(message, "line ") {
+			message = after
+		}
+
+		return message
+	}
+
+	return strings.TrimPrefix(err.Error(), "yaml: ")
+}
+
+// ---
+
+type checker struct {
+	issues []Issue
+}
+
+func (c *checker) check(node *yaml.Node, t reflect.Type, path string) {
+	node = resolve(node)
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) != 0 {
+			c.check(node.Content[0], t, path)
+		}
+
+		return
+	}
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	n := len(c.issues)
+
+	if !isCustom(t) {
+		switch t.Kind() { //nolint:exhaustive // other kinds are decoded as a whole
+		case reflect.Struct:
+			if node.Kind == yaml.MappingNode {
+				c.checkStruct(node, t, path)
+			}
+		case reflect.Map:
+			if node.Kind == yaml.MappingNode {
+				for i := 0; i+1 < len(node.Content); i += 2 {
+					key := node.Content[i]
+					c.checkValue(key, t.Key(), Join(path, key.Value))
+					c.check(node.Content[i+1], t.Elem(), Join(path, key.Value))
+				}
+			}
+		case reflect.Slice, reflect.Array:
+			if node.Kind == yaml.SequenceNode {
+				for i, item := range node.Content {
+					c.check(item, t.Elem(), Join(path, strconv.Itoa(i)))
+				}
+			}
+		}
+	}
+
+	if len(c.issues) == n {
+		c.checkValue(node, t, path)
+	}
+}
+
+func (c *checker) checkValue(node *yaml.Node, t reflect.Type, path string) {
+	value := reflect.New(t)
+
+	err := node.Decode(value.Interface())
+	if err != nil {
+		c.issues = append(c.issues, At(node, path, Message(err)))
+
+		return
+	}
+
+	if v, ok := value.Interface().(validator); ok {
+		err := v.Validate()
+		if err != nil {
+			c.issues = append(c.issues, At(node, path, err.Error()))
+		}
+	}
+}
+
+func (c *checker) checkStruct(node *yaml.Node, t reflect.Type, path string) {
+	fields := make(map[string]reflect.Type)
+	collectFields(t, fields)
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+
+		ft, ok := fields[key.Value]
+		if !ok {
+			message := fmt.Sprintf("unknown key %q", key.Value)
+			if suggestion := closest(key.Value, fields); suggestion != "" {
+				message += fmt.Sprintf(", did you mean %q?", suggestion)
+			}
+
+			c.issues = append(c.issues, At(key, Join(path, key.Value), message))
+
+			continue
+		}
+
+		c.check(value, ft, Join(path, key.Value))
+	}
+}
+
+// ---
+
+type validator interface {
+	Validate() error
+}
+
+func resolve(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	return node
+}
+
+func isCustom(t reflect.Type) bool {
+	pt := reflect.PointerTo(t)
+
+	return pt.Implements(textUnmarshalerType) || pt.Implements(yamlUnmarshalerType)
+}
+
+// collectFields collects names and types of fields of the struct type t the way yaml package does it.
+func collectFields(t reflect.Type, fields map[string]reflect.Type) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("yaml")
+		if tag == "-" {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if slices.Contains(strings.Split(options, ","), "inline") {
+			ft := field.Type
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct {
+				collectFields(ft, fields)
+			}
+
+			continue
+		}
+
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		fields[name] = field.Type
+	}
+}
+
+// closest returns the known name that is the most similar to the given name or empty string if there is no similar name.
+func closest(name string, fields map[string]reflect.Type) string {
+	result := ""
+	best := max(len(name)/3, 1) + 1
+
+	for candidate := range fields {
+		distance := editDistance(name, candidate)
+		if distance < best || (distance == best && candidate < result) {
+			result = candidate
+			best = distance
+		}
+	}
+
+	return result
+}
+
+func editDistance(a, b string) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		prev := row[0]
+		row[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			prev, row[j] = row[j], min(row[j]+1, row[j-1]+1, prev+cost)
+		}
+	}
+
+	return row[len(b)]
+}
+
+// ---
+
+var (
+	linePattern = regexp.MustCompile(`line (\d+): `)
+
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	yamlUnmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+)
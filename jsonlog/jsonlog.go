@@ -0,0 +1,223 @@
+// Package jsonlog parses JSON log records into log entries of logf.
+// It is shared by the command-line tool and adapters of other logging libraries that receive records in JSON form.
+package jsonlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/ssgreg/logf"
+)
+
+// Parse parses a single JSON object keeping original order of its members.
+// Numbers are parsed as json.Number, nested objects and arrays are parsed as Object and Array.
+func Parse(data []byte) (Object, bool) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || data[0] != '{' {
+		return nil, false
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	object, err := decodeObject(dec)
+	if err != nil {
+		return nil, false
+	}
+
+	_, err = dec.Token()
+	if !errors.Is(err, io.EOF) {
+		return nil, false
+	}
+
+	return object, true
+}
+
+// ParseValue parses a single JSON value the same way as [Parse] does it for members of objects.
+func ParseValue(data []byte) (any, bool) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	value, err := decodeValue(dec)
+	if err != nil {
+		return nil, false
+	}
+
+	_, err = dec.Token()
+	if !errors.Is(err, io.EOF) {
+		return nil, false
+	}
+
+	return value, true
+}
+
+// ---
+
+// Member is a member of a JSON object.
+type Member struct {
+	Key   string
+	Value any
+}
+
+// Object is a JSON object that keeps original order of its members.
+type Object []Member
+
+// EncodeLogfObject encodes members of the object as fields, see [Field].
+func (o Object) EncodeLogfObject(enc logf.FieldEncoder) error {
+	for _, member := range o {
+		Field(member.Key, member.Value).Accept(enc)
+	}
+
+	return nil
+}
+
+// ---
+
+// Array is a JSON array.
+type Array []any
+
+// EncodeLogfArray encodes items of the array the same way as [Field] does it for values.
+func (a Array) EncodeLogfArray(enc logf.TypeEncoder) error {
+	for _, item := range a {
+		switch v := item.(type) {
+		case string:
+			enc.EncodeTypeString(v)
+		case json.Number:
+			if i, err := v.Int64(); err == nil {
+				enc.EncodeTypeInt64(i)
+			} else if f, err := v.Float64(); err == nil {
+				enc.EncodeTypeFloat64(f)
+			} else {
+				enc.EncodeTypeString(v.String())
+			}
+		case bool:
+			enc.EncodeTypeBool(v)
+		case Array:
+			enc.EncodeTypeArray(v)
+		case Object:
+			enc.EncodeTypeObject(v)
+		default:
+			enc.EncodeTypeAny(v)
+		}
+	}
+
+	return nil
+}
+
+// ---
+
+// Field returns a field of the type best matching the parsed JSON value.
+func Field(key string, value any) logf.Field {
+	switch v := value.(type) {
+	case string:
+		return logf.String(key, v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return logf.Int64(key, i)
+		}
+
+		if f, err := v.Float64(); err == nil {
+			return logf.Float64(key, f)
+		}
+
+		return logf.String(key, v.String())
+	case bool:
+		return logf.Bool(key, v)
+	case Array:
+		return logf.Array(key, v)
+	case Object:
+		return logf.Object(key, v)
+	default:
+		return logf.Any(key, v)
+	}
+}
+
+// ---
+
+func decodeObject(dec *json.Decoder) (Object, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err //nolint:wrapcheck // error is passed as is from the decoder
+	}
+
+	if token != json.Delim('{') {
+		return nil, errUnexpectedToken
+	}
+
+	return decodeObjectMembers(dec)
+}
+
+func decodeObjectMembers(dec *json.Decoder) (Object, error) {
+	object := Object{}
+
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err //nolint:wrapcheck // error is passed as is from the decoder
+		}
+
+		key, ok := token.(string)
+		if !ok {
+			return nil, errUnexpectedToken
+		}
+
+		value, err := decodeValue(dec)
+		if err != nil {
+			return nil, err
+		}
+
+		object = append(object, Member{key, value})
+	}
+
+	_, err := dec.Token()
+	if err != nil {
+		return nil, err //nolint:wrapcheck // error is passed as is from the decoder
+	}
+
+	return object, nil
+}
+
+func decodeValue(dec *json.Decoder) (any, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err //nolint:wrapcheck // error is passed as is from the decoder
+	}
+
+	switch token {
+	case json.Delim('{'):
+		return decodeObjectMembers(dec)
+	case json.Delim('['):
+		array := Array{}
+
+		for dec.More() {
+			value, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			array = append(array, value)
+		}
+
+		_, err := dec.Token()
+		if err != nil {
+			return nil, err //nolint:wrapcheck // error is passed as is from the decoder
+		}
+
+		return array, nil
+	default:
+		return token, nil
+	}
+}
+
+// ---
+
+var errUnexpectedToken = errors.New("unexpected token")
+
+// ---
+
+var (
+	_ logf.ObjectEncoder = Object(nil)
+	_ logf.ArrayEncoder  = Array(nil)
+)
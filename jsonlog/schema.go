@@ -0,0 +1,205 @@
+package jsonlog
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ssgreg/logf"
+)
+
+// Schema describes well-known keys of JSON log records and the way their values are parsed.
+// An empty key means that records have no such well-known key.
+type Schema struct {
+	TimeKey    string
+	LevelKey   string
+	MessageKey string
+	LoggerKey  string
+	CallerKey  string
+	ErrorKey   string
+	StackKey   string
+	ParseTime  func(value any) (time.Time, bool)
+	ParseLevel func(s string) (logf.Level, bool)
+	ParseStack func(value any) ([]runtime.Frame, bool)
+}
+
+// Entry makes a log entry of the object.
+// Well-known keys are mapped to the corresponding entry attributes, the error is added as an error field,
+// and all other members become fields made by the field function in the same order as they appear in the object.
+// Members of well-known keys having values that cannot be parsed become fields as well.
+// Stack trace frames become the stack trace of the error, so they are output the same way as stack traces of errors
+// logged by logf, or the last field if the record has no error.
+// Nil field function means [Field].
+func (s *Schema) Entry(object Object, field func(Member) logf.Field) logf.Entry {
+	entry := logf.Entry{Level: logf.LevelInfo}
+
+	if field == nil {
+		field = func(member Member) logf.Field {
+			return Field(member.Key, member.Value)
+		}
+	}
+
+	var stack *Member
+
+	var frames []runtime.Frame
+
+	for i, member := range object {
+		if member.Key != "" && member.Key == s.StackKey && s.ParseStack != nil {
+			if f, ok := s.ParseStack(member.Value); ok {
+				stack, frames = &object[i], f
+
+				continue
+			}
+		}
+
+		if s.apply(&entry, member) {
+			continue
+		}
+
+		entry.Fields = append(entry.Fields, field(member))
+	}
+
+	if stack != nil && !s.attachStack(entry.Fields, frames) {
+		entry.Fields = append(entry.Fields, field(*stack))
+	}
+
+	return entry
+}
+
+// attachStack makes frames the stack trace of the error field and reports whether the error field is found.
+func (s *Schema) attachStack(fields []logf.Field, frames []runtime.Frame) bool {
+	for i := range fields {
+		if fields[i].Key != s.ErrorKey || fields[i].Type != logf.FieldTypeError {
+			continue
+		}
+
+		if err, ok := fields[i].Any.(error); ok {
+			fields[i].Any = tracedError{err, frames}
+
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Schema) apply(entry *logf.Entry, member Member) bool {
+	if member.Key == "" {
+		return false
+	}
+
+	str, isString := member.Value.(string)
+
+	switch member.Key {
+	case s.TimeKey:
+		ts, ok := s.ParseTime(member.Value)
+		if ok {
+			entry.Time = ts
+		}
+
+		return ok
+	case s.LevelKey:
+		if isString {
+			level, ok := s.ParseLevel(str)
+			if ok {
+				entry.Level = level
+			}
+
+			return ok
+		}
+	case s.MessageKey:
+		if isString {
+			entry.Text = str
+		}
+
+		return isString
+	case s.LoggerKey:
+		if isString {
+			entry.LoggerName = str
+		}
+
+		return isString
+	case s.CallerKey:
+		if isString {
+			caller, ok := ParseCaller(str)
+			if ok {
+				entry.Caller = caller
+			}
+
+			return ok
+		}
+	case s.ErrorKey:
+		if isString {
+			entry.Fields = append(entry.Fields, logf.NamedError(member.Key, errors.New(str))) //nolint:err113 // error is restored from its text
+		}
+
+		return isString
+	}
+
+	return false
+}
+
+// ---
+
+// tracedError is an error restored from its text having stack trace frames found in the record.
+type tracedError struct {
+	error
+	frames []runtime.Frame
+}
+
+func (e tracedError) StackTrace() []runtime.Frame {
+	return e.frames
+}
+
+// ---
+
+// ParseTime parses timestamps formatted according to RFC 3339 and Unix timestamps
+// in seconds, milliseconds, microseconds or nanoseconds guessing units by magnitude.
+func ParseTime(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case string:
+		ts, err := time.Parse(time.RFC3339Nano, v)
+
+		return ts, err == nil
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		// Guess units by magnitude, values below 1e11 are treated as seconds,
+		// that covers dates up to year 5138. Time zone is unknown, so use UTC
+		// the same way as RFC3339 encoders do by default.
+		switch abs := math.Abs(f); {
+		case abs < 1e11:
+			return time.Unix(0, int64(f*1e9)).UTC(), true
+		case abs < 1e14:
+			return time.Unix(0, int64(f*1e6)).UTC(), true
+		case abs < 1e17:
+			return time.Unix(0, int64(f*1e3)).UTC(), true
+		default:
+			return time.Unix(0, int64(f)).UTC(), true
+		}
+	default:
+		return time.Time{}, false
+	}
+}
+
+// ParseCaller parses the caller in `file:line` form.
+func ParseCaller(s string) (logf.EntryCaller, bool) {
+	i := strings.LastIndexByte(s, ':')
+	if i <= 0 {
+		return logf.EntryCaller{}, false
+	}
+
+	line, err := strconv.Atoi(s[i+1:])
+	if err != nil {
+		return logf.EntryCaller{}, false
+	}
+
+	return logf.EntryCaller{File: s[:i], Line: line, Specified: true}, true
+}
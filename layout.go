@@ -0,0 +1,84 @@
+package logftxt
+
+import (
+	"github.com/ssgreg/logf"
+
+	"github.com/pamburus/logftxt/internal/pkg/layout"
+	"github.com/pamburus/logftxt/internal/pkg/themecfg"
+)
+
+// Layout returns prefixes, suffixes and separators of items of the lines produced with the theme
+// and the given names of custom levels. It is intended for reading the lines back, see the parse package.
+func (t *Theme) Layout(names LevelNames) layout.Layout {
+	result := layout.Layout{
+		Timestamp: t.fmt.Timestamp.layout(),
+		Key:       t.fmt.Key.layout(),
+		Caller:    t.fmt.Caller.layout(),
+		Array:     t.fmt.Array.layout(),
+		Object:    t.fmt.Object.layout(),
+		Error:     t.fmt.Error.layout(),
+		Time:      t.fmt.Time.layout(),
+		Null:      t.fmt.Null.layout(),
+		Gutter:    t.fmt.Gutter.layout(),
+		Frame: layout.Frame{
+			Item:     t.fmt.Frame.layout(),
+			Function: t.fmt.Frame.Function.layout(),
+			File:     t.fmt.Frame.File.layout(),
+			Line:     t.fmt.Frame.Line.layout(),
+		},
+		BlockIndent: blockIndent,
+		LevelItems:  make(map[logf.Level]layout.Item),
+	}
+
+	for _, it := range t.items {
+		result.Items = append(result.Items, itemKind(it))
+	}
+
+	for i := range t.levels {
+		level := &t.levels[i]
+		result.Levels[i] = layout.Level{
+			Line:    level.Line.layout(),
+			Logger:  level.Logger.layout(),
+			Message: level.Message.layout(),
+			Field:   level.Field.layout(),
+		}
+	}
+
+	for i := range t.fmt.Level {
+		result.LevelItems[logf.Level(i)] = t.fmt.Level[i].layout()
+	}
+
+	for level, item := range t.customLevelItems(names) {
+		result.LevelItems[level] = item.layout()
+	}
+
+	return result
+}
+
+func (i *fmtItem) layout() layout.Item {
+	return layout.Item{
+		Outer:     layout.Format{Prefix: i.outer.prefix, Suffix: i.outer.suffix},
+		Inner:     layout.Format{Prefix: i.inner.prefix, Suffix: i.inner.suffix},
+		Separator: i.separator.text,
+		Text:      i.text,
+	}
+}
+
+func itemKind(it item) themecfg.Item {
+	switch it.(type) {
+	case *itemTimestamp:
+		return themecfg.ItemTimestamp
+	case *itemLevel:
+		return themecfg.ItemLevel
+	case *itemLogger:
+		return themecfg.ItemLogger
+	case *itemMessage:
+		return themecfg.ItemMessage
+	case *itemFields:
+		return themecfg.ItemFields
+	case *itemCaller:
+		return themecfg.ItemCaller
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,307 @@
+package logftxt
+
+import (
+	"slices"
+	"unicode/utf8"
+
+	"github.com/ssgreg/logf"
+
+	"github.com/pamburus/logftxt/internal/pkg/themecfg"
+)
+
+// Valid values for OutputMode.
+const (
+	OutputModeDefault OutputMode = ""
+	OutputModeText    OutputMode = "text"
+	OutputModeLogfmt  OutputMode = "logfmt"
+)
+
+// OutputMode defines whether the output is a free-form text laid out by the theme or a strict logfmt.
+//
+// OutputModeLogfmt keeps the order of items, styles and alignment of the theme but guarantees
+// that each line is a valid logfmt record suitable for `grep` and logfmt parsers like the one of Loki.
+// Timestamp, level, logger name, message and caller are written as `time`, `level`, `logger`, `msg` and `caller` fields,
+// prefixes and suffixes of the theme are dropped, keys are sanitized and values are quoted and escaped
+// according to the logfmt rules. Nested objects are flattened and multi-line values are escaped.
+// Escape sequences used for styling are never considered to be a part of keys or values,
+// so the output can be parsed with colors enabled once the escape sequences are stripped.
+//
+// Default value means the mode specified by the `settings.output-mode` of the theme, which is text unless specified.
+type OutputMode string
+
+// Validate checks whether v has a valid value.
+func (v OutputMode) Validate() error {
+	return themecfg.OutputMode(v).Validate()
+}
+
+func (v OutputMode) toEncoderOptions(o *encoderOptions) {
+	o.outputMode = v
+}
+
+func (v OutputMode) toAppenderOptions(o *appenderOptions) {
+	o.outputMode = v
+}
+
+// ---
+
+// logfmt returns a copy of the theme adjusted to produce strict logfmt output.
+// Styles are kept while prefixes and suffixes that could break the logfmt syntax are dropped.
+func (t *Theme) logfmt() *Theme {
+	lt := *t
+
+	lt.items = make([]item, len(t.items))
+	for i, it := range t.items {
+		lt.items[i] = newLogfmtItem(it)
+	}
+
+	for _, item := range []*fmtItem{
+		&lt.fmt.Timestamp, &lt.fmt.Key, &lt.fmt.Caller, &lt.fmt.String, &lt.fmt.Number, &lt.fmt.Boolean,
+		&lt.fmt.Time, &lt.fmt.Duration, &lt.fmt.Null, &lt.fmt.Error, &lt.fmt.Redacted,
+	} {
+		item.strip()
+	}
+
+	for i := range lt.fmt.Level {
+		lt.fmt.Level[i].strip()
+	}
+
+	lt.fmt.Key.separator.text = "."
+
+	for i := range lt.levels {
+		level := &lt.levels[i]
+		level.Line.strip()
+		level.Logger.strip()
+		level.Message.strip()
+		level.Field.strip()
+		level.Field.separator.text = "="
+	}
+
+	lt.fields.items = slices.Clone(t.fields.items)
+	for i := range lt.fields.items {
+		lt.fields.items[i].key.strip()
+		lt.fields.items[i].value.strip()
+	}
+
+	return &lt
+}
+
+// strip removes prefixes and suffixes keeping styles.
+func (i *fmtItem) strip() {
+	i.outer.strip()
+	i.inner.strip()
+}
+
+// strip removes prefix and suffix keeping style.
+func (f *format) strip() {
+	f.prefix = ""
+	f.suffix = ""
+}
+
+// ---
+
+// newLogfmtItem returns an item that outputs the given item as a logfmt field.
+func newLogfmtItem(it item) item {
+	switch it.(type) {
+	case *itemTimestamp:
+		return &logfmtItem{"time", it}
+	case *itemLevel:
+		return &logfmtItem{"level", &logfmtLevel{}}
+	case *itemLogger:
+		return &logfmtItem{"logger", it}
+	case *itemMessage:
+		return &logfmtItem{"msg", it}
+	case *itemCaller:
+		return &logfmtItem{"caller", it}
+	default:
+		return it
+	}
+}
+
+// logfmtItem outputs an item as a field with the given key, the field is omitted if the item has no output.
+type logfmtItem struct {
+	key  string
+	item item
+}
+
+func (i *logfmtItem) encode(e *entryEncoder) {
+	start := e.buf.Len()
+	empty := false
+
+	e.styler.Use(e.level.Field.inner.style, e.buf, func() {
+		e.theme.fmt.Key.encode(e, func() {
+			e.buf.AppendString(i.key)
+		})
+		e.level.Field.separator.encode(e)
+
+		value := e.buf.Len()
+		i.item.encode(e)
+
+		empty = e.buf.Len() == value
+		if !empty {
+			e.quoteValue(value)
+		}
+	})
+
+	if empty {
+		e.truncate(start)
+	}
+}
+
+// ---
+
+// logfmtLevel outputs the level name styled as the level item of the theme.
+type logfmtLevel struct{}
+
+func (*logfmtLevel) encode(e *entryEncoder) {
+	e.levelItem().encode(e, func() {
+		if name, ok := e.levelNames[e.entry.Level]; ok {
+			e.buf.AppendString(name)
+		} else if e.entry.Level == levelIndex(e.entry.Level) {
+			e.buf.AppendString(e.entry.Level.String())
+		} else {
+			logf.AppendInt(e.buf, int64(e.entry.Level))
+		}
+	})
+}
+
+// ---
+
+// logfmt reports whether top-level values are written as is to be quoted as a whole by quoteValue.
+func (e *entryEncoder) logfmt() bool {
+	return e.outputMode == OutputModeLogfmt && e.depth == 0
+}
+
+// quoteValue quotes and escapes the value written starting at the given position if logfmt rules require it.
+// Escape sequences written by the styler are kept as is and are not considered to be a part of the value,
+// any other escape character is a part of the value and is escaped like other control characters.
+func (e *entryEncoder) quoteValue(start int) {
+	marks := e.styler.Marks(start)
+	if !logfmtQuotingNeeded(e.buf.Data[start:], start, marks) {
+		return
+	}
+
+	e.scratch = append(e.scratch[:0], e.buf.Data[start:]...)
+	e.rewind(start)
+	e.buf.Data = append(e.buf.Data[:start], '"')
+
+	s := e.scratch
+	p := 0
+	m := 0
+
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		switch {
+		case c == esc && m < len(marks) && marks[m] == start+i:
+			e.buf.AppendBytes(s[p:i])
+			marks[m] = e.buf.Len()
+			m++
+			p = i
+			i += escapeSequenceLen(s[i:])
+
+		case c < utf8.RuneSelf && c >= 0x20 && c != '\\' && c != '"':
+			i++
+
+		case c < utf8.RuneSelf:
+			e.buf.AppendBytes(s[p:i])
+
+			switch c {
+			case '\t':
+				e.buf.AppendString(`\t`)
+			case '\r':
+				e.buf.AppendString(`\r`)
+			case '\n':
+				e.buf.AppendString(`\n`)
+			case '\\', '"':
+				e.buf.AppendByte('\\')
+				e.buf.AppendByte(c)
+			default:
+				e.buf.AppendString(`\u00`)
+				e.buf.AppendByte(hex[c>>4])
+				e.buf.AppendByte(hex[c&0xf])
+			}
+
+			i++
+			p = i
+
+		default:
+			r, n := utf8.DecodeRune(s[i:])
+			if r == utf8.RuneError && n == 1 {
+				e.buf.AppendBytes(s[p:i])
+				e.buf.AppendString(`\ufffd`)
+				p = i + 1
+			}
+
+			i += n
+		}
+	}
+
+	e.buf.AppendBytes(s[p:])
+	e.buf.AppendByte('"')
+}
+
+// appendLogfmtKey appends the key replacing characters that are not allowed in logfmt keys with underscores.
+func (e *entryEncoder) appendLogfmtKey(k string) {
+	if k == "" {
+		e.buf.AppendByte('_')
+
+		return
+	}
+
+	for _, r := range k {
+		if !logfmtKeyRune(r) {
+			r = '_'
+		}
+
+		e.buf.Data = utf8.AppendRune(e.buf.Data, r)
+	}
+}
+
+// ---
+
+// logfmtQuotingNeeded reports whether the value written at the given position has to be quoted according to logfmt rules.
+// Escape sequences at the marked positions are not considered to be a part of the value.
+func logfmtQuotingNeeded(value []byte, start int, marks []int) bool {
+	visible := false
+
+	for i := 0; i < len(value); {
+		c := value[i]
+
+		switch {
+		case c == esc && len(marks) != 0 && marks[0] == start+i:
+			marks = marks[1:]
+			i += escapeSequenceLen(value[i:])
+
+			continue
+		case c < utf8.RuneSelf:
+			if !logfmtKeyRune(rune(c)) {
+				return true
+			}
+
+			i++
+		default:
+			r, n := utf8.DecodeRune(value[i:])
+			if r == utf8.RuneError && n == 1 {
+				return true
+			}
+
+			i += n
+		}
+
+		visible = true
+	}
+
+	return !visible
+}
+
+// logfmtKeyRune reports whether the rune is allowed in logfmt keys and unquoted values.
+func logfmtKeyRune(r rune) bool {
+	return r > ' ' && r != '=' && r != '"' && r != 0x7f && r != utf8.RuneError
+}
+
+// ---
+
+var (
+	_ EncoderOption  = OutputModeLogfmt
+	_ AppenderOption = OutputModeLogfmt
+)
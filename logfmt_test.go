@@ -0,0 +1,216 @@
+package logftxt_test
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ssgreg/logf"
+
+	"github.com/pamburus/go-tst/tst"
+	"github.com/pamburus/logftxt"
+)
+
+func TestOutputModeLogfmt(tt *testing.T) {
+	t := tst.New(tt)
+
+	env := logftxt.Environment(func(string) (string, bool) { return "", false })
+	ts := time.Date(2024, 3, 15, 14, 30, 5, 0, time.UTC)
+
+	encode := func(entry logf.Entry, options ...logftxt.EncoderOption) string {
+		enc := logftxt.NewEncoder(append([]logftxt.EncoderOption{
+			env,
+			&logftxt.Config{},
+			logftxt.OutputModeLogfmt,
+			logftxt.TimestampEncodeFunc(logftxt.TimeLayout(time.RFC3339)),
+		}, options...)...)
+		buf := logf.NewBuffer()
+		entry.Time = ts
+		t.Expect(enc.Encode(buf, entry)).ToSucceed()
+
+		return buf.String()
+	}
+
+	entry := logf.Entry{
+		LoggerName: "http",
+		Level:      logf.LevelWarn,
+		Time:       ts,
+		Text:       "request failed",
+		Fields: []logf.Field{
+			logf.String("path", "/a b"),
+			logf.Int("status", 502),
+			logf.Object("client", newMockObject(logf.String("ip", "192.0.2.1"))),
+			logf.Error(errors.New(`bad "gateway"`)),
+		},
+		Caller: logf.EntryCaller{File: "/src/http/server.go", Line: 42, Specified: true},
+	}
+
+	expected := `time=2024-03-15T14:30:05Z level=warn logger=http msg="request failed"` +
+		` path="/a b" status=502 client.ip=192.0.2.1 error="bad \"gateway\"" caller=http/server.go:42` + "\n"
+
+	t.Run("ColorNever", func(t tst.Test) {
+		t.Expect(encode(entry, logftxt.ColorNever)).ToEqual(expected)
+	})
+
+	t.Run("ColorAlways", func(t tst.Test) {
+		result := encode(entry, logftxt.ColorAlways)
+		t.Expect(strings.Contains(result, "\x1b[")).ToBeTrue()
+		t.Expect(sgrPattern.ReplaceAllString(result, "")).ToEqual(expected)
+	})
+
+	t.Run("Escaping", func(t tst.Test) {
+		result := encode(logf.Entry{Level: logf.LevelInfo, Text: "a\nb", Fields: []logf.Field{
+			logf.String("empty", ""),
+			logf.String("eq", "a=b"),
+			logf.String("ctl", "a\x01\t\\"),
+			logf.String("bad", "a\xffb"),
+			logf.String("null", "null"),
+			logf.String("unicode", "héllo"),
+			logf.Strings("list", []string{"x", "y z"}),
+		}}, logftxt.ColorNever)
+		t.Expect(result).ToEqual(
+			`time=2024-03-15T14:30:05Z level=info msg="a\nb" empty="" eq="a=b" ctl="a\u0001\t\\" bad="a\ufffdb" null=null unicode=héllo list="[x \"y z\"]"` + "\n",
+		)
+	})
+
+	t.Run("EscapeInjection", func(t tst.Test) {
+		const hostile = "a\x1b]8;;x\" admin=true \x07b"
+
+		entry := logf.Entry{Level: logf.LevelInfo, LoggerName: hostile, Text: hostile, Fields: []logf.Field{
+			logf.String("s", hostile),
+			logf.Error(errors.New(hostile)),
+			logf.Strings("list", []string{hostile}),
+		}}
+		expected := [][2]string{
+			{"time", "2024-03-15T14:30:05Z"},
+			{"level", "info"},
+			{"logger", hostile},
+			{"msg", hostile},
+			{"s", hostile},
+			{"error", hostile},
+			{"list", `["a\u001b]8;;x\" admin=true \u0007b"]`},
+		}
+
+		result := encode(entry, logftxt.ColorNever)
+		t.Expect(strings.Count(result, "\x1b")).ToEqual(0)
+		t.Expect(parseLogfmt(result)).ToEqual(expected)
+
+		result = encode(entry, logftxt.ColorAlways)
+		t.Expect(strings.Count(result, "\x1b]")).ToEqual(0)
+		t.Expect(parseLogfmt(sgrPattern.ReplaceAllString(result, ""))).ToEqual(expected)
+	})
+
+	t.Run("Keys", func(t tst.Test) {
+		result := encode(logf.Entry{Level: logf.LevelInfo, Fields: []logf.Field{
+			logf.Int("a b", 1),
+			logf.Int(`x="y"`, 2),
+			logf.Int("", 3),
+			logf.Object("o k", newMockObject(logf.Int("k\n", 4))),
+		}}, logftxt.ColorNever)
+		t.Expect(result).ToEqual("time=2024-03-15T14:30:05Z level=info a_b=1 x__y_=2 _=3 o_k.k_=4\n")
+	})
+
+	t.Run("CustomLevel", func(t tst.Test) {
+		t.Expect(encode(logf.Entry{Level: logf.LevelDebug + 1, Text: "x"}, logftxt.ColorNever)).ToEqual("time=2024-03-15T14:30:05Z level=4 msg=x\n")
+		t.Expect(encode(logf.Entry{Level: logf.LevelDebug + 1, Text: "x"}, logftxt.ColorNever, logftxt.LevelNames{logf.LevelDebug + 1: "trace"})).
+			ToEqual("time=2024-03-15T14:30:05Z level=trace msg=x\n")
+	})
+
+	t.Run("Layout", func(t tst.Test) {
+		result := encode(logf.Entry{Level: logf.LevelInfo, Text: "x", Fields: []logf.Field{
+			logf.String("text", "line one\nline two"),
+		}}, logftxt.ColorNever, logftxt.MultilineLayoutBlock, logftxt.LineWidth(10), logftxt.LineOverflowWrap)
+		t.Expect(result).ToEqual(`time=2024-03-15T14:30:05Z level=info msg=x text="line one\nline two"` + "\n")
+	})
+
+	t.Run("Theme", func(t tst.Test) {
+		theme, err := logftxt.ReadTheme(strings.NewReader(strings.Join([]string{
+			"theme:",
+			"  version: '1.0'",
+			"  items: [level, message, fields]",
+			"  settings:",
+			"    output-mode: logfmt",
+			"  formatting:",
+			"    level: {all: {outer: {prefix: '[', suffix: ']'}}, error: {text: ERR}}",
+			"    field: {separator: {text: ': '}}",
+			"    types:",
+			"      string: {outer: {prefix: '<', suffix: '>'}}",
+		}, "\n")))
+		t.Expect(err).ToNot(tst.HaveOccurred())
+
+		entry := logf.Entry{Level: logf.LevelError, Text: "x", Fields: []logf.Field{logf.String("k", "v")}}
+		enc := logftxt.NewEncoder(env, &logftxt.Config{}, theme, logftxt.ColorNever)
+		buf := logf.NewBuffer()
+		t.Expect(enc.Encode(buf, entry)).ToSucceed()
+		t.Expect(buf.String()).ToEqual("level=error msg=x k=v\n")
+
+		enc = logftxt.NewEncoder(env, &logftxt.Config{}, theme, logftxt.ColorNever, logftxt.OutputModeText)
+		buf = logf.NewBuffer()
+		t.Expect(enc.Encode(buf, entry)).ToSucceed()
+		t.Expect(buf.String()).ToEqual("[ERR] x k: <v>\n")
+	})
+
+	t.Run("InvalidTheme", func(t tst.Test) {
+		_, err := logftxt.ReadTheme(strings.NewReader("theme: {version: '1.0', settings: {output-mode: json}}"))
+		t.Expect(err).To(tst.HaveOccurred())
+		t.Expect(logftxt.OutputMode("json").Validate()).ToFail()
+		t.Expect(logftxt.OutputModeLogfmt.Validate()).ToSucceed()
+	})
+}
+
+var sgrPattern = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// parseLogfmt parses a logfmt line into key-value pairs, quoted values are unquoted the same way as Go strings.
+// It returns nil if the line is malformed.
+func parseLogfmt(line string) [][2]string {
+	var pairs [][2]string
+
+	line = strings.TrimSuffix(line, "\n")
+
+	for line != "" {
+		key, rest, ok := strings.Cut(line, "=")
+		if !ok || key == "" || strings.ContainsAny(key, " \"") {
+			return nil
+		}
+
+		var value string
+
+		if strings.HasPrefix(rest, `"`) {
+			end := 1
+			for end < len(rest) && rest[end] != '"' {
+				if rest[end] == '\\' {
+					end++
+				}
+
+				end++
+			}
+
+			if end >= len(rest) {
+				return nil
+			}
+
+			unquoted, err := strconv.Unquote(rest[:end+1])
+			if err != nil {
+				return nil
+			}
+
+			value, rest = unquoted, rest[end+1:]
+		} else {
+			value, rest, _ = strings.Cut(rest, " ")
+			rest = " " + rest
+		}
+
+		pairs = append(pairs, [2]string{key, value})
+
+		if rest != "" && rest != " " && !strings.HasPrefix(rest, " ") {
+			return nil
+		}
+
+		line = strings.TrimPrefix(rest, " ")
+	}
+
+	return pairs
+}
@@ -0,0 +1,141 @@
+// Package logrustxt provides logrus.Formatter implementation that outputs log entries of github.com/sirupsen/logrus
+// in the same textual human-readable form as logftxt does it for logf.
+package logrustxt
+
+import (
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/ssgreg/logf"
+
+	"github.com/pamburus/logftxt"
+)
+
+// NewFormatter returns a new logrus.Formatter that formats entries using logftxt.Encoder
+// constructed with the given options, so that theme and configuration are shared with logf loggers.
+//
+// Logrus levels trace, fatal and panic are mapped to [logftxt.LevelTrace], [logftxt.LevelFatal] and [logftxt.LevelPanic]
+// named with [logftxt.DefaultLevelNames], so they are displayed using the theme's formatting for these levels if it has one
+// or as the nearest standard level otherwise.
+func NewFormatter(options ...logftxt.EncoderOption) logrus.Formatter {
+	return &formatter{logftxt.NewEncoder(append([]logftxt.EncoderOption{logftxt.DefaultLevelNames()}, options...)...)}
+}
+
+// NewWriterFormatter returns a new logrus.Formatter that formats entries for output to w
+// the same way as logftxt.NewAppender does it.
+// It should be used together with logrus.Logger having w as its output.
+func NewWriterFormatter(w io.Writer, options ...logftxt.AppenderOption) logrus.Formatter {
+	return &formatter{logftxt.NewWriterEncoder(w, append([]logftxt.AppenderOption{logftxt.DefaultLevelNames()}, options...)...)}
+}
+
+// ---
+
+type formatter struct {
+	enc logf.Encoder
+}
+
+// Format formats the entry with its fields encoded in sorted key order.
+func (f *formatter) Format(e *logrus.Entry) ([]byte, error) {
+	entry := logf.Entry{
+		Level: logrusLevelToLogf(e.Level),
+		Time:  e.Time,
+		Text:  e.Message,
+	}
+
+	if len(e.Data) != 0 {
+		keys := make([]string, 0, len(e.Data))
+		for key := range e.Data {
+			keys = append(keys, key)
+		}
+
+		slices.Sort(keys)
+
+		entry.Fields = make([]logf.Field, len(keys))
+		for i, key := range keys {
+			entry.Fields[i] = newField(key, e.Data[key])
+		}
+	}
+
+	if e.HasCaller() {
+		entry.Caller = logf.EntryCaller{
+			PC:        e.Caller.PC,
+			File:      e.Caller.File,
+			Line:      e.Caller.Line,
+			Specified: true,
+		}
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	err := f.enc.Encode(buf, entry)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // error is passed as is from the encoder
+	}
+
+	if e.Buffer != nil {
+		e.Buffer.Write(buf.Bytes())
+
+		return e.Buffer.Bytes(), nil
+	}
+
+	return slices.Clone(buf.Bytes()), nil
+}
+
+// ---
+
+// newField returns a field for the value of the entry data.
+// Errors are passed as is to let logftxt format them as configured, including stack traces and chains of wrapped errors.
+func newField(key string, value any) logf.Field {
+	if err, ok := value.(error); ok {
+		return logf.NamedError(key, err)
+	}
+
+	return logf.Any(key, value)
+}
+
+func logrusLevelToLogf(level logrus.Level) logf.Level {
+	switch level {
+	case logrus.PanicLevel:
+		return logftxt.LevelPanic
+	case logrus.FatalLevel:
+		return logftxt.LevelFatal
+	case logrus.ErrorLevel:
+		return logf.LevelError
+	case logrus.WarnLevel:
+		return logf.LevelWarn
+	case logrus.InfoLevel:
+		return logf.LevelInfo
+	case logrus.DebugLevel:
+		return logf.LevelDebug
+	case logrus.TraceLevel:
+		fallthrough
+	default:
+		return logftxt.LevelTrace
+	}
+}
+
+// ---
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *logf.Buffer {
+	return bufferPool.Get().(*logf.Buffer) //nolint:forcetypeassert // pool contains only buffers
+}
+
+// putBuffer resets the buffer and returns it to the pool.
+func putBuffer(buf *logf.Buffer) {
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+var bufferPool = sync.Pool{
+	New: func() any {
+		return logf.NewBufferWithCapacity(1024)
+	},
+}
+
+// ---
+
+var _ logrus.Formatter = (*formatter)(nil)
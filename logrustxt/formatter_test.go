@@ -0,0 +1,137 @@
+package logrustxt_test
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/pamburus/go-tst/tst"
+	"github.com/pamburus/logftxt"
+	"github.com/pamburus/logftxt/logrustxt"
+)
+
+func TestFormatter(tt *testing.T) {
+	t := tst.New(tt)
+
+	theme, err := logftxt.ReadTheme(strings.NewReader(strings.Join([]string{
+		"theme:",
+		"  version: '1.0'",
+		"  items: [level, message, fields, caller]",
+		"  formatting:",
+		"    level:",
+		"      all: {outer: {prefix: '[', suffix: ']'}}",
+		"      panic: {text: PNC}",
+		"      fatal: {text: FTL}",
+		"      error: {text: ERR}",
+		"      warning: {text: WRN}",
+		"      info: {text: INF}",
+		"      debug: {text: DBG}",
+		"      trace: {text: TRC}",
+		"    field: {separator: {text: '='}}",
+		"    caller: {outer: {prefix: '@ '}}",
+		"    types:",
+		"      array: {outer: {prefix: '[', suffix: ']'}, separator: {text: ','}}",
+	}, "\n")))
+	t.Expect(err).ToNot(tst.HaveOccurred())
+
+	env := logftxt.Environment(func(string) (string, bool) { return "", false })
+
+	newLogger := func(options ...logftxt.EncoderOption) (*logrus.Logger, *bytes.Buffer) {
+		buf := &bytes.Buffer{}
+		logger := logrus.New()
+		logger.SetOutput(buf)
+		logger.SetLevel(logrus.TraceLevel)
+		logger.SetFormatter(logrustxt.NewFormatter(append([]logftxt.EncoderOption{env, &logftxt.Config{}, theme, logftxt.ColorNever}, options...)...))
+
+		return logger, buf
+	}
+
+	t.Run("Levels", func(t tst.Test) {
+		logger, buf := newLogger()
+		logger.Trace("t")
+		logger.Debug("d")
+		logger.Info("i")
+		logger.Warn("w")
+		logger.Error("e")
+		t.Expect(panics(func() { logger.Panic("p") })).ToBeTrue()
+		t.Expect(buf.String()).ToEqual("[TRC] t\n[DBG] d\n[INF] i\n[WRN] w\n[ERR] e\n[PNC] p\n")
+	})
+
+	t.Run("DefaultLevels", func(t tst.Test) {
+		theme, err := logftxt.ReadTheme(strings.NewReader(strings.Join([]string{
+			"theme:",
+			"  version: '1.0'",
+			"  items: [level, message]",
+			"  formatting:",
+			"    level: {error: {text: ERR}, debug: {text: DBG}}",
+		}, "\n")))
+		t.Expect(err).ToNot(tst.HaveOccurred())
+
+		logger, buf := newLogger(theme)
+		logger.Trace("t")
+		t.Expect(panics(func() { logger.Panic("p") })).ToBeTrue()
+		t.Expect(buf.String()).ToEqual("DBG t\nERR p\n")
+	})
+
+	t.Run("Fields", func(t tst.Test) {
+		logger, buf := newLogger()
+		logger.WithFields(logrus.Fields{
+			"b": 2,
+			"a": "x y",
+			"c": []int{1, 2},
+		}).WithError(errors.New("failed")).Info("msg")
+		t.Expect(buf.String()).ToEqual(`[INF] msg a="x y" b=2 c=[1,2] error=failed` + "\n")
+	})
+
+	t.Run("Caller", func(t tst.Test) {
+		logger, buf := newLogger()
+		logger.SetReportCaller(true)
+		logger.Info("msg")
+		t.Expect(buf.String()).ToEqual("[INF] msg @ logrustxt/formatter_test.go:93\n")
+	})
+
+	t.Run("ErrorTrace", func(t tst.Test) {
+		logger, buf := newLogger(logftxt.ErrorFormatTrace)
+		logger.WithError(tracedError{errors.New("failed")}).WithField("plain", errors.New("plain")).Info("msg")
+		t.Expect(buf.String()).ToEqual(
+			"[INF] msg error=failed plain=plain\n" +
+				"  | error=\n" +
+				"  |   main.main /src/main.go:23\n",
+		)
+	})
+
+	t.Run("Writer", func(t tst.Test) {
+		buf := &bytes.Buffer{}
+		logger := logrus.New()
+		logger.SetOutput(buf)
+		logger.SetFormatter(logrustxt.NewWriterFormatter(buf, env, &logftxt.Config{}, theme, logftxt.ColorNever))
+		logger.Info("msg")
+		t.Expect(buf.String()).ToEqual("[INF] msg\n")
+	})
+}
+
+// ---
+
+func panics(f func()) (result bool) {
+	defer func() {
+		result = recover() != nil
+	}()
+
+	f()
+
+	return false
+}
+
+// ---
+
+type tracedError struct {
+	error
+}
+
+func (e tracedError) StackTrace() []runtime.Frame {
+	return []runtime.Frame{{Function: "main.main", File: "/src/main.go", Line: 23}}
+}
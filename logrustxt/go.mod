@@ -0,0 +1,21 @@
+module github.com/pamburus/logftxt/logrustxt
+
+go 1.22
+
+replace github.com/pamburus/logftxt => ../
+
+require (
+	github.com/pamburus/go-tst v0.6.0
+	github.com/pamburus/logftxt v0.9.0
+	github.com/sirupsen/logrus v1.9.3
+	github.com/ssgreg/logf v1.4.2
+)
+
+require (
+	github.com/pamburus/ansitty v0.1.2 // indirect
+	github.com/pamburus/go-ansi-esc v0.5.0 // indirect
+	github.com/veggiemonk/strcase v0.0.0-20240108101409-9f441287a9a9 // indirect
+	golang.org/x/sys v0.27.0 // indirect
+	golang.org/x/term v0.26.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,448 @@
+package logftxt
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pamburus/go-ansi-esc/sgr"
+)
+
+// Valid values for OutputMarkup.
+const (
+	MarkupNone OutputMarkup = iota
+	MarkupHTML
+)
+
+// OutputMarkup selects the way styles of the theme are represented in the output.
+//
+// MarkupNone represents styles with ANSI SGR escape sequences as requested by [ColorSetting].
+// MarkupHTML represents styles with HTML `<span>` elements having inline styles and escapes special HTML characters,
+// so that the output can be placed into a `<pre>` element of an incident report or a wiki page.
+// Clickable callers are represented with `<a>` elements,
+// only `file`, `http` and `https` links and links having the scheme of `caller.url` configuration setting are kept.
+// Unless [ColorSetting] is specified explicitly, all colors of the theme are used as is with MarkupHTML.
+//
+// See also [NewSVGWriter] that renders the output as an SVG image.
+type OutputMarkup int
+
+func (m OutputMarkup) toEncoderOptions(o *encoderOptions) {
+	o.markup = m
+}
+
+func (m OutputMarkup) toAppenderOptions(o *appenderOptions) {
+	o.markup = m
+}
+
+// colorSetting returns color setting to be used with the markup instead of s.
+func (m OutputMarkup) colorSetting(s ColorSetting) ColorSetting {
+	if m != MarkupNone && s == ColorAuto {
+		return ColorTrueColor
+	}
+
+	return s
+}
+
+// ---
+
+// appendHTML appends the text with SGR escape sequences replaced with HTML `<span>` elements
+// and OSC 8 hyperlinks having one of the given schemes replaced with HTML `<a>` elements.
+func appendHTML(buf, text []byte, schemes []string) []byte {
+	var (
+		s         = markupScanner{schemes: schemes}
+		link      string
+		span      bool
+		spanStyle markupStyle
+	)
+
+	closeSpan := func() {
+		if span {
+			buf = append(buf, "</span>"...)
+			span = false
+		}
+	}
+
+	s.scan(text, func(piece []byte) {
+		if span && s.style != spanStyle {
+			closeSpan()
+		}
+
+		if s.link != link {
+			closeSpan()
+
+			if link != "" {
+				buf = append(buf, "</a>"...)
+			}
+
+			if s.link != "" {
+				buf = append(buf, `<a href="`...)
+				buf = appendEscapedHTML(buf, []byte(s.link))
+				buf = append(buf, `">`...)
+			}
+
+			link = s.link
+		}
+
+		if !span && s.style != (markupStyle{}) {
+			buf = append(buf, `<span style="`...)
+			buf = s.style.appendCSS(buf)
+			buf = append(buf, `">`...)
+			span, spanStyle = true, s.style
+		}
+
+		buf = appendEscapedHTML(buf, piece)
+	})
+
+	closeSpan()
+
+	if link != "" {
+		buf = append(buf, "</a>"...)
+	}
+
+	return buf
+}
+
+func appendEscapedHTML(buf, text []byte) []byte {
+	p := 0
+
+	for i, c := range text {
+		var replacement string
+
+		switch c {
+		case '<':
+			replacement = "&lt;"
+		case '>':
+			replacement = "&gt;"
+		case '&':
+			replacement = "&amp;"
+		case '"':
+			replacement = "&#34;"
+		case '\'':
+			replacement = "&#39;"
+		default:
+			continue
+		}
+
+		buf = append(buf, text[p:i]...)
+		buf = append(buf, replacement...)
+		p = i + 1
+	}
+
+	return append(buf, text[p:]...)
+}
+
+// ---
+
+// markupLinkSchemes returns URL schemes of hyperlinks allowed in markup,
+// those are `file`, `http`, `https` and the scheme of the caller URL template.
+func markupLinkSchemes(urlTemplate string) []string {
+	schemes := []string{"file", "http", "https"}
+
+	scheme, _, ok := strings.Cut(urlTemplate, ":")
+	if ok && validURLScheme(scheme) {
+		scheme = strings.ToLower(scheme)
+		if !slices.Contains(schemes, scheme) {
+			schemes = append(schemes, scheme)
+		}
+	}
+
+	return schemes
+}
+
+// validURLScheme reports whether the scheme conforms to RFC 3986.
+func validURLScheme(scheme string) bool {
+	for i, c := range []byte(scheme) {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		case i != 0 && ('0' <= c && c <= '9' || c == '+' || c == '-' || c == '.'):
+		default:
+			return false
+		}
+	}
+
+	return scheme != ""
+}
+
+// ---
+
+// markupScanner splits text into pieces of plain text tracking SGR escape sequences and OSC 8 hyperlinks between them.
+// Hyperlinks having a scheme other than one of the schemes are ignored.
+type markupScanner struct {
+	style   markupStyle
+	link    string
+	schemes []string
+}
+
+// scan calls fn for each piece of plain text in the text, style and link of the scanner are those in effect for the piece.
+// Escape sequences other than SGR and OSC 8 are dropped.
+func (s *markupScanner) scan(text []byte, fn func(piece []byte)) {
+	p := 0
+
+	for i := 0; i < len(text); {
+		if text[i] != esc {
+			i++
+
+			continue
+		}
+
+		if i != p {
+			fn(text[p:i])
+		}
+
+		n := escapeSequenceLen(text[i:])
+		s.apply(text[i : i+n])
+		i += n
+		p = i
+	}
+
+	if p != len(text) {
+		fn(text[p:])
+	}
+}
+
+func (s *markupScanner) apply(seq []byte) {
+	switch {
+	case len(seq) >= 3 && seq[1] == '[' && seq[len(seq)-1] == 'm':
+		s.style.apply(seq[2 : len(seq)-1])
+	case len(seq) >= 4 && seq[1] == ']' && seq[2] == '8' && seq[3] == ';':
+		body := seq[4:]
+		if n := len(body); n != 0 && body[n-1] == bel {
+			body = body[:n-1]
+		} else if n >= 2 && body[n-2] == esc {
+			body = body[:n-2]
+		}
+
+		for i, c := range body {
+			if c == ';' {
+				s.link = string(body[i+1:])
+				if !s.allowed(s.link) {
+					s.link = ""
+				}
+
+				break
+			}
+		}
+	}
+}
+
+// allowed reports whether the link has one of the schemes of the scanner.
+func (s *markupScanner) allowed(link string) bool {
+	scheme, _, ok := strings.Cut(link, ":")
+
+	return ok && validURLScheme(scheme) && slices.Contains(s.schemes, strings.ToLower(scheme))
+}
+
+// ---
+
+// markupStyle is a style of text defined by SGR escape sequences, zero colors mean default ones.
+type markupStyle struct {
+	foreground sgr.Color
+	background sgr.Color
+	modes      sgr.ModeSet
+}
+
+// apply updates the style by SGR parameters like `1;38;5;208`.
+func (s *markupStyle) apply(params []byte) {
+	var args []int
+
+	for len(params) != 0 || args == nil {
+		var arg []byte
+
+		arg, params = cutByte(params, ';')
+		value, _ := strconv.Atoi(string(arg))
+		args = append(args, value)
+	}
+
+	for i := 0; i < len(args); i++ {
+		switch code := args[i]; {
+		case code == 0:
+			*s = markupStyle{}
+		case code >= 30 && code <= 37:
+			s.foreground = sgr.BasicColor(code - 30).Color() //nolint:gosec // G115: the value is at most 7
+		case code >= 90 && code <= 97:
+			s.foreground = sgr.BasicColor(code - 90 + 8).Color() //nolint:gosec // G115: the value is at most 15
+		case code == 39:
+			s.foreground = 0
+		case code >= 40 && code <= 47:
+			s.background = sgr.BasicColor(code - 40).Color() //nolint:gosec // G115: the value is at most 7
+		case code >= 100 && code <= 107:
+			s.background = sgr.BasicColor(code - 100 + 8).Color() //nolint:gosec // G115: the value is at most 15
+		case code == 49:
+			s.background = 0
+		case code == 38, code == 48, code == 58:
+			color, n := extendedColor(args[i+1:])
+			i += n
+
+			switch code {
+			case 38:
+				s.foreground = color
+			case 48:
+				s.background = color
+			}
+		default:
+			if mode, ok := sgrSetModes[code]; ok {
+				s.modes = s.modes.With(mode)
+			}
+
+			for _, mode := range sgrResetModes[code] {
+				s.modes = s.modes.Without(mode)
+			}
+		}
+	}
+}
+
+// colors returns CSS values of foreground and background colors taking reversed mode into account.
+// Empty value means the default color.
+func (s markupStyle) colors() (string, string) {
+	foreground, background := cssColor(s.foreground, ""), cssColor(s.background, "")
+
+	if s.modes.Has(sgr.Reversed) {
+		foreground, background = cssColor(s.background, markupBackground), cssColor(s.foreground, markupForeground)
+	}
+
+	return foreground, background
+}
+
+func (s markupStyle) appendCSS(buf []byte) []byte {
+	foreground, background := s.colors()
+
+	if foreground != "" {
+		buf = append(buf, "color:"...)
+		buf = append(buf, foreground...)
+		buf = append(buf, ';')
+	}
+
+	if background != "" {
+		buf = append(buf, "background-color:"...)
+		buf = append(buf, background...)
+		buf = append(buf, ';')
+	}
+
+	if s.modes.Has(sgr.Bold) {
+		buf = append(buf, "font-weight:bold;"...)
+	}
+
+	if s.modes.Has(sgr.Faint) {
+		buf = append(buf, "opacity:0.6;"...)
+	}
+
+	if s.modes.Has(sgr.Italic) {
+		buf = append(buf, "font-style:italic;"...)
+	}
+
+	if decoration := s.textDecoration(); decoration != "" {
+		buf = append(buf, "text-decoration:"...)
+		buf = append(buf, decoration...)
+		buf = append(buf, ';')
+	}
+
+	if s.modes.Has(sgr.Concealed) {
+		buf = append(buf, "visibility:hidden;"...)
+	}
+
+	return buf
+}
+
+func (s markupStyle) textDecoration() string {
+	result := ""
+	add := func(mode sgr.Mode, value string) {
+		if s.modes.Has(mode) {
+			if result != "" {
+				result += " "
+			}
+
+			result += value
+		}
+	}
+
+	add(sgr.Underlined, "underline")
+	add(sgr.DoublyUnderlined, "underline")
+	add(sgr.CrossedOut, "line-through")
+	add(sgr.Overlined, "overline")
+
+	return result
+}
+
+// ---
+
+// extendedColor parses arguments of an extended color SGR parameter like `5;208` or `2;255;128;0`.
+// It returns the color and the number of consumed arguments.
+func extendedColor(args []int) (sgr.Color, int) {
+	switch {
+	case len(args) >= 2 && args[0] == 5:
+		return sgr.PaletteColor(uint8(args[1])).Color(), 2 //nolint:gosec // G115: out of range values are truncated
+	case len(args) >= 4 && args[0] == 2:
+		return sgr.RGB(uint8(args[1]), uint8(args[2]), uint8(args[3])).Color(), 4 //nolint:gosec // G115: out of range values are truncated
+	}
+
+	return 0, len(args)
+}
+
+// cssColor returns CSS value of the color or the given default value if the color is the default one.
+func cssColor(c sgr.Color, defaultValue string) string {
+	var rgb sgr.RGBColor
+
+	if v, ok := c.RGBColor(); ok {
+		rgb = v
+	} else if v, ok := c.BasicColor(); ok {
+		rgb = basicColors[v]
+	} else if v, ok := c.PaletteColor(); ok {
+		rgb = paletteToRGB(v)
+	} else {
+		return defaultValue
+	}
+
+	return string([]byte{'#', hex[rgb.R()>>4], hex[rgb.R()&0xf], hex[rgb.G()>>4], hex[rgb.G()&0xf], hex[rgb.B()>>4], hex[rgb.B()&0xf]})
+}
+
+func cutByte(text []byte, sep byte) ([]byte, []byte) {
+	for i, c := range text {
+		if c == sep {
+			return text[:i], text[i+1:]
+		}
+	}
+
+	return text, nil
+}
+
+// ---
+
+const (
+	markupForeground = "#d4d4d4"
+	markupBackground = "#1e1e1e"
+)
+
+var sgrSetModes = map[int]sgr.Mode{
+	1:  sgr.Bold,
+	2:  sgr.Faint,
+	3:  sgr.Italic,
+	4:  sgr.Underlined,
+	5:  sgr.SlowBlink,
+	6:  sgr.RapidBlink,
+	7:  sgr.Reversed,
+	8:  sgr.Concealed,
+	9:  sgr.CrossedOut,
+	21: sgr.DoublyUnderlined,
+	51: sgr.Framed,
+	52: sgr.Encircled,
+	53: sgr.Overlined,
+}
+
+var sgrResetModes = map[int][]sgr.Mode{
+	22: {sgr.Bold, sgr.Faint},
+	23: {sgr.Italic},
+	24: {sgr.Underlined, sgr.DoublyUnderlined},
+	25: {sgr.SlowBlink, sgr.RapidBlink},
+	27: {sgr.Reversed},
+	28: {sgr.Concealed},
+	29: {sgr.CrossedOut},
+	54: {sgr.Framed, sgr.Encircled},
+	55: {sgr.Overlined},
+}
+
+// ---
+
+var (
+	_ EncoderOption  = MarkupHTML
+	_ AppenderOption = MarkupHTML
+)
@@ -0,0 +1,124 @@
+package logftxt_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ssgreg/logf"
+
+	"github.com/pamburus/go-tst/tst"
+	"github.com/pamburus/logftxt"
+)
+
+func TestMarkupHTML(tt *testing.T) {
+	t := tst.New(tt)
+
+	theme, err := logftxt.ReadTheme(strings.NewReader(strings.Join([]string{
+		"theme:",
+		"  version: '1.0'",
+		"  items: [message, fields]",
+		"  formatting:",
+		"    message: {outer: {style: {foreground: red, modes: [bold]}}}",
+		"    field: {separator: {text: '='}}",
+		"    key: {outer: {style: {background: '#ff8700'}}}",
+	}, "\n")))
+	t.Expect(err).ToNot(tst.HaveOccurred())
+
+	env := logftxt.Environment(func(string) (string, bool) { return "", false })
+
+	encode := func(options ...logftxt.EncoderOption) string {
+		enc := logftxt.NewEncoder(append([]logftxt.EncoderOption{env, &logftxt.Config{}, theme, logftxt.MarkupHTML}, options...)...)
+		buf := logf.NewBuffer()
+		t.Expect(enc.Encode(buf, logf.Entry{Level: logf.LevelInfo, Text: "a<b>&c", Fields: []logf.Field{logf.Int("k", 1)}})).ToSucceed()
+
+		return buf.String()
+	}
+
+	t.Run("Default", func(t tst.Test) {
+		t.Expect(encode()).ToEqual(
+			`<span style="color:#cd0000;font-weight:bold;">a&lt;b&gt;&amp;c</span> <span style="background-color:#ff8700;">k</span>=1` + "\n",
+		)
+	})
+
+	t.Run("ColorNever", func(t tst.Test) {
+		t.Expect(encode(logftxt.ColorNever)).ToEqual("a&lt;b&gt;&amp;c k=1\n")
+	})
+
+	t.Run("Color16", func(t tst.Test) {
+		t.Expect(encode(logftxt.Color16)).ToEqual(
+			`<span style="color:#cd0000;font-weight:bold;">a&lt;b&gt;&amp;c</span> <span style="background-color:#cdcd00;">k</span>=1` + "\n",
+		)
+	})
+
+	t.Run("Hyperlink", func(t tst.Test) {
+		enc := logftxt.NewEncoder(env, &logftxt.Config{}, logftxt.MarkupHTML, logftxt.CallerHyperlink("https://example.com/src?line={line}&x"))
+		buf := logf.NewBuffer()
+		caller := logf.EntryCaller{File: "main.go", Line: 7, Specified: true}
+		t.Expect(enc.Encode(buf, logf.Entry{Level: logf.LevelInfo, Text: "msg", Caller: caller})).ToSucceed()
+		t.Expect(strings.HasSuffix(buf.String(), `<a href="https://example.com/src?line=7&amp;x"><span style="opacity:0.6;font-style:italic;">main.go:7</span></a>`+"\n")).ToBeTrue()
+		t.Expect(strings.Contains(buf.String(), "\x1b")).ToBeFalse()
+	})
+
+	t.Run("Injection", func(t tst.Test) {
+		const hostile = "\x1b]8;;javascript:alert(1)\x07x\x1b]8;;\x07"
+
+		enc := logftxt.NewEncoder(env, &logftxt.Config{}, theme, logftxt.MarkupHTML, logftxt.ColorNever)
+		buf := logf.NewBuffer()
+		t.Expect(enc.Encode(buf, logf.Entry{Level: logf.LevelInfo, Text: hostile, Fields: []logf.Field{logf.Error(errors.New(hostile))}})).ToSucceed()
+		escaped := `\u001b]8;;javascript:alert(1)` + "\ax" + `\u001b]8;;` + "\a"
+		t.Expect(buf.String()).ToEqual(escaped + " error=" + escaped + "\n")
+	})
+
+	t.Run("LinkSchemes", func(t tst.Test) {
+		caller := logf.EntryCaller{File: "/src/main.go", Line: 7, Specified: true}
+		encode := func(cfg logftxt.Config, options ...logftxt.EncoderOption) string {
+			enc := logftxt.NewEncoder(append([]logftxt.EncoderOption{env, cfg, logftxt.MarkupHTML, logftxt.ColorNever}, options...)...)
+			buf := logf.NewBuffer()
+			t.Expect(enc.Encode(buf, logf.Entry{Level: logf.LevelInfo, Text: "msg", Caller: caller})).ToSucceed()
+
+			return buf.String()
+		}
+
+		t.Expect(encode(logftxt.Config{}, logftxt.CallerHyperlink("javascript:alert({line})"))).ToEqual("[INF] msg @ src/main.go:7\n")
+		t.Expect(encode(logftxt.Config{}, logftxt.CallerHyperlink("vscode://file{path}:{line}"))).ToEqual("[INF] msg @ src/main.go:7\n")
+
+		cfg := logftxt.Config{}
+		cfg.Caller.URL = "VSCode://file{path}:{line}"
+		t.Expect(encode(cfg, logftxt.CallerHyperlink(cfg.Caller.URL))).ToEqual(
+			`[INF] msg @ <a href="VSCode://file/src/main.go:7">src/main.go:7</a>` + "\n",
+		)
+	})
+}
+
+func TestSVGWriter(tt *testing.T) {
+	t := tst.New(tt)
+
+	render := func(text string) string {
+		buf := &bytes.Buffer{}
+		w := logftxt.NewSVGWriter(buf)
+		_, err := w.Write([]byte(text))
+		t.Expect(err).ToNot(tst.HaveOccurred())
+		t.Expect(w.Close()).ToSucceed()
+
+		return buf.String()
+	}
+
+	t.Run("Styles", func(t tst.Test) {
+		t.Expect(render("a\x1b[1;38;5;208mb\x1b[22;39m<\n\x1b[7mc\x1b[0m\n")).ToEqual(strings.Join([]string{
+			`<svg xmlns="http://www.w3.org/2000/svg" width="45.2" height="56" font-family="monospace" font-size="14">`,
+			`<rect width="100%" height="100%" fill="#1e1e1e"/>`,
+			`<text y="24" xml:space="preserve" fill="#d4d4d4"><tspan x="10">a</tspan><tspan x="18.4" fill="#ff8700" font-weight="bold">b</tspan>` +
+				`<tspan x="26.8">&lt;</tspan></text>`,
+			`<rect x="10" y="28" width="8.4" height="18" fill="#d4d4d4"/>`,
+			`<text y="42" xml:space="preserve" fill="#d4d4d4"><tspan x="10" fill="#1e1e1e">c</tspan></text>`,
+			`</svg>`,
+			``,
+		}, "\n"))
+	})
+
+	t.Run("Empty", func(t tst.Test) {
+		t.Expect(strings.HasPrefix(render(""), `<svg xmlns="http://www.w3.org/2000/svg" width="20" height="38"`)).ToBeTrue()
+	})
+}
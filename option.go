@@ -1,6 +1,11 @@
 package logftxt
 
 import (
+	"maps"
+	"time"
+
+	"github.com/ssgreg/logf"
+
 	"github.com/pamburus/logftxt/internal/pkg/env"
 )
 
@@ -11,9 +16,18 @@ const (
 	ColorAuto ColorSetting = iota
 	ColorNever
 	ColorAlways
+	Color16
+	Color256
+	ColorTrueColor
 )
 
 // ColorSetting allows to explicitly specify preference on using colors and other ANSI SGR escape sequences in output.
+//
+// Values Color16, Color256 and ColorTrueColor enable colors the same way as ColorAlways does it
+// and additionally specify the color depth supported by the terminal, so that colors of the theme
+// that are not supported are replaced with the closest supported ones.
+// Otherwise, the color depth is detected using `COLORTERM` and `TERM` environment variables,
+// and terminals that do not advertise true color support are assumed to support 256 or 16 colors.
 type ColorSetting int
 
 func (s ColorSetting) toAppenderOptions(o *appenderOptions) {
@@ -31,12 +45,40 @@ func (s ColorSetting) resolved(e Environment) ColorSetting {
 			s = ColorAlways
 		case env.ColorNever:
 			s = ColorNever
+		case env.Color16:
+			s = Color16
+		case env.Color256:
+			s = Color256
+		case env.ColorTrueColor:
+			s = ColorTrueColor
 		}
 	}
 
 	return s
 }
 
+// depth returns the color depth requested by s or detected using the environment.
+func (s ColorSetting) depth(e Environment) colorDepth {
+	switch s {
+	case Color16:
+		return colorDepth16
+	case Color256:
+		return colorDepth256
+	case ColorTrueColor:
+		return colorDepthTrueColor
+	}
+
+	switch env.ColorDepth(e) {
+	case env.Color16:
+		return colorDepth16
+	case env.Color256:
+		return colorDepth256
+	}
+
+	// TERM is not set, which is typical for Windows terminals supporting true color.
+	return colorDepthTrueColor
+}
+
 // ---
 
 // FlattenObjects tells encoder wether to flatten nested objects when encoding.
@@ -62,8 +104,9 @@ func (s FlattenObjectsSetting) toAppenderOptions(o *appenderOptions) {
 // Applicable types: [CallerEncodeFunc], [ColorSetting], [PoolSizeLimit],
 // [Config], [ConfigProvideFunc], [Environment], [FSOption],
 // [Theme], [ThemeProvideFunc], [ThemeEnvironmentRef], [ThemeRef],
-// [FlattenObjectsSetting], [TimestampEncodeFunc], [TimeValueEncodeFunc],
-// [DurationEncodeFunc], [ErrorEncodeFunc].
+// [FlattenObjectsSetting], [MultilineLayout], [TimestampEncodeFunc], [TimeValueEncodeFunc],
+// [DurationEncodeFunc], [ErrorEncodeFunc], [ErrorFormat], [RedactFieldsSetting], [CallerFormat],
+// [LineWidth], [LineOverflow], [LevelNames], [ThemeMode], [WatchConfig], [OutputMarkup], [OutputMode].
 type AppenderOption interface {
 	toAppenderOptions(*appenderOptions)
 }
@@ -75,8 +118,9 @@ type AppenderOption interface {
 // Applicable types: [CallerEncodeFunc], [ColorSetting], [PoolSizeLimit],
 // [Config], [ConfigProvideFunc], [Environment], [FSOption],
 // [Theme], [ThemeProvideFunc], [ThemeEnvironmentRef], [ThemeRef],
-// [FlattenObjectsSetting], [TimestampEncodeFunc], [TimeValueEncodeFunc],
-// [DurationEncodeFunc], [ErrorEncodeFunc].
+// [FlattenObjectsSetting], [MultilineLayout], [TimestampEncodeFunc], [TimeValueEncodeFunc],
+// [DurationEncodeFunc], [ErrorEncodeFunc], [ErrorFormat], [RedactFieldsSetting], [CallerFormat],
+// [LineWidth], [LineOverflow], [LevelNames], [ThemeMode], [WatchConfig], [OutputMarkup], [OutputMode].
 type EncoderOption interface {
 	AppenderOption
 	toEncoderOptions(*encoderOptions)
@@ -97,6 +141,62 @@ func (v PoolSizeLimit) toAppenderOptions(o *appenderOptions) {
 
 // ---
 
+// LevelNames maps log levels to names used to look up formatting of the level item in the theme.
+// It allows to style custom levels beyond the four standard ones, for example
+//
+//	logftxt.LevelNames{logf.LevelDebug + 1: "trace"}
+//
+// Standard levels are named `error`, `warning`, `info` and `debug` unless they are renamed.
+// A level that has no formatting in the theme is displayed as the nearest standard level.
+type LevelNames map[logf.Level]string
+
+func (v LevelNames) toEncoderOptions(o *encoderOptions) {
+	o.levelNames = maps.Clone(o.levelNames)
+	if o.levelNames == nil {
+		o.levelNames = make(LevelNames, len(v))
+	}
+
+	maps.Copy(o.levelNames, v)
+}
+
+func (v LevelNames) toAppenderOptions(o *appenderOptions) {
+	v.toEncoderOptions(&o.encoderOptions)
+}
+
+// DefaultLevelNames returns names of the custom levels [LevelTrace], [LevelFatal] and [LevelPanic],
+// those are `trace`, `fatal` and `panic`.
+// Adapters of other logging libraries use them to style levels that logf does not have.
+func DefaultLevelNames() LevelNames {
+	return LevelNames{
+		LevelTrace: "trace",
+		LevelFatal: "fatal",
+		LevelPanic: "panic",
+	}
+}
+
+// Custom levels for levels of other logging libraries that logf does not have, see [DefaultLevelNames].
+const (
+	LevelPanic = logf.LevelError - 2
+	LevelFatal = logf.LevelError - 1
+	LevelTrace = logf.LevelDebug + 1
+)
+
+// ---
+
+// LineWidth sets the line width in terminal cells used by [LineOverflow] settings.
+// Zero value means the width detected by NewAppender if the writer is a terminal.
+type LineWidth int
+
+func (v LineWidth) toEncoderOptions(o *encoderOptions) {
+	o.lineWidth = int(v)
+}
+
+func (v LineWidth) toAppenderOptions(o *appenderOptions) {
+	o.lineWidth = int(v)
+}
+
+// ---
+
 func defaultAppenderOptions() appenderOptions {
 	return appenderOptions{
 		defaultEncoderOptions(),
@@ -132,6 +232,7 @@ type encoderOptions struct {
 	color           ColorSetting
 	provideConfig   []ConfigProvideFunc
 	provideTheme    []ThemeProvideFunc
+	callerFormat    CallerFormat
 	encodeCaller    CallerEncodeFunc
 	encodeError     ErrorEncodeFunc
 	encodeTimestamp TimestampEncodeFunc
@@ -139,6 +240,18 @@ type encoderOptions struct {
 	encodeDuration  DurationEncodeFunc
 	poolSizeLimit   PoolSizeLimit
 	flattenObjects  bool
+	multiline       MultilineLayout
+	errorFormat     ErrorFormat
+	redactFields    []string
+	lineWidth       int
+	terminalWidth   int
+	overflow        LineOverflow
+	levelNames      LevelNames
+	themeMode       ThemeMode
+	watchInterval   time.Duration
+	markup          OutputMarkup
+	outputMode      OutputMode
+	omitZeroTime    bool
 }
 
 func (o encoderOptions) With(other []EncoderOption) encoderOptions {
@@ -156,4 +269,8 @@ var (
 	_ EncoderOption  = ColorAlways
 	_ AppenderOption = PoolSizeLimit(0)
 	_ EncoderOption  = PoolSizeLimit(0)
+	_ AppenderOption = LineWidth(0)
+	_ EncoderOption  = LineWidth(0)
+	_ AppenderOption = LevelNames(nil)
+	_ EncoderOption  = LevelNames(nil)
 )
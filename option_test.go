@@ -29,6 +29,8 @@ func TestOptions(tt *testing.T) {
 	t.Expect(eo(DurationAsHMS(Precision(2))).encodeDuration).ToNot(tst.BeZero())
 	t.Expect(ao(ErrorLong()).encodeError).ToNot(tst.BeZero())
 	t.Expect(eo(ErrorLong()).encodeError).ToNot(tst.BeZero())
+	t.Expect(ao(CallerFormatHyperlink).callerFormat).ToEqual(CallerFormatHyperlink)
+	t.Expect(eo(CallerFormatHyperlink).callerFormat).ToEqual(CallerFormatHyperlink)
 	t.Expect(ao(&Config{}).provideConfig).ToNot(tst.BeZero())
 	t.Expect(ao(&Config{}).provideConfig).ToNot(tst.BeZero())
 	t.Expect(ao(ConfigFromDefaultPath()).provideConfig).ToNot(tst.BeZero())
@@ -61,6 +63,7 @@ func TestOptions(tt *testing.T) {
 	t.Expect(DefaultDomain().FS()).ToNotEqual(nil)
 	t.Expect(ao(WithFS(SystemFS())).fs).ToNot(tst.BeZero())
 	t.Expect(eo(WithFS(SystemFS())).fs).ToNot(tst.BeZero())
+	t.Expect(eo(DefaultLevelNames()).levelNames).ToEqual(LevelNames{LevelTrace: "trace", LevelFatal: "fatal", LevelPanic: "panic"})
 }
 
 func TestTimeLayout(tt *testing.T) {
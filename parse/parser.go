@@ -0,0 +1,986 @@
+// Package parse provides a parser that reads text produced by logftxt encoder back into log entries.
+package parse
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ssgreg/logf"
+
+	"github.com/pamburus/logftxt"
+	"github.com/pamburus/logftxt/internal/pkg/layout"
+	"github.com/pamburus/logftxt/internal/pkg/themecfg"
+	"github.com/pamburus/logftxt/jsonlog"
+)
+
+// New returns a new Parser that reconstructs log entries from the text
+// produced by an encoder configured with the given theme and configuration.
+// Nil theme or configuration means the default one.
+//
+// Levels are recognized by the formatting of the standard levels and the levels having custom formatting in the theme,
+// including the levels named with [logftxt.DefaultLevelNames].
+func New(theme *logftxt.Theme, config *logftxt.Config) *Parser {
+	if theme == nil {
+		theme = logftxt.DefaultTheme()
+	}
+
+	if config == nil {
+		config = logftxt.DefaultConfig()
+	}
+
+	lt := theme.Layout(logftxt.DefaultLevelNames())
+
+	// Standard levels go first, so that they win if a custom level has the same text.
+	levels := []logf.Level{logf.LevelError, logf.LevelWarn, logf.LevelInfo, logf.LevelDebug}
+	custom := make([]logf.Level, 0, len(lt.LevelItems))
+
+	for level := range lt.LevelItems {
+		if !slices.Contains(levels, level) {
+			custom = append(custom, level)
+		}
+	}
+
+	slices.Sort(custom)
+
+	return &Parser{
+		layout:          lt,
+		timestampLayout: config.Timestamp.Format,
+		timeValueLayout: config.Timestamp.Format,
+		levels:          append(levels, custom...),
+		hasLevel:        slices.Contains(lt.Items, themecfg.ItemLevel),
+		gutter:          lt.Gutter.Outer.Prefix + lt.Gutter.Text + lt.Gutter.Outer.Suffix,
+	}
+}
+
+// ---
+
+// Parser reconstructs log entries from the text produced by logftxt encoder.
+//
+// Parser understands output with or without ANSI SGR escape sequences.
+// Field values are restored with the best matching type, so that
+// numbers, booleans, nulls, times, errors, arrays and objects can be
+// encoded again the same way. Other values including durations are restored as strings.
+// Per-level formatting of the line, logger, message and fields is taken into account.
+//
+// An entry may span several lines where each line following the first one starts with the gutter of the theme.
+// Such lines hold fields wrapped due to the line width, the remainder of a multi-line message,
+// multi-line string field values and stack trace frames of errors.
+// Errors having stack trace frames are restored with a StackTrace method returning the frames.
+type Parser struct {
+	layout          layout.Layout
+	timestampLayout string
+	timeValueLayout string
+	levels          []logf.Level
+	hasLevel        bool
+	gutter          string
+}
+
+// Parse parses a single entry without trailing line feed.
+// Lines of the entry following the first one are separated by line feeds.
+func (p *Parser) Parse(text string) (logf.Entry, error) {
+	first, rest, _ := strings.Cut(escapeSequence.ReplaceAllLiteralString(strings.TrimRight(text, "\r\n"), ""), "\n")
+
+	var continuations []string
+
+	for rest != "" {
+		var line string
+		line, rest, _ = strings.Cut(rest, "\n")
+
+		line, ok := strings.CutPrefix(line, p.gutter)
+		if !ok || p.gutter == "" {
+			return logf.Entry{}, UnrecognizedLineError{text}
+		}
+
+		continuations = append(continuations, line)
+	}
+
+	for i := range p.layout.Levels {
+		level := &p.layout.Levels[i]
+		if slices.Contains(p.layout.Levels[:i], *level) {
+			continue
+		}
+
+		if entry, ok := p.parseEntry(level, first, slices.Clone(continuations)); ok {
+			return entry, nil
+		}
+	}
+
+	return logf.Entry{}, UnrecognizedLineError{text}
+}
+
+// ParseAll parses all entries read from the given reader.
+func (p *Parser) ParseAll(reader io.Reader) ([]logf.Entry, error) {
+	var (
+		entries []logf.Entry
+		lines   []string
+		start   int
+	)
+
+	flush := func() error {
+		if len(lines) == 0 {
+			return nil
+		}
+
+		entry, err := p.Parse(strings.Join(lines, "\n"))
+		if err != nil {
+			return fmt.Errorf("line %d: %w", start, err)
+		}
+
+		entries = append(entries, entry)
+		lines = lines[:0]
+
+		return nil
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(nil, maxParsedLineSize)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+
+		if !p.continued(line) {
+			err := flush()
+			if err != nil {
+				return entries, err
+			}
+
+			start = n
+		}
+
+		lines = append(lines, line)
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return entries, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	return entries, flush()
+}
+
+// parseEntry parses the lines of an entry formatted according to the given level.
+// Lines following the first one that start with a field key may be the fields wrapped due to the line width,
+// so the longest sequence of such lines that can be parsed as a part of the first line is preferred.
+func (p *Parser) parseEntry(level *layout.Level, first string, continuations []string) (logf.Entry, bool) {
+	first, ok := strings.CutPrefix(first, level.Line.Outer.Prefix)
+	if !ok {
+		return logf.Entry{}, false
+	}
+
+	last := &first
+	if n := len(continuations); n != 0 {
+		last = &continuations[n-1]
+	}
+
+	*last, ok = strings.CutSuffix(*last, level.Line.Outer.Suffix)
+	if !ok {
+		return logf.Entry{}, false
+	}
+
+	wrapped := 0
+	for wrapped < len(continuations) {
+		lp := lineParser{p, level, continuations[wrapped]}
+		if _, _, ok := lp.key(0); !ok {
+			break
+		}
+
+		wrapped++
+	}
+
+	for n := wrapped; n >= 0; n-- {
+		lp := lineParser{p, level, strings.Join(append([]string{first}, continuations[:n]...), " ")}
+
+		// The line is formatted according to the level, so the parsed level must have the same formatting.
+		var entry logf.Entry
+		if !lp.parseItems(p.layout.Items, 0, &entry) || (p.hasLevel && p.layout.Levels[levelIndex(entry.Level)] != *level) {
+			continue
+		}
+
+		if lp.parseBlocks(continuations[n:], &entry) {
+			return entry, true
+		}
+	}
+
+	return logf.Entry{}, false
+}
+
+// continued reports whether the line is a continuation of the previous one.
+func (p *Parser) continued(line string) bool {
+	return p.gutter != "" && strings.HasPrefix(escapeSequence.ReplaceAllLiteralString(line, ""), p.gutter)
+}
+
+// ---
+
+// UnrecognizedLineError is an error that is returned in case a line does not match the theme layout.
+type UnrecognizedLineError struct {
+	Line string
+}
+
+// Error returns error message.
+func (e UnrecognizedLineError) Error() string {
+	return fmt.Sprintf("line %q does not match theme layout", e.Line)
+}
+
+// ---
+
+type lineParser struct {
+	p     *Parser
+	level *layout.Level
+	s     string
+}
+
+type candidate struct {
+	end   int
+	apply func(*logf.Entry)
+}
+
+func (lp *lineParser) parseItems(items []themecfg.Item, pos int, entry *logf.Entry) bool {
+	if len(items) == 0 {
+		return pos == len(lp.s)
+	}
+
+	start := pos
+	if pos != 0 {
+		if pos == len(lp.s) || lp.s[pos] != ' ' {
+			return !lp.required(items[0]) && lp.parseItems(items[1:], pos, entry)
+		}
+
+		start++
+
+		// Skip padding added by alignment.
+		for start < len(lp.s) && lp.s[start] == ' ' {
+			start++
+		}
+	}
+
+	// Message is free text, so prefer to treat everything that looks like fields as fields.
+	if items[0] == themecfg.ItemMessage && lp.parseItems(items[1:], pos, entry) {
+		return true
+	}
+
+	for _, c := range lp.candidates(items, start) {
+		if lp.parseItems(items[1:], c.end, entry) {
+			c.apply(entry)
+
+			return true
+		}
+	}
+
+	return !lp.required(items[0]) && lp.parseItems(items[1:], pos, entry)
+}
+
+func (lp *lineParser) required(it themecfg.Item) bool {
+	switch it {
+	case themecfg.ItemTimestamp:
+		return lp.p.timestampLayout != ""
+	case themecfg.ItemLevel:
+		return true
+	default:
+		return false
+	}
+}
+
+func (lp *lineParser) candidates(items []themecfg.Item, pos int) []candidate {
+	lt := &lp.p.layout
+
+	switch items[0] {
+	case themecfg.ItemTimestamp:
+		return lp.timestampCandidates(pos)
+	case themecfg.ItemLevel:
+		for _, value := range lp.p.levels {
+			level := lt.LevelItems[value]
+			if end, ok := lp.match(pos, level.Outer.Prefix, level.Text, level.Outer.Suffix); ok {
+				return []candidate{{end, func(e *logf.Entry) { e.Level = value }}}
+			}
+		}
+	case themecfg.ItemLogger:
+		logger := &lp.level.Logger.Outer
+		if logger.Prefix == "" && logger.Suffix == "" {
+			return nil
+		}
+
+		if text, end, ok := lp.wrapped(pos, logger, false); ok && text != "" {
+			return []candidate{{end, func(e *logf.Entry) { e.LoggerName = text }}}
+		}
+	case themecfg.ItemMessage:
+		return lp.messageCandidates(pos, items[1:])
+	case themecfg.ItemFields:
+		return lp.fieldsCandidates(pos)
+	case themecfg.ItemCaller:
+		if text, end, ok := lp.wrapped(pos, &lt.Caller.Outer, false); ok {
+			if caller, ok := jsonlog.ParseCaller(text); ok {
+				return []candidate{{end, func(e *logf.Entry) { e.Caller = caller }}}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (lp *lineParser) timestampCandidates(pos int) []candidate {
+	layout := lp.p.timestampLayout
+	if layout == "" {
+		return nil
+	}
+
+	outer := &lp.p.layout.Timestamp.Outer
+	if !strings.HasPrefix(lp.s[pos:], outer.Prefix) {
+		return nil
+	}
+
+	begin := pos + len(outer.Prefix)
+	end := skipWords(lp.s, begin, countWords(layout))
+
+	text, ok := strings.CutSuffix(lp.s[begin:end], outer.Suffix)
+	if !ok {
+		return nil
+	}
+
+	ts, err := parseTime(layout, text)
+	if err != nil {
+		return nil
+	}
+
+	return []candidate{{end, func(e *logf.Entry) { e.Time = ts }}}
+}
+
+// messageCandidates returns possible ends of the message starting at pos.
+// Message is free text, so it is anchored on the positions where one of the next items may start
+// instead of trying every space in the line.
+func (lp *lineParser) messageCandidates(pos int, next []themecfg.Item) []candidate {
+	outer := &lp.level.Message.Outer
+	if !strings.HasPrefix(lp.s[pos:], outer.Prefix) {
+		return nil
+	}
+
+	var result []candidate
+
+	begin := pos + len(outer.Prefix)
+
+	for end := begin + 1; end <= len(lp.s); end++ {
+		if end != len(lp.s) && (lp.s[end] != ' ' || !lp.anchor(end, next)) {
+			continue
+		}
+
+		if text, ok := strings.CutSuffix(lp.s[begin:end], outer.Suffix); ok && text != "" {
+			result = append(result, candidate{end, func(e *logf.Entry) { e.Text = text }})
+		}
+	}
+
+	return result
+}
+
+// parseBlocks parses multi-line blocks following the main line of the entry.
+// The remainder of the message goes first, then each field value or stack trace frames of an error
+// are preceded by a line with the field key and indented.
+func (lp *lineParser) parseBlocks(lines []string, entry *logf.Entry) bool {
+	var pending []logf.Field
+
+	i := 0
+	for ; i < len(lines) && !lp.blockHeader(lines, i); i++ {
+		entry.Text += "\n" + lines[i]
+	}
+
+	for i < len(lines) {
+		key, _, _ := lp.sub(lines[i]).key(0)
+
+		var values []string
+
+		for i++; i < len(lines); i++ {
+			value, ok := strings.CutPrefix(lines[i], lp.p.layout.BlockIndent)
+			if !ok {
+				break
+			}
+
+			values = append(values, value)
+		}
+
+		if i != len(lines) && !lp.blockHeader(lines, i) {
+			return false
+		}
+
+		pending = lp.appendBlock(entry, key, values, pending)
+	}
+
+	entry.Fields = append(entry.Fields, pending...)
+
+	return true
+}
+
+// blockHeader reports whether the i-th line consists of a field key and is followed by an indented value.
+func (lp *lineParser) blockHeader(lines []string, i int) bool {
+	if i+1 >= len(lines) || !strings.HasPrefix(lines[i+1], lp.p.layout.BlockIndent) {
+		return false
+	}
+
+	_, end, ok := lp.sub(lines[i]).key(0)
+
+	return ok && end == len(lines[i])
+}
+
+// appendBlock sets stack trace frames of the error field with the given key
+// or adds a multi-line string field to the pending ones if the values are not stack trace frames.
+// Pending fields are inserted before the error field, so that the blocks are encoded in the same order again.
+func (lp *lineParser) appendBlock(entry *logf.Entry, key string, values []string, pending []logf.Field) []logf.Field {
+	if frames, ok := lp.frames(values); ok {
+		for i := range entry.Fields {
+			field := &entry.Fields[i]
+			if field.Key != key {
+				continue
+			}
+
+			if message, ok := fieldText(field); ok {
+				*field = logf.NamedError(key, tracedError{message, frames})
+				entry.Fields = slices.Insert(entry.Fields, i, pending...)
+
+				return nil
+			}
+		}
+	}
+
+	return append(pending, logf.String(key, strings.Join(values, "\n")))
+}
+
+func (lp *lineParser) frames(values []string) ([]runtime.Frame, bool) {
+	frames := make([]runtime.Frame, len(values))
+
+	for i, value := range values {
+		frame, ok := lp.frame(value)
+		if !ok {
+			return nil, false
+		}
+
+		frames[i] = frame
+	}
+
+	return frames, true
+}
+
+// frame parses a stack trace frame consisting of function, file and line.
+func (lp *lineParser) frame(text string) (runtime.Frame, bool) {
+	ff := &lp.p.layout.Frame
+
+	text, ok := lp.unwrap(text, &ff.Outer)
+	if !ok {
+		return runtime.Frame{}, false
+	}
+
+	text, ok = strings.CutPrefix(text, ff.Function.Outer.Prefix)
+	if !ok {
+		return runtime.Frame{}, false
+	}
+
+	function, text, ok := strings.Cut(text, ff.Function.Outer.Suffix+ff.Separator+ff.File.Outer.Prefix)
+	if !ok || function == "" {
+		return runtime.Frame{}, false
+	}
+
+	i := strings.LastIndex(text, ff.File.Outer.Suffix+ff.Line.Outer.Prefix)
+	if i < 0 {
+		return runtime.Frame{}, false
+	}
+
+	line, ok := strings.CutSuffix(text[i+len(ff.File.Outer.Suffix)+len(ff.Line.Outer.Prefix):], ff.Line.Outer.Suffix)
+	if !ok {
+		return runtime.Frame{}, false
+	}
+
+	n, err := strconv.Atoi(line)
+	if err != nil {
+		return runtime.Frame{}, false
+	}
+
+	return runtime.Frame{Function: function, File: text[:i], Line: n}, true
+}
+
+// sub returns a parser of another line formatted the same way.
+func (lp *lineParser) sub(s string) *lineParser {
+	return &lineParser{lp.p, lp.level, s}
+}
+
+// anchor reports whether one of the given items may start after the spaces at pos.
+func (lp *lineParser) anchor(pos int, items []themecfg.Item) bool {
+	for pos < len(lp.s) && lp.s[pos] == ' ' {
+		pos++
+	}
+
+	for i, it := range items {
+		switch it {
+		case themecfg.ItemMessage:
+			continue
+		case themecfg.ItemFields:
+			if _, _, ok := lp.key(pos); ok {
+				return true
+			}
+		default:
+			if len(lp.candidates(items[i:], pos)) != 0 {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (lp *lineParser) fieldsCandidates(pos int) []candidate {
+	var (
+		fields []logf.Field
+		ends   []int
+	)
+
+	for {
+		field, end, ok := lp.field(pos, []string{" "})
+		if !ok {
+			break
+		}
+
+		fields = append(fields, field)
+		ends = append(ends, end)
+
+		if end == len(lp.s) || lp.s[end] != ' ' {
+			break
+		}
+
+		pos = end + 1
+	}
+
+	result := make([]candidate, len(fields))
+	for i := range fields {
+		n := len(fields) - i
+		result[i] = candidate{ends[n-1], func(e *logf.Entry) { e.Fields = fields[:n] }}
+	}
+
+	return result
+}
+
+func (lp *lineParser) field(pos int, terminators []string) (logf.Field, int, bool) {
+	name, pos, ok := lp.key(pos)
+	if !ok {
+		return logf.Field{}, 0, false
+	}
+
+	value, pos, ok := lp.value(pos, terminators)
+	if !ok {
+		return logf.Field{}, 0, false
+	}
+
+	return logf.Any(name, value), pos, true
+}
+
+// key reads a field key followed by the field separator.
+// Unquoted key ends at the first space, so that the check is cheap enough to be done at every word.
+func (lp *lineParser) key(pos int) (string, int, bool) {
+	key := &lp.p.layout.Key.Outer
+
+	if !strings.HasPrefix(lp.s[pos:], key.Prefix) {
+		return "", 0, false
+	}
+
+	pos += len(key.Prefix)
+	separator := key.Suffix + lp.level.Field.Separator
+
+	var (
+		name string
+		ok   bool
+	)
+
+	if strings.HasPrefix(lp.s[pos:], `"`) {
+		name, pos, ok = lp.token(pos, []string{separator})
+	} else {
+		// Separator itself may contain spaces, but it starts not later than the first one.
+		end := min(pos+strings.IndexByte(lp.s[pos:]+" ", ' ')+len(separator), len(lp.s))
+		if i := strings.Index(lp.s[pos:end], separator); i > 0 {
+			name, pos, ok = lp.s[pos:pos+i], pos+i, true
+		}
+	}
+
+	if !ok || name == "" || !strings.HasPrefix(lp.s[pos:], separator) {
+		return "", 0, false
+	}
+
+	return name, pos + len(separator), true
+}
+
+func (lp *lineParser) value(pos int, terminators []string) (any, int, bool) {
+	lt := &lp.p.layout
+	rest := lp.s[pos:]
+
+	if strings.HasPrefix(rest, `"`) {
+		text, end, ok := lp.token(pos, terminators)
+
+		return text, end, ok
+	}
+
+	if v, end, ok := lp.timeValue(pos, terminators); ok {
+		return v, end, true
+	}
+
+	if lt.Error.Outer.Prefix != "" && strings.HasPrefix(rest, lt.Error.Outer.Prefix) {
+		if text, end, ok := lp.wrapped(pos, &lt.Error.Outer, true, terminators...); ok {
+			text = strings.TrimPrefix(text, lt.Error.Inner.Prefix)
+			text = strings.TrimSuffix(text, lt.Error.Inner.Suffix)
+
+			return errors.New(text), end, true
+		}
+	}
+
+	if lt.Array.Outer.Prefix != "" && strings.HasPrefix(rest, lt.Array.Outer.Prefix) {
+		if v, end, ok := lp.array(pos + len(lt.Array.Outer.Prefix)); ok && terminated(lp.s[end:], terminators) {
+			return v, end, true
+		}
+	}
+
+	if lt.Object.Outer.Prefix != "" && strings.HasPrefix(rest, lt.Object.Outer.Prefix) {
+		if v, end, ok := lp.object(pos + len(lt.Object.Outer.Prefix)); ok && terminated(lp.s[end:], terminators) {
+			return v, end, true
+		}
+	}
+
+	text, end, ok := lp.token(pos, terminators)
+	if !ok {
+		return nil, 0, false
+	}
+
+	if null, ok := lp.unwrap(text, &lt.Null.Outer); ok && null == "null" {
+		return nil, end, true
+	}
+
+	return parseScalar(text), end, true
+}
+
+func (lp *lineParser) timeValue(pos int, terminators []string) (any, int, bool) {
+	outer := &lp.p.layout.Time.Outer
+	if outer.Prefix == "" || lp.p.timeValueLayout == "" || !strings.HasPrefix(lp.s[pos:], outer.Prefix) {
+		return nil, 0, false
+	}
+
+	begin := pos + len(outer.Prefix)
+	end := skipWords(lp.s, begin, countWords(lp.p.timeValueLayout))
+
+	text, ok := strings.CutSuffix(lp.s[begin:end], outer.Suffix)
+	if !ok || !terminated(lp.s[end:], terminators) {
+		return nil, 0, false
+	}
+
+	ts, err := parseTime(lp.p.timeValueLayout, text)
+	if err != nil {
+		return nil, 0, false
+	}
+
+	return ts, end, true
+}
+
+func (lp *lineParser) array(pos int) (any, int, bool) {
+	array := &lp.p.layout.Array
+	closing := array.Inner.Suffix + array.Outer.Suffix
+
+	if strings.HasPrefix(lp.s[pos:], array.Outer.Suffix) {
+		return valuesArray(nil), pos + len(array.Outer.Suffix), true
+	}
+
+	if !strings.HasPrefix(lp.s[pos:], array.Inner.Prefix) {
+		return nil, 0, false
+	}
+
+	pos += len(array.Inner.Prefix)
+
+	var values valuesArray
+
+	for {
+		value, end, ok := lp.value(pos, []string{array.Separator, closing})
+		if !ok {
+			return nil, 0, false
+		}
+
+		values = append(values, value)
+
+		if strings.HasPrefix(lp.s[end:], closing) {
+			return values, end + len(closing), true
+		}
+
+		if !strings.HasPrefix(lp.s[end:], array.Separator) {
+			return nil, 0, false
+		}
+
+		pos = end + len(array.Separator)
+	}
+}
+
+func (lp *lineParser) object(pos int) (any, int, bool) {
+	object := &lp.p.layout.Object
+	closing := object.Inner.Suffix + object.Outer.Suffix
+
+	if strings.HasPrefix(lp.s[pos:], object.Outer.Suffix) {
+		return fieldsObject(nil), pos + len(object.Outer.Suffix), true
+	}
+
+	if !strings.HasPrefix(lp.s[pos:], object.Inner.Prefix) {
+		return nil, 0, false
+	}
+
+	pos += len(object.Inner.Prefix)
+
+	var fields fieldsObject
+
+	for {
+		field, end, ok := lp.field(pos, []string{object.Separator, closing})
+		if !ok {
+			return nil, 0, false
+		}
+
+		fields = append(fields, field)
+
+		if strings.HasPrefix(lp.s[end:], closing) {
+			return fields, end + len(closing), true
+		}
+
+		if !strings.HasPrefix(lp.s[end:], object.Separator) {
+			return nil, 0, false
+		}
+
+		pos = end + len(object.Separator)
+	}
+}
+
+// token reads either a quoted string or a bare text until one of the terminators or end of line.
+func (lp *lineParser) token(pos int, terminators []string) (string, int, bool) {
+	rest := lp.s[pos:]
+
+	if strings.HasPrefix(rest, `"`) {
+		quoted, err := strconv.QuotedPrefix(rest)
+		if err != nil || !terminated(rest[len(quoted):], terminators) {
+			return "", 0, false
+		}
+
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			return "", 0, false
+		}
+
+		return value, pos + len(quoted), true
+	}
+
+	for i := 0; i != len(rest); i++ {
+		if terminated(rest[i:], terminators) {
+			return rest[:i], pos + i, i != 0
+		}
+	}
+
+	return rest, len(lp.s), rest != ""
+}
+
+// match checks that the concatenation of the given parts follows at pos and
+// is followed by a space or end of line.
+func (lp *lineParser) match(pos int, parts ...string) (int, bool) {
+	for _, part := range parts {
+		if !strings.HasPrefix(lp.s[pos:], part) {
+			return 0, false
+		}
+
+		pos += len(part)
+	}
+
+	return pos, pos == len(lp.s) || lp.s[pos] == ' '
+}
+
+// wrapped reads a text surrounded by the prefix and suffix of the given format.
+// If spaces is false, the text must not contain any spaces.
+func (lp *lineParser) wrapped(pos int, f *layout.Format, spaces bool, terminators ...string) (string, int, bool) {
+	if !strings.HasPrefix(lp.s[pos:], f.Prefix) {
+		return "", 0, false
+	}
+
+	begin := pos + len(f.Prefix)
+	terminators = append(terminators, " ")
+
+	for end := begin; end <= len(lp.s); end++ {
+		if !spaces && end != len(lp.s) && lp.s[end] == ' ' && !strings.HasPrefix(lp.s[end:], f.Suffix) {
+			return "", 0, false
+		}
+
+		if !strings.HasPrefix(lp.s[end:], f.Suffix) {
+			continue
+		}
+
+		next := end + len(f.Suffix)
+		if next == len(lp.s) || terminated(lp.s[next:], terminators) {
+			return lp.s[begin:end], next, true
+		}
+	}
+
+	return "", 0, false
+}
+
+func (lp *lineParser) unwrap(text string, f *layout.Format) (string, bool) {
+	text, ok := strings.CutPrefix(text, f.Prefix)
+	if !ok {
+		return "", false
+	}
+
+	return strings.CutSuffix(text, f.Suffix)
+}
+
+// ---
+
+type valuesArray []any
+
+func (a valuesArray) EncodeLogfArray(enc logf.TypeEncoder) error {
+	for _, v := range a {
+		enc.EncodeTypeAny(v)
+	}
+
+	return nil
+}
+
+// ---
+
+type fieldsObject []logf.Field
+
+func (o fieldsObject) EncodeLogfObject(enc logf.FieldEncoder) error {
+	for _, field := range o {
+		field.Accept(enc)
+	}
+
+	return nil
+}
+
+// ---
+
+// tracedError is an error restored together with its stack trace frames.
+type tracedError struct {
+	message string
+	frames  []runtime.Frame
+}
+
+func (e tracedError) Error() string {
+	return e.message
+}
+
+// StackTrace returns the stack trace frames of the error.
+func (e tracedError) StackTrace() []runtime.Frame {
+	return e.frames
+}
+
+// ---
+
+// fieldText returns text of the error or string field value.
+func fieldText(field *logf.Field) (string, bool) {
+	switch field.Type { //nolint:exhaustive // only errors and strings may have stack traces
+	case logf.FieldTypeError:
+		if err, ok := field.Any.(error); ok {
+			return err.Error(), true
+		}
+	case logf.FieldTypeBytesToString:
+		return string(field.Bytes), true
+	}
+
+	return "", false
+}
+
+func parseScalar(text string) any {
+	switch text {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	if strings.Trim(text, "-.0123456789") != "" || strings.Count(text, ".") > 1 {
+		return text
+	}
+
+	if v, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return v
+	}
+
+	if v, err := strconv.ParseFloat(text, 64); err == nil {
+		return v
+	}
+
+	return text
+}
+
+// parseTime parses time using the given layout.
+// If the layout does not contain year, current year is assumed.
+func parseTime(layout, text string) (time.Time, error) {
+	ts, err := time.Parse(layout, text)
+	if err != nil {
+		return ts, fmt.Errorf("failed to parse time: %w", err)
+	}
+
+	if ts.Year() == 0 {
+		ts = ts.AddDate(time.Now().Year(), 0, 0)
+	}
+
+	return ts, nil
+}
+
+func terminated(s string, terminators []string) bool {
+	if s == "" {
+		return true
+	}
+
+	for _, t := range terminators {
+		if t != "" && strings.HasPrefix(s, t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// levelIndex returns index of the given level in per-level formatting tables.
+func levelIndex(level logf.Level) logf.Level {
+	return min(max(level, logf.LevelError), logf.LevelDebug)
+}
+
+// countWords returns the number of space separated words produced by the given time layout.
+func countWords(layout string) int {
+	return len(strings.Fields(time.Date(2006, 12, 2, 15, 4, 5, 0, time.UTC).Format(layout)))
+}
+
+// skipWords returns position right after the n-th space separated word starting from pos.
+func skipWords(s string, pos, n int) int {
+	inWord := false
+
+	for i := pos; i != len(s); i++ {
+		if s[i] == ' ' {
+			if inWord {
+				n--
+				if n == 0 {
+					return i
+				}
+			}
+
+			inWord = false
+		} else {
+			inWord = true
+		}
+	}
+
+	return len(s)
+}
+
+// ---
+
+// escapeSequence matches SGR and OSC 8 hyperlink escape sequences.
+var escapeSequence = regexp.MustCompile("\x1b\\[[0-9;:]*m|\x1b]8;[^\x1b\x07]*(?:\x1b\\\\|\x07)")
+
+const maxParsedLineSize = 1024 * 1024
+
+// ---
+
+var (
+	_ error              = UnrecognizedLineError{}
+	_ error              = tracedError{}
+	_ logf.ArrayEncoder  = valuesArray(nil)
+	_ logf.ObjectEncoder = fieldsObject(nil)
+)
@@ -0,0 +1,356 @@
+package parse_test
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ssgreg/logf"
+
+	"github.com/pamburus/go-tst/tst"
+	"github.com/pamburus/logftxt"
+	"github.com/pamburus/logftxt/internal/pkg/pathx"
+	"github.com/pamburus/logftxt/parse"
+)
+
+func TestParser(tt *testing.T) {
+	t := tst.New(tt)
+
+	config, err := logftxt.LoadConfig("../encoder_test.config.yml")
+	t.Expect(err).ToNot(tst.HaveOccurred())
+
+	testTheme, err := logftxt.NewThemeRef(pathx.ExplicitlyRelative("../encoder_test.theme.yml")).Load()
+	t.Expect(err).ToNot(tst.HaveOccurred())
+
+	someTime := time.Date(2020, 1, 2, 3, 4, 5, 6000000, time.UTC)
+
+	entries := []logf.Entry{
+		{
+			Level: logf.LevelInfo,
+			Time:  someTime,
+			Text:  "msg",
+		},
+		{
+			LoggerName: "main.sub",
+			Level:      logf.LevelWarn,
+			Time:       someTime,
+			Text:       "The quick brown fox jumps over a lazy dog",
+			Fields: []logf.Field{
+				logf.String("s", "a b=c"),
+				logf.String("e", ""),
+				logf.String("n", "42"),
+				logf.String("q", "-\x00-\n-\"-"),
+				logf.Int("i", -42),
+				logf.Float64("f", 4.5),
+				logf.Bool("b", true),
+				logf.Any("z", nil),
+				logf.Time("t", someTime),
+				logf.Error(errors.New("oops: it's broken")),
+				logf.Ints("a", []int{1, 2, 3}),
+				logf.Strings("a0", []string{}),
+				logf.Array("aa", newMockArray(logf.TypeEncoder.EncodeTypeArray, newMockArray(logf.TypeEncoder.EncodeTypeString, "x", "y z"))),
+			},
+			Caller: logf.EntryCaller{File: "pkg/test.go", Line: 42, Specified: true},
+		},
+		{
+			Level: logf.LevelDebug,
+			Time:  someTime,
+			Fields: []logf.Field{
+				logf.String("a", "b"),
+				logf.Object("o", newMockObject(logf.Int("x", 1), logf.Object("y", newMockObject(logf.String("z", "w"))))),
+				logf.Object("o0", newMockObject()),
+			},
+		},
+		{
+			Level: logf.LevelError,
+			Time:  someTime,
+			Text:  "failed to check a = b",
+		},
+	}
+
+	themes := []*logftxt.Theme{testTheme}
+
+	for _, name := range []string{"default", "fancy"} {
+		theme, err := logftxt.LoadBuiltInTheme(name)
+		t.Expect(err).ToNot(tst.HaveOccurred())
+
+		themes = append(themes, theme)
+	}
+
+	for _, theme := range themes {
+		for _, color := range []logftxt.ColorSetting{logftxt.ColorNever, logftxt.ColorAlways} {
+			for flatten := range 2 {
+				enc := logftxt.NewEncoder(config, theme, color, logftxt.FlattenObjects(flatten == 1))
+				parser := parse.New(theme, config)
+
+				buf := logf.NewBuffer()
+				for _, entry := range entries {
+					t.Expect(enc.Encode(buf, entry)).ToSucceed()
+				}
+
+				parsed, err := parser.ParseAll(strings.NewReader(buf.String()))
+				t.Expect(err).ToNot(tst.HaveOccurred())
+				t.Expect(len(parsed)).ToEqual(len(entries))
+
+				// Flattened object keys are parsed back as plain dotted keys,
+				// so only the text without styles can be compared in this case.
+				if flatten == 1 {
+					enc = logftxt.NewEncoder(config, theme, logftxt.ColorNever, logftxt.FlattenObjects(true))
+				}
+
+				expected := logf.NewBuffer()
+				actual := logf.NewBuffer()
+				for i := range entries {
+					t.Expect(enc.Encode(expected, entries[i])).ToSucceed()
+					t.Expect(enc.Encode(actual, parsed[i])).ToSucceed()
+				}
+
+				t.Expect(actual.String()).ToEqual(expected.String())
+			}
+		}
+	}
+
+	t.Run("Values", func(t tst.Test) {
+		parser := parse.New(testTheme, config)
+		entry, err := parser.Parse("Jan  2 03:04:05.006 |INF| me: hello world i=42 f=4.5 b=false s=\"x y\" n=null t=[[Jan  2 03:04:05.000]] @ a/b.go:7")
+		t.Expect(err).ToNot(tst.HaveOccurred())
+		t.Expect(entry.LoggerName).ToEqual("me")
+		t.Expect(entry.Text).ToEqual("hello world")
+		t.Expect(entry.Level).ToEqual(logf.LevelInfo)
+		t.Expect(entry.Caller.File).ToEqual("a/b.go")
+		t.Expect(entry.Caller.Line).ToEqual(7)
+		t.Expect(len(entry.Fields)).ToEqual(6)
+		t.Expect(entry.Fields[0].Type).ToEqual(logf.FieldTypeInt64)
+		t.Expect(entry.Fields[1].Type).ToEqual(logf.FieldTypeFloat64)
+		t.Expect(entry.Fields[2].Type).ToEqual(logf.FieldTypeBool)
+		t.Expect(entry.Fields[3].Type).ToEqual(logf.FieldTypeBytesToString)
+		t.Expect(entry.Fields[4].Type).ToEqual(logf.FieldTypeAny)
+		t.Expect(entry.Fields[5].Type).ToEqual(logf.FieldTypeTime)
+	})
+
+	t.Run("Hyperlink", func(t tst.Test) {
+		enc := logftxt.NewEncoder(config, testTheme, logftxt.ColorAlways, logftxt.CallerHyperlink(""))
+		buf := logf.NewBuffer()
+		t.Expect(enc.Encode(buf, entries[1])).ToSucceed()
+
+		entry, err := parse.New(testTheme, config).Parse(buf.String())
+		t.Expect(err).ToNot(tst.HaveOccurred())
+		t.Expect(entry.Caller).ToEqual(entries[1].Caller)
+	})
+
+	t.Run("Aligned", func(t tst.Test) {
+		theme, err := logftxt.ReadTheme(strings.NewReader(
+			"theme: {version: '1.0', items: [level, message, fields], settings: {alignment: {message-width: 20, key-memory: 4}}, " +
+				"formatting: {level: {info: {text: INF}}, field: {separator: {text: '='}}}}",
+		))
+		t.Expect(err).ToNot(tst.HaveOccurred())
+
+		input := []logf.Entry{
+			{Level: logf.LevelInfo, Text: "hello  world", Fields: []logf.Field{logf.Int("a", 1)}},
+			{Level: logf.LevelInfo, Text: "hi", Fields: []logf.Field{logf.Int("a", 2)}},
+		}
+
+		enc := logftxt.NewEncoder(config, theme, logftxt.ColorNever)
+		buf := logf.NewBuffer()
+		for _, entry := range input {
+			t.Expect(enc.Encode(buf, entry)).ToSucceed()
+		}
+
+		parsed, err := parse.New(theme, config).ParseAll(strings.NewReader(buf.String()))
+		t.Expect(err).ToNot(tst.HaveOccurred())
+		t.Expect(len(parsed)).ToEqual(2)
+		t.Expect(parsed[0].Text).ToEqual("hello  world")
+		t.Expect(parsed[1].Text).ToEqual("hi")
+		t.Expect(parsed[1].Fields).ToEqual([]logf.Field{logf.Int("a", 2)})
+	})
+
+	t.Run("CustomLevels", func(t tst.Test) {
+		theme, err := logftxt.ReadTheme(strings.NewReader(strings.Join([]string{
+			"theme:",
+			"  version: '1.0'",
+			"  items: [level, message]",
+			"  formatting:",
+			"    level:",
+			"      all: {outer: {prefix: '[', suffix: ']'}}",
+			"      error: {text: ERR}",
+			"      trace: {text: TRC}",
+			"      -1: {text: FTL}",
+		}, "\n")))
+		t.Expect(err).ToNot(tst.HaveOccurred())
+
+		parser := parse.New(theme, &logftxt.Config{})
+
+		entry, err := parser.Parse("[FTL] msg")
+		t.Expect(err).ToNot(tst.HaveOccurred())
+		t.Expect(entry.Level).ToEqual(logftxt.LevelFatal)
+
+		entry, err = parser.Parse("[TRC] msg")
+		t.Expect(err).ToNot(tst.HaveOccurred())
+		t.Expect(entry.Level).ToEqual(logftxt.LevelTrace)
+	})
+
+	t.Run("PerLevelFormatting", func(t tst.Test) {
+		theme, err := logftxt.ReadTheme(strings.NewReader(strings.Join([]string{
+			"theme:",
+			"  version: '1.0'",
+			"  items: [level, logger, message, fields]",
+			"  formatting:",
+			"    line:",
+			"      levels:",
+			"        error: {outer: {prefix: '!! ', suffix: ' !!'}}",
+			"    level:",
+			"      all: {outer: {prefix: '|', suffix: '|'}}",
+			"      debug: {text: DBG}",
+			"      info: {text: INF}",
+			"      warning: {text: WRN}",
+			"      error: {text: ERR}",
+			"    logger:",
+			"      outer: {suffix: ':'}",
+			"      levels:",
+			"        warning: {outer: {prefix: '<', suffix: '>'}}",
+			"    message:",
+			"      levels:",
+			"        debug: {outer: {prefix: '\"', suffix: '\"'}}",
+			"    field:",
+			"      separator: {text: '='}",
+			"      levels:",
+			"        error: {separator: {text: ': '}}",
+		}, "\n")))
+		t.Expect(err).ToNot(tst.HaveOccurred())
+
+		input := []logf.Entry{
+			{Level: logf.LevelDebug, LoggerName: "a", Text: "hello world", Fields: []logf.Field{logf.Int("x", 1)}},
+			{Level: logf.LevelInfo, LoggerName: "b", Text: "hi", Fields: []logf.Field{logf.String("s", "v w")}},
+			{Level: logf.LevelWarn, LoggerName: "c", Text: "careful", Fields: []logf.Field{logf.Bool("b", true)}},
+			{Level: logf.LevelError, LoggerName: "d", Text: "failed", Fields: []logf.Field{logf.Int("n", 2), logf.String("s", "x")}},
+		}
+
+		for _, color := range []logftxt.ColorSetting{logftxt.ColorNever, logftxt.ColorAlways} {
+			enc := logftxt.NewEncoder(config, theme, color)
+
+			buf := logf.NewBuffer()
+			for _, entry := range input {
+				t.Expect(enc.Encode(buf, entry)).ToSucceed()
+			}
+
+			parsed, err := parse.New(theme, config).ParseAll(strings.NewReader(buf.String()))
+			t.Expect(err).ToNot(tst.HaveOccurred())
+			t.Expect(parsed).ToEqual(input)
+		}
+
+		_, err = parse.New(theme, config).Parse("|ERR| d: failed n: 2")
+		t.Expect(err).ToFail()
+	})
+
+	t.Run("MultipleLines", func(t tst.Test) {
+		input := []logf.Entry{
+			{
+				Level: logf.LevelError,
+				Time:  someTime,
+				Text:  "request failed\nsee details",
+				Fields: []logf.Field{
+					logf.Int("attempt", 3),
+					logf.String("query", "SELECT *\n  FROM t"),
+					logf.String("host", "example.com"),
+					logf.Error(tracedError{"connection refused", []runtime.Frame{
+						{Function: "main.connect", File: "/src/app/main.go", Line: 42},
+						{Function: "main.main", File: "/src/app/main.go", Line: 7},
+					}}),
+					logf.String("user", "alice"),
+				},
+			},
+			{
+				Level: logf.LevelInfo,
+				Time:  someTime,
+				Text:  "done",
+			},
+		}
+
+		for _, theme := range themes {
+			for _, color := range []logftxt.ColorSetting{logftxt.ColorNever, logftxt.ColorAlways} {
+				enc := logftxt.NewEncoder(config, theme, color,
+					logftxt.MultilineLayoutBlock, logftxt.ErrorFormatTrace, logftxt.LineWidth(60), logftxt.LineOverflowWrap,
+				)
+
+				expected := logf.NewBuffer()
+				for _, entry := range input {
+					t.Expect(enc.Encode(expected, entry)).ToSucceed()
+				}
+
+				t.Expect(strings.Count(expected.String(), "\n") > 8).ToBeTrue()
+
+				parsed, err := parse.New(theme, config).ParseAll(strings.NewReader(expected.String()))
+				t.Expect(err).ToNot(tst.HaveOccurred())
+				t.Expect(len(parsed)).ToEqual(len(input))
+
+				actual := logf.NewBuffer()
+				for _, entry := range parsed {
+					t.Expect(enc.Encode(actual, entry)).ToSucceed()
+				}
+
+				t.Expect(actual.String()).ToEqual(expected.String())
+				t.Expect(parsed[0].Text).ToEqual(input[0].Text)
+			}
+		}
+	})
+
+	t.Run("Unrecognized", func(t tst.Test) {
+		parser := parse.New(nil, nil)
+		t.Expect(parser.Parse("garbage")).ToFailWith(parse.UnrecognizedLineError{Line: "garbage"})
+		t.Expect(parser.ParseAll(strings.NewReader("garbage\n"))).ToFail()
+	})
+}
+
+// ---
+
+func newMockArray[T any](f func(logf.TypeEncoder, T), values ...T) logf.ArrayEncoder {
+	return mockArray[T]{f, values}
+}
+
+type mockArray[T any] struct {
+	f      func(logf.TypeEncoder, T)
+	values []T
+}
+
+func (a mockArray[T]) EncodeLogfArray(enc logf.TypeEncoder) error {
+	for _, value := range a.values {
+		a.f(enc, value)
+	}
+
+	return nil
+}
+
+// ---
+
+func newMockObject(fields ...logf.Field) logf.ObjectEncoder {
+	return mockObject{fields}
+}
+
+type mockObject struct {
+	fields []logf.Field
+}
+
+func (a mockObject) EncodeLogfObject(enc logf.FieldEncoder) error {
+	for _, field := range a.fields {
+		field.Accept(enc)
+	}
+
+	return nil
+}
+
+// ---
+
+type tracedError struct {
+	message string
+	frames  []runtime.Frame
+}
+
+func (e tracedError) Error() string {
+	return e.message
+}
+
+func (e tracedError) StackTrace() []runtime.Frame {
+	return e.frames
+}
@@ -0,0 +1,144 @@
+package logftxt
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// RedactFields tells encoder to replace values of fields matching any of the given patterns with a placeholder.
+//
+// A pattern is a dot-separated key path, for example `http.headers.authorization`,
+// and each of its components may contain glob wildcards supported by [path.Match].
+// A pattern matches a field if it matches trailing components of the field's key path,
+// so a pattern without dots matches a key at any nesting level.
+// Patterns specified by several RedactFields options and by the configuration are combined.
+func RedactFields(patterns ...string) RedactFieldsSetting {
+	return RedactFieldsSetting(patterns)
+}
+
+// RedactFieldsSetting is a list of patterns of keys of fields that should be redacted.
+type RedactFieldsSetting []string
+
+func (s RedactFieldsSetting) toEncoderOptions(o *encoderOptions) {
+	o.redactFields = append(o.redactFields, s...)
+}
+
+func (s RedactFieldsSetting) toAppenderOptions(o *appenderOptions) {
+	o.redactFields = append(o.redactFields, s...)
+}
+
+// ---
+
+func validateRedactPattern(pattern string) error {
+	if pattern == "" {
+		return errors.New("empty pattern")
+	}
+
+	for _, segment := range strings.Split(pattern, ".") {
+		if segment == "" {
+			return fmt.Errorf("empty key in pattern %q", pattern)
+		}
+
+		_, err := path.Match(segment, "")
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+func newRedactor(patterns []string) (redactor, []error) {
+	var (
+		result redactor
+		errs   []error
+	)
+
+	for _, pattern := range patterns {
+		err := validateRedactPattern(pattern)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		var p redactPattern
+
+		for _, segment := range strings.Split(pattern, ".") {
+			p = append(p, redactSegment{segment, strings.ContainsAny(segment, `*?[\`)})
+		}
+
+		result = append(result, p)
+	}
+
+	return result, errs
+}
+
+// ---
+
+// redactor is a compiled list of patterns of keys of fields that should be redacted.
+type redactor []redactPattern
+
+func (r redactor) matches(keys []string, key string) bool {
+	for _, p := range r {
+		if p.matches(keys, key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ---
+
+type redactPattern []redactSegment
+
+// matches reports whether the pattern matches trailing components of the key path formed by keys followed by key.
+// Keys containing dots are treated as several components.
+func (p redactPattern) matches(keys []string, key string) bool {
+	i, ok := p.matchKey(len(p)-1, key)
+
+	for k := len(keys) - 1; ok && i >= 0 && k >= 0; k-- {
+		i, ok = p.matchKey(i, keys[k])
+	}
+
+	return ok && i < 0
+}
+
+// matchKey matches components of the key against the pattern segments ending at index i
+// and returns index of the last segment left unmatched.
+func (p redactPattern) matchKey(i int, key string) (int, bool) {
+	for ; i >= 0; i-- {
+		j := strings.LastIndexByte(key, '.')
+		if !p[i].matches(key[j+1:]) {
+			return i, false
+		}
+
+		if j < 0 {
+			return i - 1, true
+		}
+
+		key = key[:j]
+	}
+
+	return i, true
+}
+
+// ---
+
+type redactSegment struct {
+	pattern string
+	glob    bool
+}
+
+func (s redactSegment) matches(key string) bool {
+	if s.glob {
+		ok, _ := path.Match(s.pattern, key)
+
+		return ok
+	}
+
+	return s.pattern == key
+}
@@ -0,0 +1,419 @@
+package logftxt
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ssgreg/logf"
+)
+
+// ---
+
+// encodeReflected encodes a value of a type that has no dedicated encoding using reflection.
+// Structs and maps are encoded as objects, slices and arrays are encoded as arrays and pointers are followed.
+// Nesting depth and the number of times a value can be revisited through references are limited
+// by `values.reflection` configuration settings.
+func (e *entryEncoder) encodeReflected(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			e.EncodeTypeAny(nil)
+
+			return
+		}
+
+		ref := reflectedRef{v.Pointer(), v.Type()}
+		if v.Elem().Kind() == reflect.Struct && hasReflectedFields(v.Elem().Type()) {
+			e.encodeReflectedObject(reflected{e, v.Elem(), ref})
+
+			return
+		}
+
+		if e.appendReflectedPlaceholder(ref, false) {
+			return
+		}
+
+		e.enterReflected(ref, false)
+		e.EncodeTypeAny(v.Elem().Interface())
+		e.leaveReflected(ref, false)
+	case reflect.Struct:
+		if !hasReflectedFields(v.Type()) {
+			_, _ = fmt.Fprintf(e.buf, "%v", v.Interface())
+
+			return
+		}
+
+		e.encodeReflectedObject(reflected{e, v, reflectedRef{}})
+	case reflect.Map:
+		if v.IsNil() {
+			e.EncodeTypeAny(nil)
+
+			return
+		}
+
+		e.encodeReflectedObject(reflected{e, v, reflectedRef{v.Pointer(), v.Type()}})
+	case reflect.Slice, reflect.Array:
+		r := reflected{e, v, reflectedRef{}}
+		if v.Kind() == reflect.Slice && v.Len() != 0 {
+			r.ref = reflectedRef{v.Pointer(), v.Type()}
+		}
+
+		if !e.appendReflectedPlaceholder(r.ref, true) {
+			e.EncodeTypeArray(r)
+		}
+	default:
+		_, _ = fmt.Fprintf(e.buf, "%v", v.Interface())
+	}
+}
+
+func (e *entryEncoder) encodeReflectedObject(r reflected) {
+	if !e.appendReflectedPlaceholder(r.ref, true) {
+		e.EncodeTypeObject(r)
+	}
+}
+
+// reflectedObject returns v as an object encoded using reflection if v is a struct or a map
+// or a pointer to them that has no dedicated encoding and does not exceed the limits.
+// It allows such values to be flattened the same way as logf.Object fields are.
+func (e *entryEncoder) reflectedObject(v any) (logf.ObjectEncoder, bool) {
+	switch v.(type) {
+	case nil, logf.ObjectEncoder, logf.ArrayEncoder, fmt.Stringer, error:
+		return nil, false
+	}
+
+	r := reflected{e, reflect.ValueOf(v), reflectedRef{}}
+
+	switch r.v.Kind() {
+	case reflect.Pointer:
+		if r.v.IsNil() || r.v.Elem().Kind() != reflect.Struct {
+			return nil, false
+		}
+
+		r.ref = reflectedRef{r.v.Pointer(), r.v.Type()}
+		r.v = r.v.Elem()
+
+		fallthrough
+	case reflect.Struct:
+		if !hasReflectedFields(r.v.Type()) {
+			return nil, false
+		}
+	case reflect.Map:
+		if r.v.IsNil() {
+			return nil, false
+		}
+
+		r.ref = reflectedRef{r.v.Pointer(), r.v.Type()}
+	default:
+		return nil, false
+	}
+
+	if e.reflectedPlaceholder(r.ref, true) != "" {
+		return nil, false
+	}
+
+	return r, true
+}
+
+// appendReflectedPlaceholder appends a placeholder instead of a value reached by ref if it exceeds the limits.
+func (e *entryEncoder) appendReflectedPlaceholder(ref reflectedRef, nested bool) bool {
+	placeholder := e.reflectedPlaceholder(ref, nested)
+	if placeholder == "" {
+		return false
+	}
+
+	e.theme.fmt.Null.encode(e, func() {
+		e.buf.AppendString(placeholder)
+	})
+
+	return true
+}
+
+// reflectedPlaceholder returns a placeholder for a value reached by ref if it exceeds the limits or an empty string otherwise.
+func (e *entryEncoder) reflectedPlaceholder(ref reflectedRef, nested bool) string {
+	switch {
+	case nested && e.reflectDepth >= e.reflectMaxDepth:
+		return reflectedDepthPlaceholder
+	case e.reflectedCycleLimitExceeded(ref):
+		return reflectedCyclePlaceholder
+	default:
+		return ""
+	}
+}
+
+// reflectedCycleLimitExceeded reports whether the value referenced by ref is already being encoded
+// more times than allowed by the cycle limit, i.e. it contains itself.
+func (e *entryEncoder) reflectedCycleLimitExceeded(ref reflectedRef) bool {
+	if ref.ptr == 0 {
+		return false
+	}
+
+	n := 0
+
+	for _, r := range e.reflectPath {
+		if r == ref {
+			n++
+		}
+	}
+
+	return n > e.reflectMaxCycles
+}
+
+func (e *entryEncoder) enterReflected(ref reflectedRef, nested bool) {
+	if nested {
+		e.reflectDepth++
+	}
+
+	if ref.ptr != 0 {
+		e.reflectPath = append(e.reflectPath, ref)
+	}
+}
+
+func (e *entryEncoder) leaveReflected(ref reflectedRef, nested bool) {
+	if nested {
+		e.reflectDepth--
+	}
+
+	if ref.ptr != 0 {
+		e.reflectPath = e.reflectPath[:len(e.reflectPath)-1]
+	}
+}
+
+// isNilPointer reports whether v is a typed nil pointer, methods of such values often dereference the receiver and panic.
+func isNilPointer(v any) bool {
+	rv := reflect.ValueOf(v)
+
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
+
+// ---
+
+// reflectedRef identifies a value that can be reached by reference and thus can contain itself.
+// Type is needed to distinguish a slice from a pointer to its first element.
+type reflectedRef struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// ---
+
+// reflected is a struct, a map, a slice or an array encoded using reflection.
+type reflected struct {
+	e   *entryEncoder
+	v   reflect.Value
+	ref reflectedRef
+}
+
+// EncodeLogfObject encodes exported fields of a struct in order of declaration or entries of a map in sorted key order.
+func (r reflected) EncodeLogfObject(enc logf.FieldEncoder) error {
+	r.e.enterReflected(r.ref, true)
+	defer r.e.leaveReflected(r.ref, true)
+
+	if r.v.Kind() == reflect.Map {
+		keys := r.v.MapKeys()
+		slices.SortFunc(keys, compareReflectedKeys)
+
+		for _, key := range keys {
+			enc.EncodeFieldAny(formatReflectedKey(key), r.v.MapIndex(key).Interface())
+		}
+
+		return nil
+	}
+
+	for _, field := range reflectedFields(r.v.Type()) {
+		fv, err := r.v.FieldByIndexErr(field.index)
+		if err != nil || !fv.CanInterface() || (field.omitEmpty && isEmptyReflectedValue(fv)) {
+			continue
+		}
+
+		enc.EncodeFieldAny(field.name, fv.Interface())
+	}
+
+	return nil
+}
+
+// EncodeLogfArray encodes elements of a slice or an array.
+func (r reflected) EncodeLogfArray(enc logf.TypeEncoder) error {
+	r.e.enterReflected(r.ref, true)
+	defer r.e.leaveReflected(r.ref, true)
+
+	for i := range r.v.Len() {
+		enc.EncodeTypeAny(r.v.Index(i).Interface())
+	}
+
+	return nil
+}
+
+// ---
+
+// reflectedField is an exported struct field to be encoded.
+type reflectedField struct {
+	index     []int
+	name      string
+	omitEmpty bool
+}
+
+// reflectedFields returns fields of the struct type t, the result is cached per type.
+func reflectedFields(t reflect.Type) []reflectedField {
+	if fields, ok := reflectedFieldCache.Load(t); ok {
+		return fields.([]reflectedField) //nolint:forcetypeassert // cache contains only field lists
+	}
+
+	fields, _ := reflectedFieldCache.LoadOrStore(t, collectReflectedFields(t, nil, nil))
+
+	return fields.([]reflectedField) //nolint:forcetypeassert // cache contains only field lists
+}
+
+func hasReflectedFields(t reflect.Type) bool {
+	return len(reflectedFields(t)) != 0
+}
+
+// collectReflectedFields collects exported fields of the struct type t.
+// Field names are taken from `log` tag, then from `json` tag and finally from the field name.
+// Fields of embedded structs without a name in the tag are promoted the same way as encoding/json does it.
+func collectReflectedFields(t reflect.Type, index []int, visited []reflect.Type) []reflectedField {
+	if slices.Contains(visited, t) {
+		return nil
+	}
+
+	visited = append(visited, t)
+
+	var fields []reflectedField
+
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		name, omitEmpty, skip := parseReflectedTag(sf.Tag)
+
+		if skip {
+			continue
+		}
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+
+		if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			fields = append(fields, collectReflectedFields(ft, slices.Concat(index, []int{i}), visited)...)
+
+			continue
+		}
+
+		if !sf.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+
+		fields = append(fields, reflectedField{slices.Concat(index, []int{i}), name, omitEmpty})
+	}
+
+	return fields
+}
+
+// parseReflectedTag returns field name and options specified by `log` or `json` tag.
+func parseReflectedTag(tag reflect.StructTag) (name string, omitEmpty, skip bool) {
+	value, ok := tag.Lookup("log")
+	if !ok {
+		value = tag.Get("json")
+	}
+
+	if value == "-" {
+		return "", false, true
+	}
+
+	name, options, _ := strings.Cut(value, ",")
+
+	for _, option := range strings.Split(options, ",") {
+		if option == "omitempty" {
+			omitEmpty = true
+		}
+	}
+
+	return name, omitEmpty, false
+}
+
+// isEmptyReflectedValue reports whether v is empty in the same sense as `omitempty` option of encoding/json means it.
+func isEmptyReflectedValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	default:
+		return false
+	}
+}
+
+// ---
+
+// compareReflectedKeys orders map keys by their values if they are ordered and by their text representation otherwise.
+func compareReflectedKeys(a, b reflect.Value) int {
+	if a.Kind() == reflect.Interface {
+		a = a.Elem()
+	}
+
+	if b.Kind() == reflect.Interface {
+		b = b.Elem()
+	}
+
+	if a.IsValid() && b.IsValid() && a.Kind() == b.Kind() {
+		switch a.Kind() {
+		case reflect.String:
+			return cmp.Compare(a.String(), b.String())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return cmp.Compare(a.Int(), b.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return cmp.Compare(a.Uint(), b.Uint())
+		case reflect.Float32, reflect.Float64:
+			return cmp.Compare(a.Float(), b.Float())
+		}
+	}
+
+	return cmp.Compare(formatReflectedKey(a), formatReflectedKey(b))
+}
+
+func formatReflectedKey(v reflect.Value) string {
+	if v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Invalid:
+		return "null"
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10)
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// ---
+
+const (
+	reflectedDepthPlaceholder = "..."
+	reflectedCyclePlaceholder = "<cycle>"
+)
+
+var reflectedFieldCache sync.Map
+
+// ---
+
+var (
+	_ logf.ObjectEncoder = reflected{}
+	_ logf.ArrayEncoder  = reflected{}
+)
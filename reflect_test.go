@@ -0,0 +1,169 @@
+package logftxt_test
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/ssgreg/logf"
+
+	"github.com/pamburus/go-tst/tst"
+	"github.com/pamburus/logftxt"
+)
+
+func TestReflection(tt *testing.T) {
+	t := tst.New(tt)
+
+	theme, err := logftxt.ReadTheme(strings.NewReader(strings.Join([]string{
+		"theme:",
+		"  version: '1.0'",
+		"  items: [message, fields]",
+		"  formatting:",
+		"    field: {separator: {text: '='}}",
+		"    types:",
+		"      array: {outer: {prefix: '[', suffix: ']'}, separator: {text: ','}}",
+		"      object: {outer: {prefix: '{', suffix: '}'}, separator: {text: ' '}}",
+	}, "\n")))
+	t.Expect(err).ToNot(tst.HaveOccurred())
+
+	env := logftxt.Environment(func(string) (string, bool) { return "", false })
+
+	encode := func(t tst.Test, cfg logftxt.Config, fields ...logf.Field) string {
+		enc := logftxt.NewEncoder(cfg, env, logftxt.ColorNever, theme, logftxt.FlattenObjects(false))
+		buf := logf.NewBuffer()
+		t.Expect(enc.Encode(buf, logf.Entry{Text: "msg", Fields: fields})).ToSucceed()
+
+		return buf.String()
+	}
+
+	t.Run("Struct", func(t tst.Test) {
+		value := reflectedUser{
+			Name:    "alice",
+			Email:   "",
+			Roles:   []string{"admin", "dev"},
+			Address: &reflectedAddress{City: "Paris"},
+			secret:  "hidden",
+			Skipped: 1,
+		}
+		t.Expect(encode(t, logftxt.Config{}, logf.Any("user", value))).ToEqual(
+			"msg user={name=alice roles=[admin,dev] address={City=Paris Zip=0} age=0}\n",
+		)
+		t.Expect(encode(t, logftxt.Config{}, logf.Any("user", &value))).ToEqual(
+			"msg user={name=alice roles=[admin,dev] address={City=Paris Zip=0} age=0}\n",
+		)
+	})
+
+	t.Run("Embedded", func(t tst.Test) {
+		value := reflectedEmployee{reflectedAddress{City: "Oslo", Zip: 1}, "ops"}
+		t.Expect(encode(t, logftxt.Config{}, logf.Any("e", value))).ToEqual("msg e={City=Oslo Zip=1 team=ops}\n")
+	})
+
+	t.Run("Map", func(t tst.Test) {
+		t.Expect(encode(t, logftxt.Config{},
+			logf.Any("s", map[string]int{"b": 2, "a": 1, "c": 3}),
+			logf.Any("i", map[int]string{10: "x", 9: "y"}),
+			logf.Any("nil", map[string]int(nil)),
+		)).ToEqual(`msg s={a=1 b=2 c=3} i={"9"=y "10"=x} nil=null` + "\n")
+	})
+
+	t.Run("Pointer", func(t tst.Test) {
+		n := 42
+		t.Expect(encode(t, logftxt.Config{},
+			logf.Any("p", &n),
+			logf.Any("nil", (*reflectedAddress)(nil)),
+			logf.Any("slice", []*reflectedAddress{{City: "Rome"}, nil}),
+			logf.Any("url", (*url.URL)(nil)),
+			logf.Any("err", (*reflectedError)(nil)),
+		)).ToEqual("msg p=42 nil=null slice=[{City=Rome Zip=0},null] url=null err=<nil>\n")
+	})
+
+	t.Run("Flatten", func(t tst.Test) {
+		enc := logftxt.NewEncoder(logftxt.Config{}, env, logftxt.ColorNever, theme)
+		buf := logf.NewBuffer()
+		t.Expect(enc.Encode(buf, logf.Entry{Text: "msg", Fields: []logf.Field{
+			logf.Any("user", reflectedUser{Name: "bob", Address: &reflectedAddress{City: "Rome"}}),
+			logf.Any("m", map[string]any{"k": map[string]int{"x": 1}}),
+		}})).ToSucceed()
+		t.Expect(buf.String()).ToEqual("msg user.name=bob user.address.City=Rome user.address.Zip=0 user.age=0 m.k.x=1\n")
+	})
+
+	t.Run("Cycle", func(t tst.Test) {
+		node := &reflectedNode{Name: "a"}
+		node.Next = &reflectedNode{Name: "b", Next: node}
+
+		t.Expect(encode(t, logftxt.Config{}, logf.Any("n", node))).ToEqual(
+			"msg n={Name=a Next={Name=b Next=<cycle>}}\n",
+		)
+
+		cfg := logftxt.Config{}
+		cfg.Values.Reflection.MaxCycles = 1
+
+		t.Expect(encode(t, cfg, logf.Any("n", node))).ToEqual(
+			"msg n={Name=a Next={Name=b Next={Name=a Next={Name=b Next=<cycle>}}}}\n",
+		)
+
+		m := map[string]any{"a": 1}
+		m["self"] = m
+
+		t.Expect(encode(t, logftxt.Config{}, logf.Any("m", m))).ToEqual("msg m={a=1 self=<cycle>}\n")
+	})
+
+	t.Run("MaxDepth", func(t tst.Test) {
+		cfg := logftxt.Config{}
+		cfg.Values.Reflection.MaxDepth = 2
+
+		t.Expect(encode(t, cfg,
+			logf.Any("a", [][][][]int{{{{1}}}}),
+			logf.Any("m", map[string]any{"x": map[string]any{"y": map[string]int{"z": 1}}}),
+		)).ToEqual("msg a=[[...]] m={x={y=...}}\n")
+	})
+
+	t.Run("NoExportedFields", func(t tst.Test) {
+		t.Expect(encode(t, logftxt.Config{}, logf.Any("v", anyStruct{42}))).ToEqual("msg v={42}\n")
+	})
+
+	t.Run("InvalidConfig", func(t tst.Test) {
+		cfg := logftxt.Config{}
+		cfg.Values.Reflection.MaxDepth = -1
+		t.Expect(cfg.Validate()).ToFail()
+
+		cfg = logftxt.Config{}
+		cfg.Values.Reflection.MaxCycles = -1
+		t.Expect(cfg.Validate()).ToFail()
+	})
+}
+
+// ---
+
+type reflectedUser struct {
+	Name    string            `json:"name"`
+	Email   string            `json:"email,omitempty"`
+	Roles   []string          `log:"roles,omitempty"`
+	Address *reflectedAddress `json:"address,omitempty"`
+	Age     int               `json:"age"`
+	Skipped int               `json:"-"`
+	secret  string
+}
+
+type reflectedAddress struct {
+	City string
+	Zip  int
+}
+
+type reflectedEmployee struct {
+	reflectedAddress
+	Team string `log:"team"`
+}
+
+type reflectedNode struct {
+	Name string
+	Next *reflectedNode
+}
+
+type reflectedError struct {
+	text string
+}
+
+func (e *reflectedError) Error() string {
+	return e.text
+}
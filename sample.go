@@ -0,0 +1,317 @@
+package logftxt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/ssgreg/logf"
+)
+
+// RenderSample writes a canonical set of log messages encoded using the given theme to w.
+//
+// The messages cover all log levels including [LevelTrace] and [LevelFatal], all value types the theme has formatting for,
+// truncated values with an ellipsis, errors with a stack trace, logger names and callers,
+// so it is handy to see how a theme looks while designing it.
+// Nil theme means the default one.
+// The options are applied on top of the theme, [DefaultLevelNames], [LineOverflowTruncate] and [ErrorFormatTrace],
+// so for example [ColorAlways] can be used to force colors.
+// Colors are enabled automatically if w is a terminal, the same way as with [NewWriterEncoder].
+func RenderSample(theme *Theme, w io.Writer, options ...AppenderOption) error {
+	if theme == nil {
+		theme = DefaultTheme()
+	}
+
+	options = append([]AppenderOption{
+		theme,
+		DefaultLevelNames(),
+		RedactFields(sampleRedactedKey),
+		LineWidth(sampleLineWidth),
+		LineOverflowTruncate,
+		ErrorFormatTrace,
+	}, options...)
+
+	enc := NewWriterEncoder(w, options...)
+	buf := logf.NewBufferWithCapacity(4096)
+
+	for _, entry := range sampleEntries() {
+		buf.Reset()
+
+		err := enc.Encode(buf, entry)
+		if err != nil {
+			return fmt.Errorf("failed to encode sample entry: %w", err)
+		}
+
+		_, err = w.Write(buf.Bytes())
+		if err != nil {
+			return fmt.Errorf("failed to write sample entry: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// SampleColumn is a column of the output of [RenderSampleColumns].
+type SampleColumn struct {
+	Title string
+	Theme *Theme
+}
+
+// RenderSampleColumns writes the sample of [RenderSample] encoded using the theme of each of the given columns to w side by side.
+//
+// The columns are laid out in rows of n columns separated by an empty line, zero n means a single row.
+// The width in terminal cells is split evenly between the columns of a row, each column starts with its title.
+// Zero width means width of the terminal w refers to, or a column width suitable for the sample if w is not a terminal.
+// Entries are wrapped to the column width using [LineOverflowWrap], the parts of lines that still do not fit are cut.
+// Colors are enabled automatically if w is a terminal, the same way as with [NewWriterEncoder].
+// [MarkupHTML] is not supported because the columns are aligned using spaces.
+func RenderSampleColumns(columns []SampleColumn, w io.Writer, n, width int, options ...AppenderOption) error {
+	if n <= 0 || n > len(columns) {
+		n = len(columns)
+	}
+
+	if n == 0 {
+		return nil
+	}
+
+	o := defaultAppenderOptions().With(options).resolved(w)
+	if o.markup == MarkupHTML {
+		return errors.New("side by side sample output does not support HTML markup")
+	}
+
+	if width <= 0 {
+		width = o.terminalWidth
+	}
+
+	columnWidth := sampleColumnWidth
+	if width > 0 {
+		columnWidth = (width - sampleColumnGap*(n-1)) / n
+	}
+
+	if columnWidth < 1 {
+		return fmt.Errorf("width %d is too small for %d columns", width, n)
+	}
+
+	options = append(options[:len(options):len(options)], o.color, LineWidth(columnWidth), LineOverflowWrap)
+
+	for i := 0; i < len(columns); i += n {
+		if i != 0 {
+			_, err := w.Write([]byte{'\n'})
+			if err != nil {
+				return fmt.Errorf("failed to write sample entry: %w", err)
+			}
+		}
+
+		err := renderSampleRow(columns[i:min(i+n, len(columns))], w, columnWidth, options)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// renderSampleRow writes the sample encoded using the theme of each of the given columns of the given width to w side by side.
+func renderSampleRow(columns []SampleColumn, w io.Writer, width int, options []AppenderOption) error {
+	texts := make([][]string, len(columns))
+	height := 0
+
+	for i, column := range columns {
+		buf := &bytes.Buffer{}
+
+		err := RenderSample(column.Theme, buf, options...)
+		if err != nil {
+			return err
+		}
+
+		texts[i] = strings.Split(column.Title+"\n"+strings.TrimSuffix(buf.String(), "\n"), "\n")
+		height = max(height, len(texts[i]))
+	}
+
+	var line []byte
+
+	for row := range height {
+		line = line[:0]
+
+		for i, text := range texts {
+			if i != 0 {
+				line = append(line, strings.Repeat(" ", sampleColumnGap)...)
+			}
+
+			if row < len(text) {
+				line = appendFitted(line, text[row], width)
+			} else {
+				line = appendFitted(line, "", width)
+			}
+		}
+
+		line = append(line, '\n')
+
+		_, err := w.Write(line)
+		if err != nil {
+			return fmt.Errorf("failed to write sample entry: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ---
+
+func sampleEntries() []logf.Entry {
+	ts := time.Date(2024, 3, 15, 14, 30, 5, 123456789, time.UTC)
+	caller := func(file string, line int) logf.EntryCaller {
+		return logf.EntryCaller{File: file, Line: line, Specified: true}
+	}
+
+	service := []logf.Field{logf.String("service", "api")}
+
+	return []logf.Entry{
+		{
+			LoggerID:      1,
+			LoggerName:    "db",
+			DerivedFields: service,
+			Level:         LevelTrace,
+			Time:          ts,
+			Text:          "resolving database address",
+			Fields: []logf.Field{
+				logf.String("host", "db.local"),
+				logf.Strings("addresses", []string{"192.0.2.10", "192.0.2.11"}),
+			},
+			Caller: caller("/src/example/internal/db/pool.go", 37),
+		},
+		{
+			LoggerID:      1,
+			LoggerName:    "db",
+			DerivedFields: service,
+			Level:         logf.LevelDebug,
+			Time:          ts.Add(3 * time.Millisecond),
+			Text:          "connecting to database",
+			Fields: []logf.Field{
+				logf.String("host", "db.local"),
+				logf.Int("port", 5432),
+				logf.Bool("tls", true),
+				logf.Duration("timeout", 5*time.Second),
+			},
+			Caller: caller("/src/example/internal/db/pool.go", 42),
+		},
+		{
+			LoggerID:      2,
+			LoggerName:    "http",
+			DerivedFields: service,
+			Level:         logf.LevelInfo,
+			Time:          ts.Add(10 * time.Millisecond),
+			Text:          "request accepted",
+			Fields: []logf.Field{
+				logf.Object("client", fieldsObject{
+					logf.String("ip", "192.0.2.1"),
+					logf.String("agent", `curl/8.0 "beta"`),
+				}),
+				logf.Time("started", ts.Add(10*time.Millisecond)),
+			},
+			Caller: caller("/src/example/internal/http/server.go", 102),
+		},
+		{
+			LoggerID:      2,
+			LoggerName:    "http",
+			DerivedFields: service,
+			Level:         logf.LevelInfo,
+			Time:          ts.Add(12 * time.Millisecond),
+			Text:          "request handled",
+			Fields: []logf.Field{
+				logf.String("method", "GET"),
+				logf.String("path", "/search?q=log viewer"),
+				logf.Int("status", 200),
+				logf.Duration("latency", 1500*time.Microsecond),
+				logf.Strings("tags", []string{"public", "cached"}),
+				logf.String(sampleRedactedKey, "secret"),
+			},
+			Caller: caller("/src/example/internal/http/server.go", 118),
+		},
+		{
+			LoggerID:   3,
+			LoggerName: "cache",
+			Level:      logf.LevelWarn,
+			Time:       ts.Add(250 * time.Millisecond),
+			Text:       "cache entry is stale",
+			Fields: []logf.Field{
+				logf.String("key", "user:42\tprofile"),
+				logf.String("value", "line one\nline two"),
+				logf.String("empty", ""),
+				logf.Any("owner", nil),
+				logf.Ints("shards", []int{1, 2, 3}),
+				logf.Float64("ratio", 0.75),
+			},
+			Caller: caller("/src/example/internal/cache/cache.go", 7),
+		},
+		{
+			LoggerID:      2,
+			LoggerName:    "http",
+			DerivedFields: service,
+			Level:         logf.LevelWarn,
+			Time:          ts.Add(500 * time.Millisecond),
+			Text:          "response is too large",
+			Fields: []logf.Field{
+				logf.Int("size", 1048576),
+				logf.String("body", strings.Repeat("lorem ipsum dolor sit amet ", 10)),
+			},
+			Caller: caller("/src/example/internal/http/server.go", 124),
+		},
+		{
+			LoggerID:      2,
+			LoggerName:    "http",
+			DerivedFields: service,
+			Level:         logf.LevelError,
+			Time:          ts.Add(time.Second),
+			Text:          "failed to handle request",
+			Fields: []logf.Field{
+				logf.NamedError("error", fmt.Errorf("failed to query database: %w", errors.New("connection reset by peer"))),
+				logf.Int("attempt", 3),
+			},
+			Caller: caller("/src/example/internal/http/server.go", 131),
+		},
+		{
+			LoggerID:      4,
+			LoggerName:    "main",
+			DerivedFields: service,
+			Level:         LevelFatal,
+			Time:          ts.Add(2 * time.Second),
+			Text:          "shutting down",
+			Fields: []logf.Field{
+				logf.NamedError("error", sampleTracedError{errors.New("database is unavailable")}),
+			},
+			Caller: caller("/src/example/cmd/api/main.go", 23),
+		},
+	}
+}
+
+// ---
+
+// sampleTracedError is an error with a fixed stack trace, so that the sample is the same each time it is rendered.
+type sampleTracedError struct {
+	error
+}
+
+func (e sampleTracedError) StackTrace() []runtime.Frame {
+	return []runtime.Frame{
+		{Function: "main.(*server).serve", File: "/src/example/cmd/api/server.go", Line: 57},
+		{Function: "main.main", File: "/src/example/cmd/api/main.go", Line: 23},
+	}
+}
+
+func (e sampleTracedError) Unwrap() error {
+	return e.error
+}
+
+// ---
+
+const (
+	sampleRedactedKey = "password"
+	sampleLineWidth   = 200
+	sampleColumnWidth = 100
+	sampleColumnGap   = 2
+)
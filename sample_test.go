@@ -0,0 +1,158 @@
+package logftxt_test
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/pamburus/go-tst/tst"
+	"github.com/pamburus/logftxt"
+)
+
+func TestRenderSample(tt *testing.T) {
+	t := tst.New(tt)
+
+	env := logftxt.Environment(func(string) (string, bool) { return "", false })
+
+	t.Run("Default", func(t tst.Test) {
+		buf := &bytes.Buffer{}
+		t.Expect(logftxt.RenderSample(nil, buf, env, logftxt.ColorNever)).ToSucceed()
+
+		var lines, blocks []string
+
+		for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+			if strings.HasPrefix(line, "  | ") {
+				blocks = append(blocks, line)
+			} else {
+				lines = append(lines, line)
+			}
+		}
+
+		t.Expect(len(lines)).ToEqual(8)
+
+		for i, prefix := range []string{
+			"[TRC] db:", "[DBG] db:", "[INF] http:", "[INF] http:", "[WRN] cache:", "[WRN] http:", "[ERR] http:", "[ERR] main:",
+		} {
+			t.Expect(strings.Contains(lines[i], prefix)).ToBeTrue()
+		}
+
+		t.Expect(strings.Contains(lines[2], `client.agent="curl/8.0 \"beta\"" started='Mar 15 14:30:05'`)).ToBeTrue()
+
+		for _, expected := range []string{
+			"tags=[public cached]",
+			"password=***",
+			"latency=00:00:00.0015",
+			"@ http/server.go:118",
+		} {
+			t.Expect(strings.Contains(lines[3], expected)).ToBeTrue()
+		}
+
+		t.Expect(strings.Contains(lines[4], `key="user:42\tprofile" value="line one\nline two" empty="" owner=null`)).ToBeTrue()
+		t.Expect(strings.Contains(lines[5], `body="lorem ipsum dolor sit amet lorem ipsum dolor sit amet lorem ipsum dolor sit amet lorem ipsum dol"…`)).ToBeTrue()
+		t.Expect(strings.Contains(lines[6], "error='failed to query database: connection reset by peer'")).ToBeTrue()
+		t.Expect(strings.Contains(lines[7], "error='database is unavailable'")).ToBeTrue()
+		t.Expect(blocks).ToEqual([]string{
+			"  | error=",
+			"  |   at main.(*server).serve (/src/example/cmd/api/server.go:57)",
+			"  |   at main.main (/src/example/cmd/api/main.go:23)",
+		})
+	})
+
+	t.Run("CustomLevels", func(t tst.Test) {
+		theme, err := logftxt.ReadTheme(strings.NewReader(strings.Join([]string{
+			"theme:",
+			"  version: '1.0'",
+			"  items: [level, message]",
+			"  formatting: {level: {trace: {text: TRC}, fatal: {text: FTL}}}",
+		}, "\n")))
+		t.Expect(err).ToNot(tst.HaveOccurred())
+
+		buf := &bytes.Buffer{}
+		t.Expect(logftxt.RenderSample(theme, buf, env, logftxt.ColorNever)).ToSucceed()
+		t.Expect(strings.HasPrefix(buf.String(), "TRC resolving database address\n")).ToBeTrue()
+		t.Expect(strings.Contains(buf.String(), "\nFTL shutting down\n")).ToBeTrue()
+	})
+
+	t.Run("BuiltIn", func(t tst.Test) {
+		themes, err := logftxt.ListBuiltInThemes()
+		t.Expect(err).ToNot(tst.HaveOccurred())
+
+		for _, name := range themes {
+			theme, err := logftxt.LoadBuiltInTheme(name)
+			t.Expect(err).ToNot(tst.HaveOccurred())
+
+			buf := &bytes.Buffer{}
+			t.Expect(logftxt.RenderSample(theme, buf, env, logftxt.ColorAlways)).ToSucceed()
+			t.Expect(strings.Count(buf.String(), "\n")).ToEqual(11)
+			t.Expect(strings.Contains(buf.String(), "\x1b[")).ToBeTrue()
+		}
+	})
+
+	t.Run("WriteError", func(t tst.Test) {
+		t.Expect(logftxt.RenderSample(nil, failingWriter{}, env)).ToFail()
+	})
+}
+
+func TestRenderSampleColumns(tt *testing.T) {
+	t := tst.New(tt)
+
+	env := logftxt.Environment(func(string) (string, bool) { return "", false })
+
+	load := func(name string) *logftxt.Theme {
+		theme, err := logftxt.LoadBuiltInTheme(name)
+		t.Expect(err).ToNot(tst.HaveOccurred())
+
+		return theme
+	}
+
+	columns := []logftxt.SampleColumn{
+		{Title: "default", Theme: load("default")},
+		{Title: "fancy", Theme: load("fancy")},
+		{Title: "tint", Theme: load("tint")},
+	}
+
+	t.Run("Row", func(t tst.Test) {
+		for _, color := range []logftxt.ColorSetting{logftxt.ColorNever, logftxt.ColorAlways} {
+			buf := &bytes.Buffer{}
+			t.Expect(logftxt.RenderSampleColumns(columns, buf, 0, 124, env, color)).ToSucceed()
+
+			lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			t.Expect(strings.HasPrefix(lines[0], "default"+strings.Repeat(" ", 35)+"fancy")).ToBeTrue()
+
+			for _, line := range lines {
+				t.Expect(displayWidth(line)).ToEqual(124)
+			}
+		}
+	})
+
+	t.Run("Rows", func(t tst.Test) {
+		buf := &bytes.Buffer{}
+		t.Expect(logftxt.RenderSampleColumns(columns, buf, 2, 82, env, logftxt.ColorNever)).ToSucceed()
+
+		rows := strings.Split(buf.String(), "\n\n")
+		t.Expect(len(rows)).ToEqual(2)
+		t.Expect(strings.HasPrefix(rows[1], "tint"+strings.Repeat(" ", 36)+"\n")).ToBeTrue()
+	})
+
+	t.Run("Errors", func(t tst.Test) {
+		t.Expect(logftxt.RenderSampleColumns(columns, &bytes.Buffer{}, 0, 5, env)).ToFail()
+		t.Expect(logftxt.RenderSampleColumns(columns, &bytes.Buffer{}, 0, 0, env, logftxt.MarkupHTML)).ToFail()
+		t.Expect(logftxt.RenderSampleColumns(columns, failingWriter{}, 0, 0, env)).ToFail()
+	})
+}
+
+// displayWidth returns number of characters in the line skipping escape sequences.
+func displayWidth(line string) int {
+	return utf8.RuneCountInString(regexp.MustCompile("\x1b\\[[0-9;]*m").ReplaceAllString(line, ""))
+}
+
+// ---
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
@@ -9,15 +9,19 @@ import (
 func newStyler() styler {
 	return styler{
 		defaultStyle,
+		stylePatch{IsEmpty: true},
 		make(sgr.Sequence, 0, 8),
 		false,
+		nil,
 	}
 }
 
 type styler struct {
 	style    style
+	override stylePatch
 	seq      sgr.Sequence
 	disabled bool
+	marks    []int
 }
 
 func (s styler) Disabled(value bool) styler {
@@ -34,28 +38,111 @@ func (s *styler) Use(style stylePatch, buf *logf.Buffer, f func()) {
 	}
 
 	old := s.style
-	if s.style.UpdateBy(style) {
-		seq := old.diffToSequence(s.style, s.seq[0:0])
-		buf.Data = seq.Render(buf.Data)
+	updated := s.style.UpdateBy(style)
+
+	if !s.override.IsEmpty {
+		s.style.UpdateBy(s.override)
+		updated = s.style != old
+	}
+
+	if updated {
+		s.render(buf, old.diffToSequence(s.style, s.seq[0:0]))
 
 		f()
 
-		seq = s.style.diffToSequence(old, s.seq[0:0])
-		buf.Data = seq.Render(buf.Data)
+		s.render(buf, s.style.diffToSequence(old, s.seq[0:0]))
 		s.style = old
 	} else {
 		f()
 	}
 }
 
+// Override uses the style and keeps it on top of any other style used while f is being called.
+func (s *styler) Override(style stylePatch, buf *logf.Buffer, f func()) {
+	if s.disabled || style.IsEmpty {
+		f()
+
+		return
+	}
+
+	old := s.override
+	s.override = style
+
+	s.Use(style, buf, f)
+
+	s.override = old
+}
+
+// render appends the sequence to the buffer and remembers its position, see [styler.Marks].
+func (s *styler) render(buf *logf.Buffer, seq sgr.Sequence) {
+	if len(seq) != 0 {
+		s.marks = append(s.marks, buf.Len())
+		buf.Data = seq.Render(buf.Data)
+	}
+}
+
+// Mark remembers positions of all escape sequences in buf starting at the given position,
+// so that they are treated the same way as the sequences written by the styler itself.
+func (s *styler) Mark(buf []byte, start int) {
+	for i := start; i < len(buf); i++ {
+		if buf[i] == esc {
+			s.marks = append(s.marks, i)
+			i += escapeSequenceLen(buf[i:]) - 1
+		}
+	}
+}
+
+// Marks returns sorted positions of the escape sequences written starting at the given position.
+// Any other escape character in the buffer comes from the content and must not be trusted.
+func (s *styler) Marks(start int) []int {
+	i := len(s.marks)
+	for i != 0 && s.marks[i-1] >= start {
+		i--
+	}
+
+	return s.marks[i:]
+}
+
+// Forget drops positions of the escape sequences at the given position and after it
+// because the buffer has been truncated there.
+func (s *styler) Forget(pos int) {
+	s.marks = s.marks[:len(s.marks)-len(s.Marks(pos))]
+}
+
+// Reset drops positions of all escape sequences.
+func (s *styler) Reset() {
+	s.marks = s.marks[:0]
+}
+
 // ---
 
 type stylePatch struct {
-	Background sgr.Command
-	Foreground sgr.Command
-	Modes      [3]sgr.ModeSet
-	HasModes   bool
-	IsEmpty    bool
+	Background      sgr.Command
+	Foreground      sgr.Command
+	Modes           [3]sgr.ModeSet
+	HasModes        bool
+	IsEmpty         bool
+	BackgroundColor sgr.Color
+	ForegroundColor sgr.Color
+}
+
+// downgraded returns the patch with colors converted to the closest ones supported with the given color depth.
+func (p stylePatch) downgraded(depth colorDepth) stylePatch {
+	if depth == colorDepthTrueColor {
+		return p
+	}
+
+	if !p.BackgroundColor.IsZero() {
+		p.BackgroundColor = depth.convert(p.BackgroundColor)
+		p.Background = sgr.SetBackgroundColor(p.BackgroundColor)
+	}
+
+	if !p.ForegroundColor.IsZero() {
+		p.ForegroundColor = depth.convert(p.ForegroundColor)
+		p.Foreground = sgr.SetForegroundColor(p.ForegroundColor)
+	}
+
+	return p
 }
 
 func (p stylePatch) encode(e *entryEncoder, encodeInner func()) {
@@ -0,0 +1,192 @@
+package logftxt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/pamburus/go-ansi-esc/sgr"
+)
+
+// NewSVGWriter returns a writer that collects text with ANSI SGR escape sequences,
+// like the output of [NewAppender] with colors enabled, and renders it as an SVG image written to w when the writer is closed.
+//
+// Styles are rendered the same way as with [MarkupHTML], so that the image matches the terminal.
+// It is handy for making screenshots of log output, for example together with [RenderSample].
+func NewSVGWriter(w io.Writer) *SVGWriter {
+	return &SVGWriter{w: w}
+}
+
+// SVGWriter renders collected text as an SVG image, see [NewSVGWriter].
+type SVGWriter struct {
+	w    io.Writer
+	data []byte
+}
+
+// Write collects the text to be rendered.
+func (s *SVGWriter) Write(p []byte) (int, error) {
+	s.data = append(s.data, p...)
+
+	return len(p), nil
+}
+
+// Close renders the collected text as an SVG image and writes it to the underlying writer.
+func (s *SVGWriter) Close() error {
+	lines := svgLines(s.data)
+	width := 0
+
+	for _, line := range lines {
+		width = max(width, line.width())
+	}
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" font-family="monospace" font-size="%s">`+"\n",
+		svgNumber(2*svgPadding+float64(width)*svgCellWidth),
+		svgNumber(2*svgPadding+float64(len(lines))*svgLineHeight),
+		svgNumber(svgFontSize),
+	)
+	fmt.Fprintf(&buf, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", markupBackground)
+
+	for i, line := range lines {
+		line.render(&buf, svgPadding+float64(i)*svgLineHeight)
+	}
+
+	buf.WriteString("</svg>\n")
+
+	s.data = s.data[:0]
+
+	_, err := s.w.Write(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to write svg: %w", err)
+	}
+
+	return nil
+}
+
+// ---
+
+// svgSpan is a piece of text having the same style.
+type svgSpan struct {
+	text   []byte
+	style  markupStyle
+	column int
+}
+
+type svgLine []svgSpan
+
+func svgLines(data []byte) []svgLine {
+	var (
+		s      markupScanner
+		lines  []svgLine
+		line   svgLine
+		column int
+	)
+
+	s.scan(bytes.TrimSuffix(data, []byte("\n")), func(piece []byte) {
+		for {
+			text, rest, found := bytes.Cut(piece, []byte("\n"))
+			if n := len(line); n != 0 && line[n-1].style == s.style {
+				line[n-1].text = slices.Concat(line[n-1].text, text)
+			} else if len(text) != 0 {
+				line = append(line, svgSpan{text, s.style, column})
+			}
+
+			column += displayWidth(text)
+
+			if !found {
+				break
+			}
+
+			lines = append(lines, line)
+			line, column, piece = nil, 0, rest
+		}
+	})
+
+	return append(lines, line)
+}
+
+func (l svgLine) width() int {
+	if len(l) == 0 {
+		return 0
+	}
+
+	last := l[len(l)-1]
+
+	return last.column + displayWidth(last.text)
+}
+
+func (l svgLine) render(buf *bytes.Buffer, top float64) {
+	for _, span := range l {
+		if _, background := span.style.colors(); background != "" {
+			fmt.Fprintf(buf, `<rect x="%s" y="%s" width="%s" height="%s" fill="%s"/>`+"\n",
+				svgNumber(svgPadding+float64(span.column)*svgCellWidth),
+				svgNumber(top),
+				svgNumber(float64(displayWidth(span.text))*svgCellWidth),
+				svgNumber(svgLineHeight),
+				background,
+			)
+		}
+	}
+
+	if len(l) == 0 {
+		return
+	}
+
+	fmt.Fprintf(buf, `<text y="%s" xml:space="preserve" fill="%s">`, svgNumber(top+svgBaseline), markupForeground)
+
+	for _, span := range l {
+		if span.style.modes.Has(sgr.Concealed) {
+			continue
+		}
+
+		fmt.Fprintf(buf, `<tspan x="%s"`, svgNumber(svgPadding+float64(span.column)*svgCellWidth))
+
+		if foreground, _ := span.style.colors(); foreground != "" {
+			fmt.Fprintf(buf, ` fill="%s"`, foreground)
+		}
+
+		if span.style.modes.Has(sgr.Bold) {
+			buf.WriteString(` font-weight="bold"`)
+		}
+
+		if span.style.modes.Has(sgr.Faint) {
+			buf.WriteString(` fill-opacity="0.6"`)
+		}
+
+		if span.style.modes.Has(sgr.Italic) {
+			buf.WriteString(` font-style="italic"`)
+		}
+
+		if decoration := span.style.textDecoration(); decoration != "" {
+			fmt.Fprintf(buf, ` text-decoration="%s"`, decoration)
+		}
+
+		buf.WriteByte('>')
+		buf.Write(appendEscapedHTML(nil, span.text))
+		buf.WriteString("</tspan>")
+	}
+
+	buf.WriteString("</text>\n")
+}
+
+func svgNumber(value float64) string {
+	return strconv.FormatFloat(math.Round(value*10)/10, 'f', -1, 64)
+}
+
+// ---
+
+const (
+	svgFontSize   = 14
+	svgCellWidth  = 8.4
+	svgLineHeight = 18
+	svgBaseline   = 14
+	svgPadding    = 10
+)
+
+// ---
+
+var _ io.WriteCloser = (*SVGWriter)(nil)
@@ -15,5 +15,6 @@ require (
 	github.com/pamburus/go-ansi-esc v0.5.0 // indirect
 	github.com/veggiemonk/strcase v0.0.0-20240108101409-9f441287a9a9 // indirect
 	golang.org/x/sys v0.27.0 // indirect
+	golang.org/x/term v0.26.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
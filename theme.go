@@ -1,12 +1,14 @@
 package logftxt
 
 import (
+	"bytes"
 	"embed"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"path"
+	"slices"
 	"strings"
 	"sync"
 
@@ -31,13 +33,10 @@ func LoadTheme(filename string, opts ...domainOption) (*Theme, error) {
 }
 
 // ReadTheme reads theme configuration from the given reader.
+//
+// If the theme extends another theme, the base theme is resolved the same way as [ThemeRef] does it.
 func ReadTheme(reader io.Reader) (*Theme, error) {
-	cfg, err := themecfg.Load(reader)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read theme: %w", err)
-	}
-
-	return newTheme(cfg), nil
+	return readTheme(reader, defaultDomain().fs)
 }
 
 // DefaultTheme returns default built-in theme.
@@ -56,16 +55,7 @@ func DefaultTheme() *Theme {
 
 // LoadBuiltInTheme loads named built-in theme.
 func LoadBuiltInTheme(name string) (*Theme, error) {
-	f, err := embeddedThemes.Open(path.Join("assets/theme", name+".yml"))
-	if err != nil {
-		if errors.Is(err, fs.ErrNotExist) {
-			return nil, fmt.Errorf("built-in theme %q not found", name)
-		}
-
-		return nil, fmt.Errorf("failed to load built-in theme %q: %w", name, err)
-	}
-
-	return ReadTheme(f)
+	return loadTheme("@"+name, defaultDomain().fs)
 }
 
 // ListBuiltInThemes returns list of names of all built-in themes.
@@ -88,7 +78,32 @@ func ListBuiltInThemes() ([]string, error) {
 type Theme struct {
 	items    []item
 	fmt      fmtItems
+	levels   [4]fmtLevel
+	fields   fieldRules
 	settings themecfg.Settings
+
+	// levelFormatting is used to build formatting of level items of custom levels.
+	levelFormatting formatting.Level
+
+	// levelDependentFields is true if output of fields depends on the log level.
+	levelDependentFields bool
+
+	// variants contains the theme itself and its variants for particular modes, it is shared by all of them.
+	variants map[ThemeMode]*Theme
+}
+
+// variant returns variant of the theme for the given mode or the theme itself if the mode is auto.
+// If the theme has no variant for the mode, the theme without any variant applied is returned.
+func (t *Theme) variant(mode ThemeMode) *Theme {
+	if mode == ThemeModeAuto || t.variants == nil {
+		return t
+	}
+
+	if variant, ok := t.variants[mode]; ok {
+		return variant
+	}
+
+	return t.variants[ThemeModeAuto]
 }
 
 func (t *Theme) toEncoderOptions(o *encoderOptions) {
@@ -148,7 +163,12 @@ func (v ThemeEnvironmentRef) fn() ThemeProvideFunc {
 		domain = NewDomain(domain, v.opts...)
 
 		if v, ok := env.Theme(domain.Environment()); ok {
-			return NewThemeRef(v, WithFS(domain.FS())).Load()
+			theme, err := NewThemeRef(v, WithFS(domain.FS())).Load()
+			if err != nil {
+				return nil, err
+			}
+
+			return theme.variant(ThemeModeAuto.resolved(domain.Environment())), nil
 		}
 
 		return nil, nil
@@ -157,6 +177,43 @@ func (v ThemeEnvironmentRef) fn() ThemeProvideFunc {
 
 // ---
 
+// Valid values for ThemeMode.
+const (
+	ThemeModeAuto  ThemeMode = ""
+	ThemeModeDark  ThemeMode = "dark"
+	ThemeModeLight ThemeMode = "light"
+)
+
+// ThemeMode selects a variant of the theme tuned for dark or light terminal background.
+//
+// ThemeModeAuto selects the variant using `LOGFTXT_THEME_MODE` environment variable
+// or `COLORFGBG` environment variable set by some terminals. If the mode cannot be detected, no variant is applied.
+type ThemeMode string
+
+func (m ThemeMode) toEncoderOptions(o *encoderOptions) {
+	o.themeMode = m
+}
+
+func (m ThemeMode) toAppenderOptions(o *appenderOptions) {
+	o.themeMode = m
+}
+
+func (m ThemeMode) resolved(e Environment) ThemeMode {
+	if m == ThemeModeAuto {
+		switch env.ThemeMode(e) {
+		case env.ModeDark:
+			m = ThemeModeDark
+		case env.ModeLight:
+			m = ThemeModeLight
+		case env.ModeAuto:
+		}
+	}
+
+	return m
+}
+
+// ---
+
 // NewThemeRef constructs a new theme reference with the given name and options.
 func NewThemeRef(name string, opts ...domainOption) ThemeRef {
 	return ThemeRef{name, opts}
@@ -195,10 +252,6 @@ func (v ThemeRef) Load() (*Theme, error) {
 		return nil, nil //nolint:nilnil // type [Theme] has no exported methods and cannot be used directly
 	}
 
-	if v.name[0] == '@' {
-		return LoadBuiltInTheme(v.name[1:])
-	}
-
 	return loadTheme(v.name, defaultDomain().with(v.options).fs)
 }
 
@@ -211,21 +264,154 @@ func (v ThemeRef) toAppenderOptions(o *appenderOptions) {
 }
 
 func (v ThemeRef) fn() ThemeProvideFunc {
-	return ThemeProvideFunc(func(Domain) (*Theme, error) {
-		return v.Load()
+	return ThemeProvideFunc(func(domain Domain) (*Theme, error) {
+		if v.name == "" {
+			return nil, nil
+		}
+
+		return loadTheme(v.name, NewDomain(domain, v.options...).FS())
 	})
 }
 
 // ---
 
-func loadTheme(filename string, fs FS) (*Theme, error) {
-	f, err := fs.Open(filename) //nolint:gosec // it is ok to allow user to specify theme files
+func loadTheme(name string, fileSystem FS) (*Theme, error) {
+	return newThemeWithVariants(func(mode themecfg.Mode) (*themecfg.Theme, error) {
+		return loadThemeConfig(name, fileSystem, nil, nil, mode)
+	})
+}
+
+func readTheme(reader io.Reader, fileSystem FS) (*Theme, error) {
+	data, err := io.ReadAll(reader)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load theme %w", err)
+		return nil, fmt.Errorf("failed to read theme: %w", err)
+	}
+
+	return newThemeWithVariants(func(mode themecfg.Mode) (*themecfg.Theme, error) {
+		return readThemeConfig(bytes.NewReader(data), fileSystem, nil, nil, mode)
+	})
+}
+
+// newThemeWithVariants builds the theme and its variants for all modes the theme has variants for.
+func newThemeWithVariants(load func(themecfg.Mode) (*themecfg.Theme, error)) (*Theme, error) {
+	cfg, err := load(themecfg.ModeDefault)
+	if err != nil {
+		return nil, err
+	}
+
+	theme, err := newValidTheme(cfg)
+	if err != nil || len(cfg.Modes) == 0 {
+		return theme, err
+	}
+
+	theme.variants = map[ThemeMode]*Theme{ThemeModeAuto: theme}
+
+	for _, mode := range cfg.Modes {
+		cfg, err := load(mode)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s variant of theme: %w", mode, err)
+		}
+
+		variant, err := newValidTheme(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s variant of theme: %w", mode, err)
+		}
+
+		variant.variants = theme.variants
+		theme.variants[ThemeMode(mode)] = variant
+	}
+
+	return theme, nil
+}
+
+func newValidTheme(cfg *themecfg.Theme) (*Theme, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read theme: theme is invalid: %w", err)
+	}
+
+	return newTheme(cfg), nil
+}
+
+// loadThemeConfig loads theme configuration referenced by the given name the same way as ThemeRef does it.
+// The chain contains names of the themes being loaded and is used to detect cycles.
+// The palette contains colors of the themes being loaded that override colors of the loaded theme.
+// The mode selects variants applied to the theme and the themes it extends.
+func loadThemeConfig(name string, fileSystem FS, chain []string, palette themecfg.Palette, mode themecfg.Mode) (*themecfg.Theme, error) {
+	if slices.Contains(chain, name) {
+		return nil, ThemeCycleError{slices.Concat(chain, []string{name})}
+	}
+
+	f, err := openTheme(name, fileSystem)
+	if err != nil {
+		return nil, err
 	}
 	defer f.Close() //nolint:errcheck // read-only
 
-	return ReadTheme(f)
+	return readThemeConfig(f, fileSystem, append(chain, name), palette, mode)
+}
+
+// readThemeConfig reads theme configuration and merges it on top of the base theme configuration it extends, if any.
+// Palette of the theme overrides palette of the base theme, so that styles of the base theme use the overridden colors.
+func readThemeConfig(
+	reader io.Reader, fileSystem FS, chain []string, palette themecfg.Palette, mode themecfg.Mode,
+) (*themecfg.Theme, error) {
+	src, err := themecfg.Parse(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read theme: %w", err)
+	}
+
+	return decodeThemeConfig(src, fileSystem, chain, palette, mode)
+}
+
+// decodeThemeConfig decodes parsed theme configuration and merges it on top of the base theme configuration it extends, if any.
+func decodeThemeConfig(
+	src *themecfg.Source, fileSystem FS, chain []string, palette themecfg.Palette, mode themecfg.Mode,
+) (*themecfg.Theme, error) {
+	palette = src.PaletteFor(mode).UpdatedBy(palette)
+
+	if src.Extends == "" {
+		cfg, err := src.Decode(palette, mode)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read theme: %w", err)
+		}
+
+		return cfg, nil
+	}
+
+	base, err := loadThemeConfig(src.Extends, fileSystem, chain, palette, mode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load base theme %q: %w", src.Extends, err)
+	}
+
+	cfg, err := src.Decode(base.Palette, mode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read theme: %w", err)
+	}
+
+	return base.UpdatedBy(cfg), nil
+}
+
+func openTheme(name string, fileSystem FS) (fs.File, error) {
+	if name, ok := strings.CutPrefix(name, "@"); ok {
+		f, err := embeddedThemes.Open(path.Join("assets/theme", name+".yml"))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("built-in theme %q not found", name)
+			}
+
+			return nil, fmt.Errorf("failed to load built-in theme %q: %w", name, err)
+		}
+
+		return f, nil
+	}
+
+	f, err := fileSystem.Open(name) //nolint:gosec // it is ok to allow user to specify theme files
+	if err != nil {
+		return nil, fmt.Errorf("failed to load theme %w", err)
+	}
+
+	return f, nil
 }
 
 // ---
@@ -239,6 +425,10 @@ type item interface {
 type itemTimestamp struct{}
 
 func (*itemTimestamp) encode(e *entryEncoder) {
+	if e.omitZeroTime && e.entry.Time.IsZero() {
+		return
+	}
+
 	e.theme.fmt.Timestamp.encode(e, func() {
 		e.appendTimestamp(e.entry.Time)
 	})
@@ -249,13 +439,7 @@ func (*itemTimestamp) encode(e *entryEncoder) {
 type itemLevel struct{}
 
 func (*itemLevel) encode(e *entryEncoder) {
-	// assert that logf.LevelDebug value is greater of equal than logf.LevelError value
-	_ = [logf.LevelDebug - logf.LevelError]struct{}{}
-
-	index := e.entry.Level
-	index = max(index, logf.LevelError)
-	index = min(index, logf.LevelDebug)
-	level := e.theme.fmt.Level[index]
+	level := e.levelItem()
 
 	level.encode(e, func() {
 		e.buf.AppendString(level.text)
@@ -268,8 +452,8 @@ type itemLogger struct{}
 
 func (*itemLogger) encode(e *entryEncoder) {
 	if e.entry.LoggerName != "" {
-		e.theme.fmt.Logger.encode(e, func() {
-			e.buf.AppendString(e.entry.LoggerName)
+		e.level.Logger.encode(e, func() {
+			e.appendText(e.entry.LoggerName)
 		})
 	}
 }
@@ -279,9 +463,23 @@ func (*itemLogger) encode(e *entryEncoder) {
 type itemMessage struct{}
 
 func (*itemMessage) encode(e *entryEncoder) {
-	if e.entry.Text != "" {
-		e.theme.fmt.Message.encode(e, func() {
-			e.buf.AppendString(e.entry.Text)
+	text := e.entry.Text
+
+	if e.multilineBlocks() {
+		if ml, ok := multilineText(text); ok {
+			text, ml, _ = strings.Cut(ml, "\n")
+			e.blocks = append(e.blocks, block{text: ml})
+		}
+	}
+
+	if width := e.theme.settings.Alignment.MessageWidth; width > 0 {
+		// Next item should start after the message padded to the width and a separator.
+		e.alignTo = e.column() + width + 1
+	}
+
+	if text != "" {
+		e.level.Message.encode(e, func() {
+			e.appendText(text)
 		})
 	}
 }
@@ -304,29 +502,35 @@ type itemFields struct{}
 
 func (*itemFields) encode(e *entryEncoder) {
 	// Logger's fields.
-	if bytes, ok := e.cache.Get(e.entry.LoggerID); ok {
+	cache := e.derivedFieldsCache()
+	if cache == nil {
+		encodeFields(e, e.entry.DerivedFields)
+	} else if bytes, ok := cache.Get(e.entry.LoggerID); ok {
 		e.buf.AppendBytes(bytes)
+		e.lastPos = e.buf.Len()
 	} else {
 		le := e.buf.Len()
+		nb := len(e.blocks)
 
-		for _, field := range e.entry.DerivedFields {
-			pos := e.appendSeparator()
-			field.Accept(e)
-			e.confirmSeparator(pos)
-		}
+		encodeFields(e, e.entry.DerivedFields)
 
-		if n := e.buf.Len() - le; n != 0 {
+		// Deferred blocks are not part of the cached bytes, so such fields cannot be cached.
+		if n := e.buf.Len() - le; n != 0 && len(e.blocks) == nb {
 			bf := make([]byte, n)
 			copy(bf, e.buf.Data[le:])
-			e.cache.Set(e.entry.LoggerID, bf)
+			cache.Set(e.entry.LoggerID, bf)
 		}
 	}
 
 	// Entry's fields.
-	for _, field := range e.entry.Fields {
-		pos := e.appendSeparator()
+	encodeFields(e, e.entry.Fields)
+}
+
+func encodeFields(e *entryEncoder, fields []logf.Field) {
+	for _, field := range fields {
+		pos, column := e.appendFieldSeparator(field.Key)
 		field.Accept(e)
-		e.confirmSeparator(pos)
+		e.confirmFieldSeparator(pos, column, field.Key)
 	}
 }
 
@@ -355,6 +559,11 @@ type format struct {
 	style  stylePatch
 }
 
+// width returns number of terminal cells occupied by the prefix and the suffix.
+func (f format) width() int {
+	return stringWidth(f.prefix) + stringWidth(f.suffix)
+}
+
 // ---
 
 type styledText struct {
@@ -373,11 +582,9 @@ func (t styledText) encode(e *entryEncoder) {
 type fmtItems struct {
 	Timestamp fmtItem
 	Level     [4]fmtItem
-	Logger    fmtItem
-	Message   fmtItem
-	Field     fmtItem
 	Key       fmtItem
 	Caller    fmtItem
+	Gutter    fmtItem
 	Array     fmtItem
 	Object    fmtItem
 	String    fmtItem
@@ -389,6 +596,28 @@ type fmtItems struct {
 	Duration  fmtItem
 	Null      fmtItem
 	Error     fmtItem
+	Frame     fmtFrame
+	Redacted  fmtItem
+	Ellipsis  fmtItem
+}
+
+// ---
+
+// fmtLevel holds formatting of items that can be overridden for particular log levels.
+type fmtLevel struct {
+	Line    fmtItem
+	Logger  fmtItem
+	Message fmtItem
+	Field   fmtItem
+}
+
+// ---
+
+type fmtFrame struct {
+	fmtItem
+	Function fmtItem
+	File     fmtItem
+	Line     fmtItem
 }
 
 // ---
@@ -407,16 +636,14 @@ func newTheme(cfg *themecfg.Theme) *Theme {
 		fmtItems{
 			newFmtItem(cfg.Formatting.Timestamp),
 			[4]fmtItem{
-				logf.LevelDebug: newFmtItem(cfg.Formatting.Level.All.UpdatedBy(cfg.Formatting.Level.Debug)),
-				logf.LevelInfo:  newFmtItem(cfg.Formatting.Level.All.UpdatedBy(cfg.Formatting.Level.Info)),
-				logf.LevelWarn:  newFmtItem(cfg.Formatting.Level.All.UpdatedBy(cfg.Formatting.Level.Warning)),
-				logf.LevelError: newFmtItem(cfg.Formatting.Level.All.UpdatedBy(cfg.Formatting.Level.Error)),
+				logf.LevelDebug: newLevelItem(cfg.Formatting.Level, logf.LevelDebug),
+				logf.LevelInfo:  newLevelItem(cfg.Formatting.Level, logf.LevelInfo),
+				logf.LevelWarn:  newLevelItem(cfg.Formatting.Level, logf.LevelWarn),
+				logf.LevelError: newLevelItem(cfg.Formatting.Level, logf.LevelError),
 			},
-			newFmtItem(cfg.Formatting.Logger),
-			newFmtItem(cfg.Formatting.Message),
-			newFmtItem(cfg.Formatting.Field),
 			newFmtItem(cfg.Formatting.Key),
 			newFmtItem(cfg.Formatting.Caller),
+			newFmtItem(cfg.Formatting.Gutter),
 			newFmtItem(cfg.Formatting.Types.Array),
 			newFmtItem(cfg.Formatting.Types.Object),
 			newFmtItem(cfg.Formatting.Types.String),
@@ -427,17 +654,58 @@ func newTheme(cfg *themecfg.Theme) *Theme {
 			newFmtItem(cfg.Formatting.Types.Time),
 			newFmtItem(cfg.Formatting.Types.Duration),
 			newFmtItem(cfg.Formatting.Types.Null),
-			newFmtItem(cfg.Formatting.Types.Error),
+			newFmtItem(cfg.Formatting.Types.Error.Item),
+			fmtFrame{
+				newFmtItem(cfg.Formatting.Types.Error.Frame.Item),
+				newFmtItem(cfg.Formatting.Types.Error.Frame.Function),
+				newFmtItem(cfg.Formatting.Types.Error.Frame.File),
+				newFmtItem(cfg.Formatting.Types.Error.Frame.Line),
+			},
+			newFmtItem(cfg.Formatting.Types.Redacted),
+			newFmtItem(cfg.Formatting.Types.Ellipsis),
+		},
+		[4]fmtLevel{
+			logf.LevelDebug: newFmtLevel(&cfg.Formatting, func(l *formatting.Levels) formatting.Item { return l.Debug }),
+			logf.LevelInfo:  newFmtLevel(&cfg.Formatting, func(l *formatting.Levels) formatting.Item { return l.Info }),
+			logf.LevelWarn:  newFmtLevel(&cfg.Formatting, func(l *formatting.Levels) formatting.Item { return l.Warning }),
+			logf.LevelError: newFmtLevel(&cfg.Formatting, func(l *formatting.Levels) formatting.Item { return l.Error }),
 		},
+		newFieldRules(cfg.Formatting.Fields),
 		cfg.Settings,
+		cfg.Formatting.Level,
+		false,
+		nil,
 	}
 
 	if theme.fmt.Key.separator.text == "" {
 		theme.fmt.Key.separator.text = "."
 	}
 
-	if theme.fmt.Logger.separator.text == "" {
-		theme.fmt.Logger.separator.text = "."
+	for i := range theme.levels {
+		level := &theme.levels[i]
+		if level.Line != theme.levels[0].Line || level.Field != theme.levels[0].Field {
+			theme.levelDependentFields = true
+		}
+	}
+
+	if theme.fmt.Gutter.text == "" {
+		theme.fmt.Gutter.text = "  | "
+	}
+
+	if theme.fmt.Frame.separator.text == "" {
+		theme.fmt.Frame.separator.text = " "
+	}
+
+	if theme.fmt.Frame.Line.outer.prefix == "" {
+		theme.fmt.Frame.Line.outer.prefix = ":"
+	}
+
+	if theme.fmt.Redacted.text == "" {
+		theme.fmt.Redacted.text = "***"
+	}
+
+	if theme.fmt.Ellipsis.text == "" {
+		theme.fmt.Ellipsis.text = "…"
 	}
 
 	return theme
@@ -462,6 +730,25 @@ func newItem(it themecfg.Item) (item, bool) {
 	}
 }
 
+func newLevelItem(cfg formatting.Level, level logf.Level) fmtItem {
+	item, _ := cfg.For(standardLevelNames[level], int(level))
+
+	return newFmtItem(item)
+}
+
+func newFmtLevel(cfg *themecfg.Formatting, override func(*formatting.Levels) formatting.Item) fmtLevel {
+	newItem := func(it *formatting.LevelItem) fmtItem {
+		return newFmtItem(it.Item.UpdatedBy(override(&it.Levels)))
+	}
+
+	return fmtLevel{
+		newItem(&cfg.Line),
+		newItem(&cfg.Logger),
+		newItem(&cfg.Message),
+		newItem(&cfg.Field),
+	}
+}
+
 func newFmtItem(it formatting.Item) fmtItem {
 	return fmtItem{
 		outer:     newFormat(it.Outer),
@@ -486,6 +773,8 @@ func newStylePatch(s themecfg.Style) stylePatch {
 		s.Modes.Sets(),
 		len(s.Modes) != 0,
 		len(s.Modes) == 0 && s.Background.IsZero() && s.Foreground.IsZero(),
+		s.Background,
+		s.Foreground,
 	}
 }
 
@@ -500,6 +789,112 @@ func newStyledText(f formatting.StyledText) styledText {
 	return result
 }
 
+// customLevelItems returns formatting of level items for levels that are styled in the theme
+// but cannot be represented by formatting of the standard levels.
+// Those are levels with numeric keys other than the standard ones and levels with the given names.
+func (t *Theme) customLevelItems(names LevelNames) map[logf.Level]*fmtItem {
+	var items map[logf.Level]*fmtItem
+
+	add := func(level logf.Level, name string) {
+		cfg, ok := t.levelFormatting.For(name, int(level))
+		if !ok {
+			return
+		}
+
+		if items == nil {
+			items = make(map[logf.Level]*fmtItem)
+		}
+
+		item := newFmtItem(cfg)
+		items[level] = &item
+	}
+
+	for _, value := range t.levelFormatting.Values() {
+		if level := logf.Level(value); level != levelIndex(level) {
+			add(level, "")
+		}
+	}
+
+	for level, name := range names {
+		add(level, name)
+	}
+
+	return items
+}
+
+// downgraded returns a copy of the theme with colors of all styles converted to the closest ones
+// supported with the given color depth, so that they are not converted each time they are used.
+// Formatting of custom levels is built separately and has to be converted the same way, see [fmtItem.downgrade].
+func (t *Theme) downgraded(depth colorDepth) *Theme {
+	if depth == colorDepthTrueColor {
+		return t
+	}
+
+	dt := *t
+
+	for _, item := range []*fmtItem{
+		&dt.fmt.Timestamp, &dt.fmt.Key, &dt.fmt.Caller, &dt.fmt.Gutter, &dt.fmt.Array, &dt.fmt.Object,
+		&dt.fmt.String, &dt.fmt.Number, &dt.fmt.Boolean, &dt.fmt.Time, &dt.fmt.Duration, &dt.fmt.Null,
+		&dt.fmt.Error, &dt.fmt.Frame.fmtItem, &dt.fmt.Frame.Function, &dt.fmt.Frame.File, &dt.fmt.Frame.Line,
+		&dt.fmt.Redacted, &dt.fmt.Ellipsis,
+	} {
+		item.downgrade(depth)
+	}
+
+	for i := range dt.fmt.Level {
+		dt.fmt.Level[i].downgrade(depth)
+	}
+
+	dt.fmt.Quotes = dt.fmt.Quotes.downgraded(depth)
+	dt.fmt.Special = dt.fmt.Special.downgraded(depth)
+
+	for i := range dt.levels {
+		level := &dt.levels[i]
+		level.Line.downgrade(depth)
+		level.Logger.downgrade(depth)
+		level.Message.downgrade(depth)
+		level.Field.downgrade(depth)
+	}
+
+	dt.fields.items = slices.Clone(t.fields.items)
+	for i := range dt.fields.items {
+		dt.fields.items[i].key.downgrade(depth)
+		dt.fields.items[i].value.downgrade(depth)
+	}
+
+	return &dt
+}
+
+// downgrade converts colors of the item's styles, see [Theme.downgraded].
+func (i *fmtItem) downgrade(depth colorDepth) {
+	i.outer.downgrade(depth)
+	i.inner.downgrade(depth)
+	i.separator.style = i.separator.style.downgraded(depth)
+}
+
+// downgrade converts colors of the format's style, see [Theme.downgraded].
+func (f *format) downgrade(depth colorDepth) {
+	f.style = f.style.downgraded(depth)
+}
+
+// levelIndex returns index of the given level in per-level formatting tables.
+func levelIndex(level logf.Level) logf.Level {
+	// assert that logf.LevelDebug value is greater of equal than logf.LevelError value
+	_ = [logf.LevelDebug - logf.LevelError]struct{}{}
+
+	return min(max(level, logf.LevelError), logf.LevelDebug)
+}
+
+// ---
+
+// standardLevelNames are names used to look up formatting of the standard levels in the theme.
+var standardLevelNames = [4]string{
+	logf.LevelError: "error",
+	logf.LevelWarn:  "warning",
+	logf.LevelInfo:  "info",
+	logf.LevelDebug: "debug",
+}
+
 // ---
 
 //go:embed assets/theme/*.yml
@@ -2,7 +2,13 @@ package logftxt_test
 
 import (
 	"bytes"
+	"errors"
+	"io/fs"
+	"strings"
 	"testing"
+	"testing/fstest"
+
+	"github.com/ssgreg/logf"
 
 	"github.com/pamburus/go-tst/tst"
 	"github.com/pamburus/logftxt"
@@ -33,6 +39,266 @@ func TestTheme(tt *testing.T) {
 
 		t.Run("Invalid", func(t tst.Test) {
 			t.Expect(logftxt.ReadTheme(bytes.NewBufferString("asdasdh"))).ToFail()
+			t.Expect(logftxt.ReadTheme(bytes.NewBufferString(
+				"theme: {version: '1.0', items: [message], settings: {multiline: aaa}}",
+			))).ToFail()
+			t.Expect(logftxt.ReadTheme(bytes.NewBufferString(
+				"theme: {version: '1.0', items: [message], formatting: {fields: [{match: {globs: ['[']}}]}}",
+			))).ToFail()
+			t.Expect(logftxt.ReadTheme(bytes.NewBufferString(
+				"theme: {version: '1.0', items: [message], formatting: {fields: [{value: {prefix: '<'}}]}}",
+			))).ToFail()
+		})
+	})
+
+	t.Run("Extends", func(t tst.Test) {
+		files := mapFS{fstest.MapFS{
+			"base.yml": {Data: []byte(strings.Join([]string{
+				"theme:",
+				"  version: '1.0'",
+				"  items: [level, message, fields]",
+				"  formatting:",
+				"    level: {all: {outer: {prefix: '<', suffix: '>'}}, info: {text: I}}",
+				"    field: {separator: {text: '='}}",
+				"    fields: [{match: {names: [a]}, value: {prefix: '(', suffix: ')'}}]",
+			}, "\n"))},
+			"child.yml": {Data: []byte(strings.Join([]string{
+				"theme:",
+				"  version: '1.0'",
+				"  extends: base.yml",
+				"  formatting:",
+				"    level: {info: {outer: {prefix: '{'}}}",
+				"    fields: [{match: {names: [a]}, value: {prefix: '['}}]",
+			}, "\n"))},
+			"grandchild.yml": {Data: []byte("theme: {version: '1.0', extends: child.yml, items: [message, level]}")},
+			"cycle-a.yml":    {Data: []byte("theme: {version: '1.0', extends: cycle-b.yml}")},
+			"cycle-b.yml":    {Data: []byte("theme: {version: '1.0', extends: cycle-a.yml}")},
+			"orphan.yml":     {Data: []byte("theme: {version: '1.0', extends: missing.yml}")},
+			"incomplete.yml": {Data: []byte("theme: {version: '1.0', formatting: {level: {info: {text: I}}}}")},
+		}}
+
+		encode := func(theme *logftxt.Theme) string {
+			buf := logf.NewBuffer()
+			enc := logftxt.NewEncoder(&logftxt.Config{}, logftxt.ColorNever, theme)
+			t.Expect(enc.Encode(buf, logf.Entry{Level: logf.LevelInfo, Text: "msg", Fields: []logf.Field{logf.Int("a", 1)}})).ToSucceed()
+
+			return buf.String()
+		}
+
+		t.Run("File", func(t tst.Test) {
+			theme, err := logftxt.LoadTheme("child.yml", logftxt.WithFS(files))
+			t.Expect(err).ToNot(tst.HaveOccurred())
+			t.Expect(encode(theme)).ToEqual("{I> msg a=[1\n")
+		})
+
+		t.Run("Chain", func(t tst.Test) {
+			theme, err := logftxt.NewThemeRef("grandchild.yml", logftxt.WithFS(files)).Load()
+			t.Expect(err).ToNot(tst.HaveOccurred())
+			t.Expect(encode(theme)).ToEqual("msg {I>\n")
+		})
+
+		t.Run("BuiltIn", func(t tst.Test) {
+			theme, err := logftxt.ReadTheme(bytes.NewBufferString(
+				"theme: {version: '1.0', extends: '@default', formatting: {level: {info: {text: NFO}}}}",
+			))
+			t.Expect(err).ToNot(tst.HaveOccurred())
+			t.Expect(encode(theme)).ToEqual(strings.Replace(encode(logftxt.DefaultTheme()), "INF", "NFO", 1))
+		})
+
+		t.Run("Cycle", func(t tst.Test) {
+			_, err := logftxt.LoadTheme("cycle-a.yml", logftxt.WithFS(files))
+			t.Expect(err).To(tst.HaveOccurred())
+
+			var cycle logftxt.ThemeCycleError
+			t.Expect(errors.As(err, &cycle)).ToBeTrue()
+			t.Expect(cycle.Chain).ToEqual([]string{"cycle-a.yml", "cycle-b.yml", "cycle-a.yml"})
+		})
+
+		t.Run("MissingBase", func(t tst.Test) {
+			t.Expect(logftxt.LoadTheme("orphan.yml", logftxt.WithFS(files))).ToFail()
+			t.Expect(logftxt.ReadTheme(bytes.NewBufferString("theme: {version: '1.0', extends: '@non-existent'}"))).ToFail()
+		})
+
+		t.Run("Incomplete", func(t tst.Test) {
+			t.Expect(logftxt.LoadTheme("incomplete.yml", logftxt.WithFS(files))).ToFail()
+		})
+	})
+
+	t.Run("Palette", func(t tst.Test) {
+		files := mapFS{fstest.MapFS{
+			"base.yml": {Data: []byte(strings.Join([]string{
+				"theme:",
+				"  version: '1.0'",
+				"  items: [message]",
+				"  palette: {accent: '#ff8700', muted: 244}",
+				"  formatting:",
+				"    message: {outer: {style: {foreground: $accent}}}",
+			}, "\n"))},
+			"child.yml":   {Data: []byte("theme: {version: '1.0', extends: base.yml, palette: {accent: green}}")},
+			"unknown.yml": {Data: []byte("theme: {version: '1.0', items: [message], formatting: {message: {outer: {style: {background: $nope}}}}}")},
+		}}
+
+		encode := func(theme *logftxt.Theme, color logftxt.ColorSetting) string {
+			buf := logf.NewBuffer()
+			enc := logftxt.NewEncoder(&logftxt.Config{}, color, theme)
+			t.Expect(enc.Encode(buf, logf.Entry{Level: logf.LevelInfo, Text: "msg"})).ToSucceed()
+
+			return buf.String()
+		}
+
+		t.Run("Reference", func(t tst.Test) {
+			theme, err := logftxt.LoadTheme("base.yml", logftxt.WithFS(files))
+			t.Expect(err).ToNot(tst.HaveOccurred())
+			t.Expect(encode(theme, logftxt.ColorTrueColor)).ToEqual("\x1b[38;2;255;135;0mmsg\x1b[0m\n")
+			t.Expect(encode(theme, logftxt.Color256)).ToEqual("\x1b[38;5;208mmsg\x1b[0m\n")
+			t.Expect(encode(theme, logftxt.Color16)).ToEqual("\x1b[33mmsg\x1b[0m\n")
+		})
+
+		t.Run("Detected", func(t tst.Test) {
+			theme, err := logftxt.LoadTheme("base.yml", logftxt.WithFS(files))
+			t.Expect(err).ToNot(tst.HaveOccurred())
+
+			encode := func(color logftxt.ColorSetting, vars map[string]string) string {
+				buf := logf.NewBuffer()
+				env := logftxt.Environment(func(name string) (string, bool) {
+					value, ok := vars[name]
+
+					return value, ok
+				})
+				enc := logftxt.NewEncoder(&logftxt.Config{}, color, env, theme)
+				t.Expect(enc.Encode(buf, logf.Entry{Level: logf.LevelInfo, Text: "msg"})).ToSucceed()
+
+				return buf.String()
+			}
+
+			t.Expect(encode(logftxt.ColorAlways, map[string]string{"TERM": "xterm-256color"})).ToEqual("\x1b[38;5;208mmsg\x1b[0m\n")
+			t.Expect(encode(logftxt.ColorAlways, map[string]string{"TERM": "xterm-256color", "COLORTERM": "truecolor"})).
+				ToEqual("\x1b[38;2;255;135;0mmsg\x1b[0m\n")
+			t.Expect(encode(logftxt.ColorAlways, map[string]string{"TERM": "linux"})).ToEqual("\x1b[33mmsg\x1b[0m\n")
+			t.Expect(encode(logftxt.ColorAlways, map[string]string{"TERM": "xterm"})).ToEqual("\x1b[38;5;208mmsg\x1b[0m\n")
+			t.Expect(encode(logftxt.ColorAlways, map[string]string{"TERM": "screen"})).ToEqual("\x1b[38;5;208mmsg\x1b[0m\n")
+			t.Expect(encode(logftxt.ColorAuto, map[string]string{"LOGFTXT_COLOR": "256"})).ToEqual("\x1b[38;5;208mmsg\x1b[0m\n")
+		})
+
+		t.Run("Downgraded", func(t tst.Test) {
+			theme, err := logftxt.ReadTheme(strings.NewReader(strings.Join([]string{
+				"theme:",
+				"  version: '1.0'",
+				"  items: [level, fields]",
+				"  formatting:",
+				"    level: {trace: {text: TRC, outer: {style: {foreground: '#ff8700'}}}}",
+				"    field: {separator: {text: '='}}",
+				"    fields: [{match: {names: [a]}, value: {style: {foreground: '#ff8700'}}}]",
+			}, "\n")))
+			t.Expect(err).ToNot(tst.HaveOccurred())
+
+			buf := logf.NewBuffer()
+			enc := logftxt.NewEncoder(&logftxt.Config{}, logftxt.Color16, theme, logftxt.DefaultLevelNames())
+			t.Expect(enc.Encode(buf, logf.Entry{Level: logftxt.LevelTrace, Fields: []logf.Field{logf.Int("a", 1)}})).ToSucceed()
+			t.Expect(buf.String()).ToEqual("\x1b[33mTRC\x1b[0m a=\x1b[33m1\x1b[0m\n")
+		})
+
+		t.Run("Override", func(t tst.Test) {
+			theme, err := logftxt.LoadTheme("child.yml", logftxt.WithFS(files))
+			t.Expect(err).ToNot(tst.HaveOccurred())
+			t.Expect(encode(theme, logftxt.ColorTrueColor)).ToEqual("\x1b[32mmsg\x1b[0m\n")
+		})
+
+		t.Run("Unknown", func(t tst.Test) {
+			_, err := logftxt.LoadTheme("unknown.yml", logftxt.WithFS(files))
+			t.Expect(err).To(tst.HaveOccurred())
+			t.Expect(strings.Contains(err.Error(), `unknown palette color "nope"`)).ToBeTrue()
+		})
+	})
+
+	t.Run("Variants", func(t tst.Test) {
+		files := mapFS{fstest.MapFS{
+			"base.yml": {Data: []byte(strings.Join([]string{
+				"theme:",
+				"  version: '1.0'",
+				"  items: [message]",
+				"  palette: {accent: red}",
+				"  variants:",
+				"    dark: {palette: {accent: bright-yellow}}",
+				"    light: {palette: {accent: blue}, items: [level, message]}",
+				"  formatting:",
+				"    level: {info: {text: I}}",
+				"    message: {outer: {style: {foreground: $accent}}}",
+			}, "\n"))},
+			"child.yml": {Data: []byte(strings.Join([]string{
+				"theme:",
+				"  version: '1.0'",
+				"  extends: base.yml",
+				"  variants:",
+				"    light: {formatting: {message: {outer: {prefix: '>'}}}}",
+			}, "\n"))},
+			"invalid.yml": {Data: []byte("theme: {version: '1.0', items: [message], variants: {dim: {}}}")},
+		}}
+
+		encode := func(vars map[string]string, options ...logftxt.EncoderOption) string {
+			buf := logf.NewBuffer()
+			env := logftxt.Environment(func(name string) (string, bool) {
+				value, ok := vars[name]
+
+				return value, ok
+			})
+			options = append([]logftxt.EncoderOption{&logftxt.Config{}, logftxt.ColorAlways, env, logftxt.WithFS(files)}, options...)
+			enc := logftxt.NewEncoder(options...)
+			t.Expect(enc.Encode(buf, logf.Entry{Level: logf.LevelInfo, Text: "msg"})).ToSucceed()
+
+			return buf.String()
+		}
+
+		const (
+			plain = "\x1b[31mmsg\x1b[0m\n"
+			dark  = "\x1b[93mmsg\x1b[0m\n"
+			light = "I \x1b[34mmsg\x1b[0m\n"
+		)
+
+		theme, err := logftxt.LoadTheme("base.yml", logftxt.WithFS(files))
+		t.Expect(err).ToNot(tst.HaveOccurred())
+
+		t.Run("Option", func(t tst.Test) {
+			t.Expect(encode(nil, theme)).ToEqual(plain)
+			t.Expect(encode(nil, theme, logftxt.ThemeModeDark)).ToEqual(dark)
+			t.Expect(encode(nil, theme, logftxt.ThemeModeLight)).ToEqual(light)
+			t.Expect(encode(map[string]string{"LOGFTXT_THEME_MODE": "light"}, theme, logftxt.ThemeModeDark)).ToEqual(dark)
+		})
+
+		t.Run("Environment", func(t tst.Test) {
+			t.Expect(encode(map[string]string{"LOGFTXT_THEME_MODE": "light"}, theme)).ToEqual(light)
+			t.Expect(encode(map[string]string{"LOGFTXT_THEME_MODE": "Dark"}, theme)).ToEqual(dark)
+			t.Expect(encode(map[string]string{"COLORFGBG": "0;15"}, theme)).ToEqual(light)
+			t.Expect(encode(map[string]string{"COLORFGBG": "15;default;0"}, theme)).ToEqual(dark)
+			t.Expect(encode(map[string]string{"COLORFGBG": "garbage"}, theme)).ToEqual(plain)
+			t.Expect(encode(map[string]string{"LOGFTXT_THEME_MODE": "dark", "COLORFGBG": "0;15"}, theme)).ToEqual(dark)
+		})
+
+		t.Run("EnvironmentRef", func(t tst.Test) {
+			vars := map[string]string{"LOGFTXT_THEME": "child.yml", "LOGFTXT_THEME_MODE": "light"}
+			t.Expect(encode(vars, logftxt.ThemeFromEnvironment())).ToEqual("I \x1b[34m>msg\x1b[0m\n")
+			t.Expect(encode(vars, logftxt.ThemeFromEnvironment(), logftxt.ThemeModeDark)).ToEqual(dark)
+		})
+
+		t.Run("BuiltIn", func(t tst.Test) {
+			theme := logftxt.DefaultTheme()
+			entry := logf.Entry{Level: logf.LevelInfo, Text: "msg", Fields: []logf.Field{logf.Int("a", 1)}}
+
+			encode := func(mode logftxt.ThemeMode) string {
+				buf := logf.NewBuffer()
+				env := logftxt.Environment(func(string) (string, bool) { return "", false })
+				enc := logftxt.NewEncoder(&logftxt.Config{}, logftxt.ColorAlways, env, theme, mode)
+				t.Expect(enc.Encode(buf, entry)).ToSucceed()
+
+				return buf.String()
+			}
+
+			t.Expect(strings.Contains(encode(logftxt.ThemeModeDark), "\x1b[94m1")).ToBeTrue()
+			t.Expect(strings.Contains(encode(logftxt.ThemeModeLight), "\x1b[34m1")).ToBeTrue()
+		})
+
+		t.Run("Invalid", func(t tst.Test) {
+			t.Expect(logftxt.LoadTheme("invalid.yml", logftxt.WithFS(files))).ToFail()
 		})
 	})
 
@@ -45,3 +311,13 @@ func TestTheme(tt *testing.T) {
 		})
 	})
 }
+
+// ---
+
+type mapFS struct {
+	fstest.MapFS
+}
+
+func (f mapFS) ConfigDir() (fs.FS, error) {
+	return f.MapFS, nil
+}
@@ -2,7 +2,14 @@ package logftxt
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/ssgreg/logf"
 )
 
 // ---
@@ -27,6 +34,32 @@ func ErrorLong() ErrorEncodeFunc {
 	}
 }
 
+// ErrorTrace returns an error formatting function that appends error message followed by
+// the stack trace frames found in the error chain, each frame on its own indented line.
+//
+// Stack trace is looked up using StackTrace method returning either *runtime.Frames, []runtime.Frame
+// or a slice of program counters like the one provided by github.com/pkg/errors package.
+// The innermost stack trace in the chain is used as the most detailed one.
+//
+// Note that encoder configured with [ErrorFormatTrace] renders stack trace frames
+// styled according to the theme in a block following the main line instead.
+func ErrorTrace() ErrorEncodeFunc {
+	return func(buf []byte, err error) []byte {
+		buf = append(buf, err.Error()...)
+
+		for _, frame := range errorStackTrace(err) {
+			buf = append(buf, "\n    at "...)
+			buf = append(buf, frame.Function...)
+			buf = append(buf, ' ')
+			buf = append(buf, frame.File...)
+			buf = append(buf, ':')
+			buf = strconv.AppendInt(buf, int64(frame.Line), 10)
+		}
+
+		return buf
+	}
+}
+
 // ---
 
 // ErrorEncodeFunc is a function that encodes errors as a text.
@@ -39,3 +72,171 @@ func (f ErrorEncodeFunc) toEncoderOptions(o *encoderOptions) {
 func (f ErrorEncodeFunc) toAppenderOptions(o *appenderOptions) {
 	o.encodeError = f
 }
+
+// ---
+
+// errorStackTrace returns stack trace frames of the innermost error in the chain that has a stack trace.
+func errorStackTrace(err error) []runtime.Frame {
+	var (
+		frames *runtime.Frames
+		list   []runtime.Frame
+	)
+
+	for ; err != nil; err = errors.Unwrap(err) {
+		if st, ok := err.(interface{ StackTrace() []runtime.Frame }); ok {
+			if f := st.StackTrace(); len(f) != 0 {
+				frames, list = nil, f
+			}
+		} else if f := stackTraceFrames(err); f != nil {
+			frames, list = f, nil
+		}
+	}
+
+	if frames == nil {
+		return list
+	}
+
+	var result []runtime.Frame
+
+	for {
+		frame, more := frames.Next()
+		if frame.PC != 0 {
+			result = append(result, frame)
+		}
+
+		if !more {
+			return result
+		}
+	}
+}
+
+func stackTraceFrames(err error) *runtime.Frames {
+	if st, ok := err.(interface{ StackTrace() *runtime.Frames }); ok {
+		return st.StackTrace()
+	}
+
+	// Packages like github.com/pkg/errors define their own types for stack traces,
+	// so reflection is used to avoid dependencies on them.
+	method := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !method.IsValid() {
+		return nil
+	}
+
+	mt := method.Type()
+	if mt.NumIn() != 0 || mt.NumOut() != 1 || mt.Out(0).Kind() != reflect.Slice || mt.Out(0).Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+
+	trace := method.Call(nil)[0]
+	if trace.Len() == 0 {
+		return nil
+	}
+
+	pcs := make([]uintptr, trace.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(trace.Index(i).Uint())
+	}
+
+	return runtime.CallersFrames(pcs)
+}
+
+// ---
+
+// errorChain is a chain of wrapped errors where each element is either
+// an errorLink containing own message of a wrapping error or
+// a nested errorChain for each of errors wrapped together.
+type errorChain []any
+
+func newErrorChain(err error, maxDepth int) errorChain {
+	var chain errorChain
+
+	for depth := 0; err != nil; depth++ {
+		if depth >= maxDepth {
+			return append(chain, errorLink(err.Error()))
+		}
+
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			next := u.Unwrap()
+			if next == nil {
+				return append(chain, errorLink(err.Error()))
+			}
+
+			chain = chain.withLink(ownErrorMessage(err.Error(), next.Error()))
+			err = next
+		case interface{ Unwrap() []error }:
+			var messages []string
+
+			for _, next := range u.Unwrap() {
+				if next != nil {
+					messages = append(messages, next.Error())
+				}
+			}
+
+			if err.Error() != strings.Join(messages, "\n") {
+				chain = chain.withLink(err.Error())
+			}
+
+			for _, next := range u.Unwrap() {
+				if next != nil {
+					chain = append(chain, newErrorChain(next, maxDepth-depth-1).compact())
+				}
+			}
+
+			return chain
+		default:
+			return append(chain, errorLink(err.Error()))
+		}
+	}
+
+	return chain
+}
+
+func (c errorChain) EncodeLogfArray(enc logf.TypeEncoder) error {
+	for _, item := range c {
+		switch item := item.(type) {
+		case errorChain:
+			enc.EncodeTypeArray(item)
+		default:
+			enc.EncodeTypeAny(item)
+		}
+	}
+
+	return nil
+}
+
+func (c errorChain) withLink(message string) errorChain {
+	if message == "" {
+		return c
+	}
+
+	return append(c, errorLink(message))
+}
+
+// compact returns the only element of c if there is one.
+func (c errorChain) compact() any {
+	if len(c) == 1 {
+		return c[0]
+	}
+
+	return c
+}
+
+// ownErrorMessage returns the part of the message that is not a part of the wrapped error message.
+func ownErrorMessage(message, wrapped string) string {
+	own, ok := strings.CutSuffix(message, wrapped)
+	if !ok {
+		return message
+	}
+
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(own), ":"))
+}
+
+// ---
+
+// errorLink is an error with a message of a single link in an error chain.
+type errorLink string
+
+func (l errorLink) Error() string {
+	return string(l)
+}
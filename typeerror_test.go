@@ -1,8 +1,11 @@
 package logftxt_test
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"runtime"
+	"strings"
 	"testing"
 
 	"github.com/pamburus/go-tst/tst"
@@ -25,6 +28,95 @@ func TestErrorShort(tt *testing.T) {
 	)
 }
 
+func TestErrorTrace(tt *testing.T) {
+	t := tst.New(tt)
+
+	t.Run("NoTrace", func(t tst.Test) {
+		t.Expect(string(logftxt.ErrorTrace()(nil, errors.New("oops")))).ToEqual("oops")
+	})
+
+	t.Run("Innermost", func(t tst.Test) {
+		inner := newMockTracedError("inner", nil)
+		outer := newMockTracedError("outer", fmt.Errorf("wrapped: %w", inner))
+
+		t.Expect(string(logftxt.ErrorTrace()(nil, outer))).ToEqual("outer" + mockTrace(inner, "\n    at %s %s:%d"))
+	})
+
+	t.Run("Frames", func(t tst.Test) {
+		err := mockFramesError{newMockTracedError("frames", nil)}
+
+		t.Expect(string(logftxt.ErrorTrace()(nil, err))).ToEqual("frames" + mockTrace(err.mockTracedError, "\n    at %s %s:%d"))
+	})
+}
+
+// ---
+
+func newMockTracedError(text string, cause error) mockTracedError {
+	pcs := make([]uintptr, 2)
+	n := runtime.Callers(2, pcs)
+
+	frames := make([]mockFrame, n)
+	for i := range frames {
+		frames[i] = mockFrame(pcs[i])
+	}
+
+	return mockTracedError{text, frames, cause}
+}
+
+type mockTracedError struct {
+	text   string
+	frames []mockFrame
+	cause  error
+}
+
+type mockFrame uintptr
+
+func (e mockTracedError) Error() string {
+	return e.text
+}
+
+func (e mockTracedError) Unwrap() error {
+	return e.cause
+}
+
+func (e mockTracedError) StackTrace() []mockFrame {
+	return e.frames
+}
+
+func (e mockTracedError) pcs() []uintptr {
+	pcs := make([]uintptr, len(e.frames))
+	for i, frame := range e.frames {
+		pcs[i] = uintptr(frame)
+	}
+
+	return pcs
+}
+
+func mockTrace(err mockTracedError, format string) string {
+	var sb strings.Builder
+
+	frames := runtime.CallersFrames(err.pcs())
+
+	for {
+		frame, more := frames.Next()
+		sb.WriteString(fmt.Sprintf(format, frame.Function, frame.File, frame.Line))
+
+		if !more {
+			return sb.String()
+		}
+	}
+}
+
+// ---
+
+type mockFramesError struct {
+	mockTracedError
+}
+
+func (e mockFramesError) StackTrace() *runtime.Frames {
+	return runtime.CallersFrames(e.pcs())
+}
+
 // ---
 
 type mockError struct{}
@@ -0,0 +1,242 @@
+package logftxt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/pamburus/logftxt/internal/pkg/themecfg"
+	"github.com/pamburus/logftxt/internal/pkg/yamlcheck"
+)
+
+// ValidateTheme reads theme configuration from the given reader and checks it thoroughly.
+//
+// Unlike [ReadTheme], it reports all found problems with their positions in the file,
+// reports unknown keys that are otherwise ignored and warns about sections that have no effect.
+// If the theme extends another theme, the base theme is resolved the same way as [ThemeRef] does it.
+// The returned error is not nil only if the theme could not be read.
+func ValidateTheme(reader io.Reader, opts ...domainOption) ([]ValidationIssue, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read theme: %w", err)
+	}
+
+	fileSystem := defaultDomain().with(opts).fs
+
+	src, err := themecfg.Parse(bytes.NewReader(data))
+	if err != nil {
+		return newValidationIssues([]yamlcheck.Issue{yamlcheck.FromError(err)}), nil
+	}
+
+	var issues []yamlcheck.Issue
+
+	var palette themecfg.Palette
+
+	if src.Extends != "" {
+		base, err := loadThemeConfig(src.Extends, fileSystem, nil, nil, themecfg.ModeDefault)
+		if err != nil {
+			line, column := src.Locate("theme", "extends")
+			issues = append(issues, yamlcheck.Issue{Line: line, Column: column, Path: "theme.extends", Message: err.Error()})
+		} else {
+			palette = base.Palette
+		}
+	}
+
+	// The theme is built the same way as readTheme does it, but from the source that has already been parsed.
+	var cfg *themecfg.Theme
+
+	_, loadErr := newThemeWithVariants(func(mode themecfg.Mode) (*themecfg.Theme, error) {
+		result, err := decodeThemeConfig(src, fileSystem, nil, nil, mode)
+		if mode == themecfg.ModeDefault {
+			cfg = result
+		}
+
+		return result, err
+	})
+
+	var items []themecfg.Item
+	if loadErr == nil {
+		items = cfg.Items
+	}
+
+	issues = append(issues, src.Check(palette, items)...)
+
+	if loadErr != nil && !hasErrors(issues) {
+		line, column := src.Locate("theme")
+		issues = append(issues, yamlcheck.Issue{Line: line, Column: column, Path: "theme", Message: loadErr.Error()})
+	}
+
+	yamlcheck.Sort(issues)
+
+	return newValidationIssues(issues), nil
+}
+
+// ValidateConfig reads configuration from the given reader and checks it thoroughly.
+//
+// Unlike [ReadConfig], it reports all found problems with their positions in the file,
+// reports unknown keys that are otherwise ignored, checks that the referenced theme can be loaded
+// and warns about settings that have no effect.
+// The returned error is not nil only if the configuration could not be read.
+func ValidateConfig(reader io.Reader, opts ...domainOption) ([]ValidationIssue, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	fileSystem := defaultDomain().with(opts).fs
+
+	var node yaml.Node
+
+	err = yaml.Unmarshal(data, &node)
+	if err != nil {
+		return newValidationIssues([]yamlcheck.Issue{yamlcheck.FromError(err)}), nil
+	}
+
+	if node.Kind == 0 {
+		return nil, nil
+	}
+
+	issues := yamlcheck.Check(&node, reflect.TypeFor[Config]())
+	if hasErrors(issues) {
+		return newValidationIssues(issues), nil
+	}
+
+	var cfg Config
+
+	err = node.Decode(&cfg)
+	if err != nil {
+		return newValidationIssues([]yamlcheck.Issue{yamlcheck.FromError(err)}), nil
+	}
+
+	if theme := yamlcheck.Lookup(&node, "theme"); theme != nil && cfg.Theme.name != "" {
+		_, err := loadTheme(cfg.Theme.name, fileSystem)
+		if err != nil {
+			issues = append(issues, yamlcheck.At(theme, "theme", err.Error()))
+		}
+	}
+
+	for _, setting := range dependentSettings {
+		if value := yamlcheck.Lookup(&node, setting.path...); value != nil && setting.unused(&cfg) {
+			issues = append(issues, yamlcheck.Warning(value, strings.Join(setting.path, "."), "setting is not used because "+setting.reason))
+		}
+	}
+
+	yamlcheck.Sort(issues)
+
+	return newValidationIssues(issues), nil
+}
+
+// ---
+
+// dependentSettings lists configuration settings that have no effect unless other settings have certain values.
+var dependentSettings = []struct {
+	path   []string
+	unused func(*Config) bool
+	reason string
+}{
+	{
+		[]string{"caller", "url"},
+		func(c *Config) bool { return c.Caller.Format != CallerFormatHyperlink },
+		"`caller.format` is not `hyperlink`",
+	},
+	{
+		[]string{"values", "duration", "precision"},
+		func(c *Config) bool { return c.Values.Duration.Format == DurationFormatDynamic },
+		"`values.duration.format` is `dynamic`",
+	},
+	{
+		[]string{"values", "error", "max-depth"},
+		func(c *Config) bool { return c.Values.Error.Format != ErrorFormatChain },
+		"`values.error.format` is not `chain`",
+	},
+	{
+		[]string{"layout", "line-width"},
+		func(c *Config) bool {
+			return c.Layout.Overflow == LineOverflowDefault || c.Layout.Overflow == LineOverflowNone
+		},
+		"`layout.overflow` is not `truncate` or `wrap`",
+	},
+}
+
+// ---
+
+// ValidationIssue is a problem found by [ValidateTheme] or [ValidateConfig].
+// Line and Column are 1-based, they are zero if the position is unknown.
+// Path is a dot-separated path of keys to the value having the problem, if known.
+type ValidationIssue struct {
+	Line     int
+	Column   int
+	Path     string
+	Severity ValidationSeverity
+	Message  string
+}
+
+// String returns the issue in `line:column: severity: message` form.
+func (i ValidationIssue) String() string {
+	switch {
+	case i.Line == 0:
+		return fmt.Sprintf("%s: %s", i.Severity, i.Message)
+	case i.Column == 0:
+		return fmt.Sprintf("%d: %s: %s", i.Line, i.Severity, i.Message)
+	}
+
+	return fmt.Sprintf("%d:%d: %s: %s", i.Line, i.Column, i.Severity, i.Message)
+}
+
+// ---
+
+// Valid values for ValidationSeverity.
+const (
+	SeverityError ValidationSeverity = iota
+	SeverityWarning
+)
+
+// ValidationSeverity tells if a [ValidationIssue] makes the file unusable or just has no effect.
+type ValidationSeverity int
+
+// String returns the severity name.
+func (s ValidationSeverity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+// ---
+
+func newValidationIssues(issues []yamlcheck.Issue) []ValidationIssue {
+	if len(issues) == 0 {
+		return nil
+	}
+
+	result := make([]ValidationIssue, len(issues))
+
+	for i, issue := range issues {
+		severity := SeverityError
+		if issue.Warning {
+			severity = SeverityWarning
+		}
+
+		result[i] = ValidationIssue{issue.Line, issue.Column, issue.Path, severity, issue.Message}
+	}
+
+	return result
+}
+
+func hasErrors(issues []yamlcheck.Issue) bool {
+	for _, issue := range issues {
+		if !issue.Warning {
+			return true
+		}
+	}
+
+	return false
+}
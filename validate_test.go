@@ -0,0 +1,182 @@
+package logftxt_test
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pamburus/go-tst/tst"
+	"github.com/pamburus/logftxt"
+)
+
+func TestValidateTheme(tt *testing.T) {
+	t := tst.New(tt)
+
+	validate := func(lines ...string) []string {
+		issues, err := logftxt.ValidateTheme(strings.NewReader(strings.Join(lines, "\n")))
+		t.Expect(err).ToNot(tst.HaveOccurred())
+
+		result := make([]string, len(issues))
+		for i, issue := range issues {
+			result[i] = issue.String()
+		}
+
+		return result
+	}
+
+	t.Run("BuiltIn", func(t tst.Test) {
+		themes, err := logftxt.ListBuiltInThemes()
+		t.Expect(err).ToNot(tst.HaveOccurred())
+
+		for _, theme := range themes {
+			issues, err := logftxt.ValidateTheme(strings.NewReader("theme: {version: '1.0', extends: '@" + theme + "'}"))
+			t.Expect(err).ToNot(tst.HaveOccurred())
+			t.Expect(issues).To(tst.BeZero())
+		}
+	})
+
+	t.Run("UnknownKeys", func(t tst.Test) {
+		t.Expect(validate(
+			"theme:",
+			"  version: '1.0'",
+			"  items: [message]",
+			"  formatting:",
+			"    message:",
+			"      outer:",
+			"        style:",
+			"          foregound: red",
+			"  setings: {}",
+		)).ToEqual([]string{
+			`8:11: error: unknown key "foregound", did you mean "foreground"?`,
+			`9:3: error: unknown key "setings", did you mean "settings"?`,
+		})
+	})
+
+	t.Run("InvalidValues", func(t tst.Test) {
+		t.Expect(validate(
+			"theme:",
+			"  version: '1.0'",
+			"  items: [message, bogus]",
+			"  variants: {dim: {}}",
+			"  formatting:",
+			"    message: {outer: {style: {foreground: reddish, background: $accent}}}",
+			"    fields: [{match: {globs: ['[']}}]",
+		)).ToEqual([]string{
+			`3:20: error: invalid value "bogus"`,
+			`4:14: error: invalid value "dim"`,
+			`6:43: error: invalid color text "reddish"`,
+			`6:64: error: unknown palette color "accent"`,
+			"7:22: error: `globs.0` is invalid: syntax error in pattern",
+		})
+	})
+
+	t.Run("Incomplete", func(t tst.Test) {
+		t.Expect(validate(
+			"theme:",
+			"  version: '1.0'",
+		)).ToEqual([]string{
+			"2:3: error: failed to read theme: theme is invalid: `items` should not be empty",
+		})
+	})
+
+	t.Run("Unused", func(t tst.Test) {
+		t.Expect(validate(
+			"theme:",
+			"  version: '1.0'",
+			"  extends: '@default'",
+			"  items: [message]",
+			"  palette: {accent: red, spare: blue}",
+			"  formatting:",
+			"    message: {outer: {style: {foreground: $accent}}}",
+			"    caller: {outer: {prefix: '@'}}",
+		)).ToEqual([]string{
+			`5:26: warning: palette color "spare" is not used`,
+			"8:5: warning: section is not used because \"caller\" is not in `items`",
+		})
+	})
+
+	t.Run("Extends", func(t tst.Test) {
+		files := mapFS{fstest.MapFS{
+			"base.yml": {Data: []byte("theme: {version: '1.0', items: [message], palette: {accent: red}}")},
+		}}
+
+		issues, err := logftxt.ValidateTheme(strings.NewReader(strings.Join([]string{
+			"theme:",
+			"  version: '1.0'",
+			"  extends: base.yml",
+			"  palette: {accent: blue}",
+			"  formatting: {message: {outer: {style: {foreground: $accent}}}}",
+		}, "\n")), logftxt.WithFS(files))
+		t.Expect(err).ToNot(tst.HaveOccurred())
+		t.Expect(issues).To(tst.BeZero())
+
+		t.Expect(validate(
+			"theme:",
+			"  version: '1.0'",
+			"  extends: '@missing'",
+		)).ToEqual([]string{
+			`3:12: error: built-in theme "missing" not found`,
+		})
+	})
+
+	t.Run("Syntax", func(t tst.Test) {
+		t.Expect(validate("theme: [")).ToEqual([]string{
+			"1: error: failed to parse theme: yaml: did not find expected node content",
+		})
+	})
+}
+
+func TestValidateConfig(tt *testing.T) {
+	t := tst.New(tt)
+
+	validate := func(lines ...string) []logftxt.ValidationIssue {
+		issues, err := logftxt.ValidateConfig(strings.NewReader(strings.Join(lines, "\n")))
+		t.Expect(err).ToNot(tst.HaveOccurred())
+
+		return issues
+	}
+
+	t.Run("Default", func(t tst.Test) {
+		t.Expect(validate("theme: '@fancy'", "caller: {format: hyperlink, url: 'vscode://file{{.File}}'}")).To(tst.BeZero())
+	})
+
+	t.Run("Problems", func(t tst.Test) {
+		t.Expect(validate(
+			"values:",
+			"  duration:",
+			"    format: hmss",
+			"    precison: 3",
+		)).ToEqual([]logftxt.ValidationIssue{
+			{Line: 3, Column: 13, Path: "values.duration.format", Severity: logftxt.SeverityError, Message: `unknown duration format "hmss"`},
+			{Line: 4, Column: 5, Path: "values.duration.precison", Severity: logftxt.SeverityError, Message: `unknown key "precison", did you mean "precision"?`},
+		})
+	})
+
+	t.Run("UnknownKeys", func(t tst.Test) {
+		t.Expect(validate("them: '@fancy'", "layout: {line-widht: 80}")).ToEqual([]logftxt.ValidationIssue{
+			{Line: 1, Column: 1, Path: "them", Severity: logftxt.SeverityError, Message: `unknown key "them", did you mean "theme"?`},
+			{Line: 2, Column: 10, Path: "layout.line-widht", Severity: logftxt.SeverityError, Message: `unknown key "line-widht", did you mean "line-width"?`},
+		})
+	})
+
+	t.Run("Unused", func(t tst.Test) {
+		t.Expect(validate(
+			"values:",
+			"  duration: {format: dynamic, precision: 3}",
+			"  error: {format: long, max-depth: 4}",
+			"layout: {line-width: 80}",
+		)).ToEqual([]logftxt.ValidationIssue{
+			{Line: 2, Column: 42, Path: "values.duration.precision", Severity: logftxt.SeverityWarning, Message: "setting is not used because `values.duration.format` is `dynamic`"},
+			{Line: 3, Column: 36, Path: "values.error.max-depth", Severity: logftxt.SeverityWarning, Message: "setting is not used because `values.error.format` is not `chain`"},
+			{Line: 4, Column: 22, Path: "layout.line-width", Severity: logftxt.SeverityWarning, Message: "setting is not used because `layout.overflow` is not `truncate` or `wrap`"},
+		})
+		t.Expect(validate("values: {error: {format: chain, max-depth: 4}}", "layout: {line-width: 80, overflow: wrap}")).To(tst.BeZero())
+	})
+
+	t.Run("Theme", func(t tst.Test) {
+		t.Expect(validate("theme: '@missing'", "caller: {url: x}")).ToEqual([]logftxt.ValidationIssue{
+			{Line: 1, Column: 8, Path: "theme", Severity: logftxt.SeverityError, Message: `built-in theme "missing" not found`},
+			{Line: 2, Column: 15, Path: "caller.url", Severity: logftxt.SeverityWarning, Message: "setting is not used because `caller.format` is not `hyperlink`"},
+		})
+	})
+}
@@ -0,0 +1,277 @@
+package logftxt
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ssgreg/logf"
+)
+
+// WatchConfig requests to check configuration and theme files for changes with the given interval
+// and to reload them without restarting the process.
+//
+// Files are checked in background at most once per interval while log messages are being encoded,
+// so encoding does not wait for the check and there is no activity while nothing is logged.
+// Only files opened through the [FS] to load configuration and theme are watched, built-in themes never change.
+// This includes file systems given with [WithFS] to [ThemeRef] and other options used by the encoder.
+// If a changed file cannot be loaded, warnings are logged before the next message the same way as it is done at startup,
+// and the previous configuration and theme stay in use until the file is fixed.
+type WatchConfig time.Duration
+
+func (v WatchConfig) toEncoderOptions(o *encoderOptions) {
+	o.watchInterval = time.Duration(v)
+}
+
+func (v WatchConfig) toAppenderOptions(o *appenderOptions) {
+	o.watchInterval = time.Duration(v)
+}
+
+// ---
+
+func newWatchingEncoder(options encoderOptions) *watchingEncoder {
+	return &watchingEncoder{options: options}
+}
+
+// watchingEncoder is an encoder that replaces the underlying encoder
+// when any of configuration or theme files it has loaded change.
+type watchingEncoder struct {
+	options  encoderOptions
+	current  atomic.Pointer[encoder]
+	messages atomic.Pointer[[]logf.Entry]
+	nextPoll atomic.Int64
+	polling  atomic.Bool
+	mu       sync.Mutex
+	files    []*watchedFile
+}
+
+func (w *watchingEncoder) Encode(buf *logf.Buffer, entry logf.Entry) error {
+	if w.current.Load() == nil {
+		w.start()
+	} else if now := time.Now().UnixNano(); now >= w.nextPoll.Load() && w.polling.CompareAndSwap(false, true) {
+		w.nextPoll.Store(now + int64(w.options.watchInterval))
+
+		go w.poll()
+	}
+
+	// Messages are taken before the encoder, so that they are reported by the encoder they were produced with.
+	messages := w.messages.Swap(nil)
+	current := w.current.Load()
+
+	if messages != nil {
+		current.report(buf, entry.Time, *messages)
+	}
+
+	return current.Encode(buf, entry)
+}
+
+// start loads configuration and theme for the first time, so that the first message is encoded using them.
+func (w *watchingEncoder) start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.current.Load() == nil {
+		w.nextPoll.Store(time.Now().Add(w.options.watchInterval).UnixNano())
+		w.reload()
+	}
+}
+
+// poll reloads configuration and theme if any of the watched files has changed.
+// It is run in background, so that encoding of messages does not wait for the files to be checked.
+func (w *watchingEncoder) poll() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	defer w.polling.Store(false)
+
+	if changed(w.files) {
+		w.reload()
+	}
+}
+
+// reload loads configuration and theme and replaces the current encoder unless loading has failed.
+// Warning messages are left to be reported before the next message.
+func (w *watchingEncoder) reload() {
+	files := newRecordingFS(w.options.fs)
+
+	options := w.options
+	options.fs = files
+
+	next := newEncoder(options)
+	messages, failed := next.configure()
+	next.once.Do(func() {})
+
+	w.files = files.recorded()
+
+	if !failed || w.current.Load() == nil {
+		w.current.Store(next)
+	}
+
+	if len(messages) != 0 {
+		w.messages.Store(&messages)
+	}
+}
+
+// ---
+
+func newRecordingFS(base FS) *recordingFS {
+	return &recordingFS{base, &fileRecord{}}
+}
+
+// recordingFS is an FS that remembers all files that were requested to be opened
+// together with their content at the moment they were read.
+type recordingFS struct {
+	base   FS
+	record *fileRecord
+}
+
+func (f *recordingFS) ConfigDir() (fs.FS, error) {
+	return f.base.ConfigDir()
+}
+
+func (f *recordingFS) Open(filename string) (fs.File, error) {
+	watched := &watchedFile{fs: f.base, name: filename}
+	f.record.add(watched)
+
+	file, err := f.base.Open(filename)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // error is passed as is from the underlying FS
+	}
+
+	watched.exists = true
+	watched.stat(file)
+
+	return &recordedFile{file, watched}, nil
+}
+
+// with returns an FS that opens files using the given base FS and remembers them together with the files of f.
+func (f *recordingFS) with(base FS) FS {
+	return &recordingFS{base, f.record}
+}
+
+// recorded returns all files that were requested to be opened.
+func (f *recordingFS) recorded() []*watchedFile {
+	return f.record.get()
+}
+
+// ---
+
+// fileRecord is a list of files opened through recording file systems.
+type fileRecord struct {
+	mu    sync.Mutex
+	files []*watchedFile
+}
+
+func (r *fileRecord) add(file *watchedFile) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.files = append(r.files, file)
+}
+
+func (r *fileRecord) get() []*watchedFile {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.files)
+}
+
+// ---
+
+// recordedFile is a file that saves all data read from it to the watched file.
+type recordedFile struct {
+	fs.File
+	watched *watchedFile
+}
+
+func (f *recordedFile) Read(p []byte) (int, error) {
+	n, err := f.File.Read(p)
+	f.watched.data = append(f.watched.data, p[:n]...)
+
+	return n, err //nolint:wrapcheck // error is passed as is from the file
+}
+
+// Close reads the rest of the file if the reader has stopped before the end,
+// so that the watched file holds the whole content of the file as it has been opened.
+func (f *recordedFile) Close() error {
+	_, err := io.Copy(io.Discard, struct{ io.Reader }{f})
+	if err != nil {
+		f.watched.exists = false
+	}
+
+	return f.File.Close() //nolint:wrapcheck // error is passed as is from the file
+}
+
+// ---
+
+// watchedFile holds content of a file at the moment it was loaded.
+type watchedFile struct {
+	fs      FS
+	name    string
+	data    []byte
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+// stat remembers size and modification time of the opened file.
+func (f *watchedFile) stat(file fs.File) {
+	f.size, f.modTime = -1, time.Time{}
+
+	if info, err := file.Stat(); err == nil {
+		f.size, f.modTime = info.Size(), info.ModTime()
+	}
+}
+
+// changed reports whether the file has changed since it was loaded.
+// Size and modification time are compared first, and the content is compared only if they differ
+// or the file system does not provide modification times.
+func (f *watchedFile) changed() bool {
+	file, err := f.fs.Open(f.name)
+	if err != nil {
+		return f.exists
+	}
+	defer file.Close() //nolint:errcheck // read-only
+
+	if !f.exists {
+		return true
+	}
+
+	info, err := file.Stat()
+	if err == nil && !f.modTime.IsZero() && info.Size() == f.size && info.ModTime().Equal(f.modTime) {
+		return false
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil || !bytes.Equal(data, f.data) {
+		return true
+	}
+
+	// The content is the same, so there is no need to read it again until the file is touched next time.
+	f.stat(file)
+
+	return false
+}
+
+func changed(files []*watchedFile) bool {
+	for _, file := range files {
+		if file.changed() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ---
+
+var (
+	_ logf.Encoder   = (*watchingEncoder)(nil)
+	_ FS             = (*recordingFS)(nil)
+	_ fs.File        = (*recordedFile)(nil)
+	_ EncoderOption  = WatchConfig(0)
+	_ AppenderOption = WatchConfig(0)
+)
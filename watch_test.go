@@ -0,0 +1,257 @@
+package logftxt_test
+
+import (
+	"io/fs"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/ssgreg/logf"
+
+	"github.com/pamburus/go-tst/tst"
+	"github.com/pamburus/logftxt"
+)
+
+func TestWatchConfig(tt *testing.T) {
+	t := tst.New(tt)
+
+	theme := func(prefix string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(
+			"theme: {version: '1.0', items: [message], formatting: {message: {outer: {prefix: '" + prefix + "'}}}}",
+		)}
+	}
+
+	environment := func(vars map[string]string) logftxt.Environment {
+		return func(name string) (string, bool) {
+			value, ok := vars[name]
+
+			return value, ok
+		}
+	}
+
+	encoding := func(t tst.Test, enc logf.Encoder) (func() string, func(func(string) bool) string) {
+		encode := func() string {
+			buf := logf.NewBuffer()
+			t.Expect(enc.Encode(buf, logf.Entry{Level: logf.LevelInfo, Text: "msg"})).ToSucceed()
+
+			return buf.String()
+		}
+
+		// Files are checked in background, so changes are noticed by one of the following messages.
+		wait := func(done func(string) bool) string {
+			output := encode()
+			for i := 0; i < 1000 && !done(output); i++ {
+				time.Sleep(time.Millisecond)
+
+				output = encode()
+			}
+
+			return output
+		}
+
+		return encode, wait
+	}
+
+	equal := func(expected string) func(string) bool {
+		return func(output string) bool {
+			return output == expected
+		}
+	}
+
+	files := newWatchedFS(fstest.MapFS{
+		"config.yml": {Data: []byte("theme: a.yml")},
+		"a.yml":      theme("a:"),
+		"b.yml":      theme("b:"),
+	})
+
+	env := environment(map[string]string{"LOGFTXT_CONFIG": "config.yml"})
+	enc := logftxt.NewEncoder(env, logftxt.WithFS(files), logftxt.ColorNever, logftxt.WatchConfig(time.Nanosecond))
+	encode, wait := encoding(t, enc)
+
+	t.Expect(encode()).ToEqual("a:msg\n")
+
+	t.Run("Theme", func(t tst.Test) {
+		files.set("a.yml", theme("A:"))
+		t.Expect(wait(equal("A:msg\n"))).ToEqual("A:msg\n")
+		t.Expect(encode()).ToEqual("A:msg\n")
+	})
+
+	t.Run("Config", func(t tst.Test) {
+		files.set("config.yml", &fstest.MapFile{Data: []byte("theme: b.yml")})
+		t.Expect(wait(equal("b:msg\n"))).ToEqual("b:msg\n")
+	})
+
+	t.Run("Invalid", func(t tst.Test) {
+		files.set("b.yml", &fstest.MapFile{Data: []byte("theme: {version: '1.0', items: [unknown]}")})
+
+		lines := strings.Split(wait(func(output string) bool { return output != "b:msg\n" }), "\n")
+		t.Expect(len(lines)).ToEqual(3)
+		t.Expect(strings.HasPrefix(lines[0], "b:failed to setup preferred theme so using previous defaults")).ToBeTrue()
+		t.Expect(lines[1]).ToEqual("b:msg")
+
+		t.Expect(encode()).ToEqual("b:msg\n")
+
+		files.set("b.yml", theme("B:"))
+		t.Expect(wait(equal("B:msg\n"))).ToEqual("B:msg\n")
+	})
+
+	t.Run("Removed", func(t tst.Test) {
+		files.set("config.yml", nil)
+
+		lines := strings.Split(wait(func(output string) bool { return output != "B:msg\n" }), "\n")
+		t.Expect(strings.HasPrefix(lines[0], "B:failed to load configuration file")).ToBeTrue()
+		t.Expect(lines[1]).ToEqual("B:msg")
+
+		files.set("config.yml", &fstest.MapFile{Data: []byte("theme: a.yml")})
+		t.Expect(wait(equal("A:msg\n"))).ToEqual("A:msg\n")
+	})
+
+	t.Run("Interval", func(t tst.Test) {
+		enc := logftxt.NewEncoder(env, logftxt.WithFS(files), logftxt.ColorNever, logftxt.WatchConfig(time.Hour))
+		encode, _ := encoding(t, enc)
+
+		t.Expect(encode()).ToEqual("A:msg\n")
+
+		files.set("a.yml", theme("a:"))
+		t.Expect(encode()).ToEqual("A:msg\n")
+	})
+
+	t.Run("ChangedWhileLoading", func(t tst.Test) {
+		files := newWatchedFS(fstest.MapFS{"a.yml": theme("a:")})
+		files.onOpen = func(name string) {
+			// The file changes right after it has been opened to be loaded.
+			files.files[name] = theme("b:")
+			files.onOpen = nil
+		}
+
+		env := environment(map[string]string{"LOGFTXT_THEME": "a.yml"})
+		enc := logftxt.NewEncoder(env, &logftxt.Config{}, logftxt.WithFS(files), logftxt.ColorNever, logftxt.WatchConfig(time.Nanosecond))
+		encode, wait := encoding(t, enc)
+
+		t.Expect(encode()).ToEqual("a:msg\n")
+		t.Expect(wait(equal("b:msg\n"))).ToEqual("b:msg\n")
+	})
+
+	t.Run("ModTime", func(t tst.Test) {
+		modified := func(prefix string, ts time.Time) *fstest.MapFile {
+			file := theme(prefix)
+			file.ModTime = ts
+
+			return file
+		}
+
+		ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+		files := newWatchedFS(fstest.MapFS{"a.yml": modified("a:", ts)})
+
+		env := environment(map[string]string{"LOGFTXT_THEME": "a.yml"})
+		enc := logftxt.NewEncoder(env, &logftxt.Config{}, logftxt.WithFS(files), logftxt.ColorNever, logftxt.WatchConfig(time.Nanosecond))
+		encode, wait := encoding(t, enc)
+
+		t.Expect(encode()).ToEqual("a:msg\n")
+
+		reads := files.readCount()
+		opens := files.openCount()
+		t.Expect(wait(func(string) bool { return files.openCount() > opens+2 })).ToEqual("a:msg\n")
+		t.Expect(files.readCount()).ToEqual(reads)
+
+		files.set("a.yml", modified("A:", ts.Add(time.Second)))
+		t.Expect(wait(equal("A:msg\n"))).ToEqual("A:msg\n")
+	})
+
+	t.Run("ThemeRefFS", func(t tst.Test) {
+		themes := newWatchedFS(fstest.MapFS{"a.yml": theme("a:")})
+		ref := logftxt.NewThemeRef("a.yml", logftxt.WithFS(themes))
+		enc := logftxt.NewEncoder(environment(nil), &logftxt.Config{}, ref, logftxt.WithFS(newWatchedFS(nil)),
+			logftxt.ColorNever, logftxt.WatchConfig(time.Nanosecond),
+		)
+		encode, wait := encoding(t, enc)
+
+		t.Expect(encode()).ToEqual("a:msg\n")
+
+		themes.set("a.yml", theme("A:"))
+		t.Expect(wait(equal("A:msg\n"))).ToEqual("A:msg\n")
+	})
+}
+
+// ---
+
+func newWatchedFS(files fstest.MapFS) *watchedFS {
+	if files == nil {
+		files = fstest.MapFS{}
+	}
+
+	return &watchedFS{files: files}
+}
+
+// watchedFS is a file system that can be changed while the encoder checks it in background.
+// It counts opens and reads of files and calls onOpen hook after a file is opened.
+type watchedFS struct {
+	mu     sync.Mutex
+	files  fstest.MapFS
+	onOpen func(name string)
+	opens  int
+	reads  int
+}
+
+func (f *watchedFS) ConfigDir() (fs.FS, error) {
+	return f, nil
+}
+
+func (f *watchedFS) Open(name string) (fs.File, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := f.files.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	f.opens++
+
+	if f.onOpen != nil {
+		f.onOpen(name)
+	}
+
+	return &countingFile{file, f}, nil
+}
+
+// set replaces the file with the given name, nil file removes it.
+func (f *watchedFS) set(name string, file *fstest.MapFile) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if file == nil {
+		delete(f.files, name)
+	} else {
+		f.files[name] = file
+	}
+}
+
+func (f *watchedFS) openCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.opens
+}
+
+func (f *watchedFS) readCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.reads
+}
+
+type countingFile struct {
+	fs.File
+	fs *watchedFS
+}
+
+func (f *countingFile) Read(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	f.fs.reads++
+	f.fs.mu.Unlock()
+
+	return f.File.Read(p)
+}
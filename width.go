@@ -0,0 +1,295 @@
+package logftxt
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// displayWidth returns number of terminal cells occupied by the text skipping escape sequences.
+func displayWidth(text []byte) int {
+	width := 0
+	prev := rune(0)
+
+	for i := 0; i < len(text); {
+		if text[i] == esc {
+			i += escapeSequenceLen(text[i:])
+
+			continue
+		}
+
+		r, n := utf8.DecodeRune(text[i:])
+		i += n
+		width += nextRuneWidth(prev, r)
+		prev = r
+	}
+
+	return width
+}
+
+// stringWidth returns number of terminal cells occupied by the plain text.
+func stringWidth(text string) int {
+	width := 0
+	prev := rune(0)
+
+	for _, r := range text {
+		width += nextRuneWidth(prev, r)
+		prev = r
+	}
+
+	return width
+}
+
+// truncateString returns the longest prefix of the plain text that fits into the given number of terminal cells.
+func truncateString(text string, width int) string {
+	prev := rune(0)
+
+	for i, r := range text {
+		width -= nextRuneWidth(prev, r)
+		if width < 0 {
+			return text[:i]
+		}
+
+		prev = r
+	}
+
+	return text
+}
+
+// appendFitted appends the text to b cut or padded with spaces to occupy exactly the given number of terminal cells.
+// Escape sequences are kept even if they follow the cut, so that styles and hyperlinks are closed.
+func appendFitted(b []byte, text string, width int) []byte {
+	prev := rune(0)
+	cut := false
+
+	for i := 0; i < len(text); {
+		if text[i] == esc {
+			n := escapeSequenceLen([]byte(text[i:]))
+			b = append(b, text[i:i+n]...)
+			i += n
+
+			continue
+		}
+
+		r, n := utf8.DecodeRuneInString(text[i:])
+		if rw := nextRuneWidth(prev, r); !cut && rw <= width {
+			b = append(b, text[i:i+n]...)
+			width -= rw
+		} else {
+			cut = true
+		}
+
+		i += n
+		prev = r
+	}
+
+	for range width {
+		b = append(b, ' ')
+	}
+
+	return b
+}
+
+// truncateEscapedString returns the longest prefix of the text that fits into the given number of terminal cells
+// when it is output quoted, see escapedRuneWidth.
+func truncateEscapedString(text string, width int) string {
+	prev := rune(0)
+
+	for i, r := range text {
+		width -= escapedRuneWidth(prev, r)
+		if width < 0 {
+			return text[:i]
+		}
+
+		prev = r
+	}
+
+	return text
+}
+
+// escapedRuneWidth returns number of terminal cells added by the rune following the previous one in a quoted string.
+// Quotes, backslashes and control characters are escaped, invalid UTF-8 is replaced by an escaped replacement character.
+func escapedRuneWidth(prev, r rune) int {
+	switch {
+	case r == '\t' || r == '\r' || r == '\n' || r == '\\' || r == '"':
+		return 2
+	case r < 0x20 || r == utf8.RuneError:
+		return len(`\u0000`)
+	}
+
+	return nextRuneWidth(prev, r)
+}
+
+// nextRuneWidth returns number of terminal cells added by the rune following the previous one.
+// An emoji joined to the previous one by a zero width joiner and an emoji modifier following a wide character
+// are a part of a single emoji sequence occupying the cells of its first emoji.
+func nextRuneWidth(prev, r rune) int {
+	if prev == zeroWidthJoiner || (inRanges(emojiModifierRanges, r) && runeWidth(prev) == 2) {
+		return 0
+	}
+
+	return runeWidth(r)
+}
+
+// runeWidth returns number of terminal cells occupied by the rune.
+// East Asian wide and fullwidth characters and emoji occupy two cells,
+// combining marks, zero width characters and control characters occupy no cells.
+func runeWidth(r rune) int {
+	switch {
+	case r < 0x20 || r == 0x7f:
+		return 0
+	case r < 0x300:
+		return 1
+	case inRanges(zeroWidthRanges, r):
+		return 0
+	case inRanges(wideRanges, r):
+		return 2
+	}
+
+	return 1
+}
+
+func inRanges(ranges []runeRange, r rune) bool {
+	i := sort.Search(len(ranges), func(i int) bool {
+		return ranges[i].last >= r
+	})
+
+	return i < len(ranges) && ranges[i].first <= r
+}
+
+// escapeSequenceLen returns length of an escape sequence at the beginning of the text.
+// CSI sequences like SGR and OSC sequences like hyperlinks are recognized,
+// any other escape character is treated as a sequence of a single byte.
+func escapeSequenceLen(text []byte) int {
+	if len(text) < 2 {
+		return len(text)
+	}
+
+	switch text[1] {
+	case '[':
+		for i := 2; i < len(text); i++ {
+			if text[i] >= 0x40 && text[i] <= 0x7e {
+				return i + 1
+			}
+		}
+	case ']':
+		for i := 2; i < len(text); i++ {
+			switch {
+			case text[i] == bel:
+				return i + 1
+			case text[i] == esc && i+1 < len(text) && text[i+1] == '\\':
+				return i + 2
+			}
+		}
+	default:
+		return 1
+	}
+
+	return len(text)
+}
+
+// ---
+
+const (
+	esc = 0x1b
+	bel = 0x07
+
+	zeroWidthJoiner = 0x200d
+)
+
+// ---
+
+type runeRange struct {
+	first rune
+	last  rune
+}
+
+var zeroWidthRanges = []runeRange{
+	{0x0300, 0x036f}, // combining diacritical marks
+	{0x0483, 0x0489},
+	{0x0591, 0x05bd},
+	{0x0610, 0x061a},
+	{0x064b, 0x065f},
+	{0x1ab0, 0x1aff},
+	{0x1dc0, 0x1dff},
+	{0x200b, 0x200f}, // zero width space, joiners and direction marks
+	{0x2028, 0x202e},
+	{0x2060, 0x2064},
+	{0x20d0, 0x20ff}, // combining marks for symbols
+	{0xfe00, 0xfe0f}, // variation selectors
+	{0xfe20, 0xfe2f},
+	{0xfeff, 0xfeff},
+	{0xe0100, 0xe01ef},
+}
+
+var emojiModifierRanges = []runeRange{
+	{0x1f3fb, 0x1f3ff}, // skin tone modifiers
+}
+
+var wideRanges = []runeRange{
+	{0x1100, 0x115f}, // hangul jamo
+	{0x231a, 0x231b},
+	{0x2329, 0x232a},
+	{0x23e9, 0x23ec},
+	{0x23f0, 0x23f0},
+	{0x23f3, 0x23f3},
+	{0x25fd, 0x25fe},
+	{0x2614, 0x2615},
+	{0x2648, 0x2653},
+	{0x267f, 0x267f},
+	{0x2693, 0x2693},
+	{0x26a1, 0x26a1},
+	{0x26aa, 0x26ab},
+	{0x26bd, 0x26be},
+	{0x26c4, 0x26c5},
+	{0x26ce, 0x26ce},
+	{0x26d4, 0x26d4},
+	{0x26ea, 0x26ea},
+	{0x26f2, 0x26f3},
+	{0x26f5, 0x26f5},
+	{0x26fa, 0x26fa},
+	{0x26fd, 0x26fd},
+	{0x2705, 0x2705},
+	{0x270a, 0x270b},
+	{0x2728, 0x2728},
+	{0x274c, 0x274c},
+	{0x274e, 0x274e},
+	{0x2753, 0x2755},
+	{0x2757, 0x2757},
+	{0x2795, 0x2797},
+	{0x27b0, 0x27b0},
+	{0x27bf, 0x27bf},
+	{0x2b1b, 0x2b1c},
+	{0x2b50, 0x2b50},
+	{0x2b55, 0x2b55},
+	{0x2e80, 0x303e}, // cjk radicals, symbols and punctuation
+	{0x3041, 0x33ff}, // hiragana, katakana, bopomofo, compatibility
+	{0x3400, 0x4dbf}, // cjk unified ideographs extension a
+	{0x4e00, 0x9fff}, // cjk unified ideographs
+	{0xa000, 0xa4cf}, // yi
+	{0xa960, 0xa97f},
+	{0xac00, 0xd7a3}, // hangul syllables
+	{0xf900, 0xfaff}, // cjk compatibility ideographs
+	{0xfe10, 0xfe19},
+	{0xfe30, 0xfe6f},
+	{0xff00, 0xff60}, // fullwidth forms
+	{0xffe0, 0xffe6},
+	{0x16fe0, 0x16fe4},
+	{0x17000, 0x18cff},
+	{0x1b000, 0x1b2ff},
+	{0x1f004, 0x1f004},
+	{0x1f0cf, 0x1f0cf},
+	{0x1f18e, 0x1f18e},
+	{0x1f191, 0x1f19a},
+	{0x1f200, 0x1f202},
+	{0x1f210, 0x1f23b},
+	{0x1f240, 0x1f248},
+	{0x1f250, 0x1f251},
+	{0x1f260, 0x1f265},
+	{0x1f300, 0x1f64f}, // emoji
+	{0x1f680, 0x1f6ff},
+	{0x1f7e0, 0x1f7eb},
+	{0x1f90c, 0x1f9ff},
+	{0x1fa70, 0x1faff},
+	{0x20000, 0x2fffd}, // cjk unified ideographs extensions
+	{0x30000, 0x3fffd},
+}
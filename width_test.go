@@ -0,0 +1,90 @@
+package logftxt
+
+import (
+	"testing"
+
+	"github.com/pamburus/go-tst/tst"
+)
+
+func TestDisplayWidth(tt *testing.T) {
+	t := tst.New(tt)
+
+	test := func(text string, expected int) func(tst.Test) {
+		return func(t tst.Test) {
+			t.Helper()
+			t.Expect(displayWidth([]byte(text))).ToEqual(expected)
+		}
+	}
+
+	t.Run("Empty", test("", 0))
+	t.Run("ASCII", test("abc", 3))
+	t.Run("UTF8", test("тест", 4))
+	t.Run("SGR", test("\x1b[1;31mabc\x1b[0m", 3))
+	t.Run("Hyperlink", test("\x1b]8;;file:///a\x1b\\abc\x1b]8;;\x07", 3))
+	t.Run("UnterminatedCSI", test("a\x1b[1", 1))
+	t.Run("UnterminatedOSC", test("a\x1b]8;;x", 1))
+	t.Run("LoneEscape", test("a\x1bb", 2))
+	t.Run("TrailingEscape", test("a\x1b", 1))
+}
+
+func TestDisplayWidthWide(tt *testing.T) {
+	t := tst.New(tt)
+
+	test := func(text string, expected int) func(tst.Test) {
+		return func(t tst.Test) {
+			t.Helper()
+			t.Expect(displayWidth([]byte(text))).ToEqual(expected)
+			t.Expect(stringWidth(text)).ToEqual(expected)
+		}
+	}
+
+	t.Run("CJK", test("日本語", 6))
+	t.Run("Hangul", test("한국어", 6))
+	t.Run("Fullwidth", test("ＡＢ", 4))
+	t.Run("Emoji", test("ok 🚀", 5))
+	t.Run("Combining", test("e\u0301", 1))
+	t.Run("VariationSelector", test("\u2764\ufe0f", 1))
+	t.Run("Mixed", test("a日b", 4))
+	t.Run("ZWJSequence", test("\U0001f468\u200d\U0001f469\u200d\U0001f467", 2))
+	t.Run("ZWJSequenceVariation", test("\U0001f3f3\ufe0f\u200d\U0001f308!", 3))
+	t.Run("SkinTone", test("\U0001f44d\U0001f3fd", 2))
+	t.Run("LoneSkinTone", test("a\U0001f3fd", 3))
+}
+
+func TestTruncateString(tt *testing.T) {
+	t := tst.New(tt)
+
+	test := func(text string, width int, expected string) func(tst.Test) {
+		return func(t tst.Test) {
+			t.Helper()
+			t.Expect(truncateString(text, width)).ToEqual(expected)
+		}
+	}
+
+	t.Run("Fits", test("abc", 3, "abc"))
+	t.Run("ASCII", test("abcdef", 3, "abc"))
+	t.Run("Zero", test("abc", 0, ""))
+	t.Run("Wide", test("日本語", 3, "日"))
+	t.Run("WideExact", test("日本語", 4, "日本"))
+	t.Run("Combining", test("e\u0301e\u0301", 1, "e\u0301"))
+	t.Run("ZWJSequence", test("\U0001f468\u200d\U0001f469\u200d\U0001f467\U0001f680", 3, "\U0001f468\u200d\U0001f469\u200d\U0001f467"))
+}
+
+func TestTruncateEscapedString(tt *testing.T) {
+	t := tst.New(tt)
+
+	test := func(text string, width int, expected string) func(tst.Test) {
+		return func(t tst.Test) {
+			t.Helper()
+			t.Expect(truncateEscapedString(text, width)).ToEqual(expected)
+		}
+	}
+
+	t.Run("Fits", test("a b", 3, "a b"))
+	t.Run("Negative", test("abc", -1, ""))
+	t.Run("Quote", test(`a"b`, 2, "a"))
+	t.Run("NewLine", test("a\nb", 3, "a\n"))
+	t.Run("Control", test("\x01\x02", 11, "\x01"))
+	t.Run("InvalidUTF8", test("\xffa", 6, "\xff"))
+	t.Run("Wide", test("日本語", 5, "日本"))
+}
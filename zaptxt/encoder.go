@@ -0,0 +1,160 @@
+// Package zaptxt provides zapcore.Encoder implementation that outputs log entries of go.uber.org/zap
+// in the same textual human-readable form as logftxt does it for logf.
+package zaptxt
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/ssgreg/logf"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/pamburus/logftxt"
+)
+
+// NewEncoder returns a new zapcore.Encoder that encodes entries using logftxt.Encoder
+// constructed with the given options, so that theme and configuration are shared with logf loggers.
+//
+// Zap levels dpanic and panic are mapped to [logftxt.LevelPanic], fatal is mapped to [logftxt.LevelFatal]
+// and levels below debug are mapped to [logftxt.LevelTrace], all of them are named with [logftxt.DefaultLevelNames],
+// so they are displayed using the theme's formatting for these levels if it has one
+// or as the nearest standard level otherwise.
+func NewEncoder(options ...logftxt.EncoderOption) zapcore.Encoder {
+	return newEncoder(logftxt.NewEncoder(append([]logftxt.EncoderOption{logftxt.DefaultLevelNames()}, options...)...))
+}
+
+// NewCore returns a new zapcore.Core that writes entries to w encoded the same way as logftxt.NewAppender does it.
+// Levels are mapped the same way as with [NewEncoder].
+func NewCore(w zapcore.WriteSyncer, enabler zapcore.LevelEnabler, options ...logftxt.AppenderOption) zapcore.Core {
+	enc := logftxt.NewWriterEncoder(w, append([]logftxt.AppenderOption{logftxt.DefaultLevelNames()}, options...)...)
+
+	return zapcore.NewCore(newEncoder(enc), w, enabler)
+}
+
+// ---
+
+type encoder struct {
+	fieldSet
+	enc      logf.Encoder
+	loggerID int32
+}
+
+func newEncoder(enc logf.Encoder) *encoder {
+	// Fields added to the encoder itself come from With, so they are snapshotted the same way as zap does it.
+	return &encoder{fieldSet: fieldSet{snapshot: true}, enc: enc, loggerID: nextLoggerID.Add(1)}
+}
+
+// Clone returns a copy of the encoder, fields added to the copy do not affect the original encoder.
+func (e *encoder) Clone() zapcore.Encoder {
+	return &encoder{e.fieldSet.clone(), e.enc, nextLoggerID.Add(1)}
+}
+
+// EncodeEntry encodes the entry with the fields added to the encoder followed by the given fields.
+func (e *encoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	entry := logf.Entry{
+		LoggerID:   e.loggerID,
+		LoggerName: ent.LoggerName,
+		Level:      zapLevelToLogf(ent.Level),
+		Time:       ent.Time,
+		Text:       ent.Message,
+	}
+
+	if ent.Caller.Defined {
+		entry.Caller = logf.EntryCaller{
+			PC:        ent.Caller.PC,
+			File:      ent.Caller.File,
+			Line:      ent.Caller.Line,
+			Specified: true,
+		}
+	}
+
+	if len(e.namespaces) == 0 {
+		// Fields added to the encoder are passed as logger's fields, so that logftxt can cache their encoded form.
+		entry.DerivedFields = e.fields
+
+		if len(fields) != 0 {
+			var fs fieldSet
+
+			fs.fields = make([]logf.Field, 0, len(fields))
+			fs.addFields(fields)
+			entry.Fields = fs.result()
+		}
+	} else {
+		// Fields go to the namespace opened by the encoder, so they cannot be separated from the fields of the encoder.
+		fs := e.fieldSet.clone()
+		fs.snapshot = false
+		fs.addFields(fields)
+		entry.Fields = fs.result()
+	}
+
+	if ent.Stack != "" {
+		entry.Fields = append(entry.Fields[:len(entry.Fields):len(entry.Fields)], logf.String(stacktraceKey, ent.Stack))
+	}
+
+	buf := getLogfBuffer()
+	defer putLogfBuffer(buf)
+
+	err := e.enc.Encode(buf, entry)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // error is passed as is from the encoder
+	}
+
+	result := bufferPool.Get()
+	result.AppendBytes(buf.Bytes())
+
+	return result, nil
+}
+
+// ---
+
+func zapLevelToLogf(level zapcore.Level) logf.Level {
+	switch {
+	case level >= zapcore.FatalLevel:
+		return logftxt.LevelFatal
+	case level >= zapcore.DPanicLevel:
+		return logftxt.LevelPanic
+	case level >= zapcore.ErrorLevel:
+		return logf.LevelError
+	case level >= zapcore.WarnLevel:
+		return logf.LevelWarn
+	case level >= zapcore.InfoLevel:
+		return logf.LevelInfo
+	case level >= zapcore.DebugLevel:
+		return logf.LevelDebug
+	default:
+		return logftxt.LevelTrace
+	}
+}
+
+// ---
+
+const stacktraceKey = "stacktrace"
+
+var (
+	nextLoggerID atomic.Int32
+	bufferPool   = buffer.NewPool()
+)
+
+// ---
+
+// getLogfBuffer returns an empty buffer from the pool.
+func getLogfBuffer() *logf.Buffer {
+	return logfBufferPool.Get().(*logf.Buffer) //nolint:forcetypeassert // pool contains only buffers
+}
+
+// putLogfBuffer resets the buffer and returns it to the pool.
+func putLogfBuffer(buf *logf.Buffer) {
+	buf.Reset()
+	logfBufferPool.Put(buf)
+}
+
+var logfBufferPool = sync.Pool{
+	New: func() any {
+		return logf.NewBufferWithCapacity(1024)
+	},
+}
+
+// ---
+
+var _ zapcore.Encoder = (*encoder)(nil)
@@ -0,0 +1,199 @@
+package zaptxt_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/pamburus/go-tst/tst"
+	"github.com/pamburus/logftxt"
+	"github.com/pamburus/logftxt/zaptxt"
+)
+
+func TestEncoder(tt *testing.T) {
+	t := tst.New(tt)
+
+	theme, err := logftxt.ReadTheme(strings.NewReader(strings.Join([]string{
+		"theme:",
+		"  version: '1.0'",
+		"  items: [level, logger, message, fields]",
+		"  formatting:",
+		"    level: {all: {outer: {prefix: '[', suffix: ']'}}, error: {text: ERR}, warning: {text: WRN}, info: {text: INF}, debug: {text: DBG}}",
+		"    logger: {outer: {suffix: ':'}}",
+		"    field: {separator: {text: '='}}",
+		"    types:",
+		"      array: {outer: {prefix: '[', suffix: ']'}, separator: {text: ' '}}",
+		"      object: {outer: {prefix: '{', suffix: '}'}, separator: {text: ' '}}",
+	}, "\n")))
+	t.Expect(err).ToNot(tst.HaveOccurred())
+
+	env := logftxt.Environment(func(string) (string, bool) { return "", false })
+
+	newLogger := func(options ...logftxt.EncoderOption) (*zap.Logger, *bytes.Buffer) {
+		buf := &bytes.Buffer{}
+		enc := zaptxt.NewEncoder(append([]logftxt.EncoderOption{env, &logftxt.Config{}, theme, logftxt.ColorNever}, options...)...)
+
+		return zap.New(zapcore.NewCore(enc, zapcore.AddSync(buf), zapcore.DebugLevel)), buf
+	}
+
+	t.Run("Levels", func(t tst.Test) {
+		logger, buf := newLogger()
+		logger.Debug("d")
+		logger.Info("i")
+		logger.Warn("w")
+		logger.Error("e")
+		t.Expect(buf.String()).ToEqual("[DBG] d\n[INF] i\n[WRN] w\n[ERR] e\n")
+	})
+
+	t.Run("Fields", func(t tst.Test) {
+		logger, buf := newLogger()
+		logger.Info("msg",
+			zap.String("s", "a b"),
+			zap.Int("i", 42),
+			zap.Bool("b", true),
+			zap.Float64("f", 1.5),
+			zap.Complex128("c", complex(1, 2)),
+			zap.ByteString("bs", []byte("text")),
+			zap.Duration("d", time.Second),
+			zap.Strings("ss", []string{"x", "y"}),
+			zap.Error(errors.New("failed")),
+		)
+		t.Expect(buf.String()).ToEqual(`[INF] msg s="a b" i=42 b=true f=1.5 c=1+2i bs=text d=00:00:01 ss=[x y] error=failed` + "\n")
+	})
+
+	t.Run("Marshalers", func(t tst.Test) {
+		logger, buf := newLogger(logftxt.FlattenObjects(false))
+		logger.Info("msg",
+			zap.Object("user", user{"alice", []string{"admin", "dev"}}),
+			zap.Array("users", users{{"bob", nil}, {"eve", []string{"ops"}}}),
+		)
+		t.Expect(buf.String()).ToEqual("[INF] msg user={name=alice roles=[admin dev]} users=[{name=bob} {name=eve roles=[ops]}]\n")
+	})
+
+	t.Run("Flattened", func(t tst.Test) {
+		logger, buf := newLogger()
+		logger.Info("msg", zap.Object("user", user{"alice", nil}))
+		t.Expect(buf.String()).ToEqual("[INF] msg user.name=alice\n")
+	})
+
+	t.Run("With", func(t tst.Test) {
+		logger, buf := newLogger()
+		child := logger.Named("db").With(zap.String("service", "api"))
+		other := child.With(zap.Int("shard", 1))
+		child.Info("one", zap.Int("n", 1))
+		other.Info("two")
+		logger.Info("three")
+		child.Info("four")
+		t.Expect(buf.String()).ToEqual(strings.Join([]string{
+			"[INF] db: one service=api n=1",
+			"[INF] db: two service=api shard=1",
+			"[INF] three",
+			"[INF] db: four service=api",
+			"",
+		}, "\n"))
+	})
+
+	t.Run("Namespace", func(t tst.Test) {
+		logger, buf := newLogger()
+		logger.With(zap.String("a", "x"), zap.Namespace("ns"), zap.String("b", "y")).Info("msg", zap.String("c", "z"))
+		logger.Info("msg", zap.Namespace("x"), zap.Namespace("y"), zap.Int("z", 1))
+		t.Expect(buf.String()).ToEqual("[INF] msg a=x ns.b=y ns.c=z\n[INF] msg x.y.z=1\n")
+	})
+
+	t.Run("Stack", func(t tst.Test) {
+		enc := zaptxt.NewEncoder(env, &logftxt.Config{}, theme, logftxt.ColorNever)
+		buf, err := enc.EncodeEntry(zapcore.Entry{Level: zapcore.FatalLevel, Message: "msg", Stack: "main.main"}, nil)
+		t.Expect(err).ToNot(tst.HaveOccurred())
+		t.Expect(buf.String()).ToEqual("[ERR] msg stacktrace=main.main\n")
+	})
+
+	t.Run("CustomLevels", func(t tst.Test) {
+		theme, err := logftxt.ReadTheme(strings.NewReader(strings.Join([]string{
+			"theme:",
+			"  version: '1.0'",
+			"  items: [level, message]",
+			"  formatting:",
+			"    level:",
+			"      all: {outer: {prefix: '[', suffix: ']'}}",
+			"      panic: {text: PNC}",
+			"      fatal: {text: FTL}",
+			"      error: {text: ERR}",
+			"      debug: {text: DBG}",
+			"      trace: {text: TRC}",
+		}, "\n")))
+		t.Expect(err).ToNot(tst.HaveOccurred())
+
+		enc := zaptxt.NewEncoder(env, &logftxt.Config{}, theme, logftxt.ColorNever)
+		result := make([]string, 0, 6)
+		for _, level := range []zapcore.Level{zapcore.DebugLevel - 1, zapcore.DebugLevel, zapcore.ErrorLevel, zapcore.DPanicLevel, zapcore.PanicLevel, zapcore.FatalLevel} {
+			buf, err := enc.EncodeEntry(zapcore.Entry{Level: level, Message: "msg"}, nil)
+			t.Expect(err).ToNot(tst.HaveOccurred())
+			result = append(result, buf.String())
+		}
+		t.Expect(strings.Join(result, "")).ToEqual("[TRC] msg\n[DBG] msg\n[ERR] msg\n[PNC] msg\n[PNC] msg\n[FTL] msg\n")
+	})
+
+	t.Run("WithSnapshot", func(t tst.Test) {
+		logger, buf := newLogger(logftxt.FlattenObjects(false))
+		u := &user{"alice", []string{"admin"}}
+		list := users{{"bob", nil}}
+		value := map[string]any{"k": "v", "n": 1}
+		child := logger.With(zap.Object("user", u), zap.Array("users", list), zap.Any("value", value))
+		u.name = "eve"
+		u.roles[0] = "guest"
+		list[0].name = "mallory"
+		value["k"] = "changed"
+		child.Info("msg", zap.Object("now", u))
+		t.Expect(buf.String()).ToEqual("[INF] msg user={name=alice roles=[admin]} users=[{name=bob}] value={k=v n=1} now={name=eve roles=[guest]}\n")
+	})
+
+	t.Run("Core", func(t tst.Test) {
+		buf := &bytes.Buffer{}
+		core := zaptxt.NewCore(zapcore.AddSync(buf), zapcore.InfoLevel, env, &logftxt.Config{}, theme, logftxt.ColorNever)
+		logger := zap.New(core)
+		logger.Debug("hidden")
+		logger.Info("shown")
+		t.Expect(buf.String()).ToEqual("[INF] shown\n")
+	})
+}
+
+// ---
+
+type user struct {
+	name  string
+	roles []string
+}
+
+func (u user) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", u.name)
+
+	if len(u.roles) != 0 {
+		return enc.AddArray("roles", zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+			for _, role := range u.roles {
+				enc.AppendString(role)
+			}
+
+			return nil
+		}))
+	}
+
+	return nil
+}
+
+type users []user
+
+func (u users) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, user := range u {
+		err := enc.AppendObject(user)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,530 @@
+package zaptxt
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ssgreg/logf"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/pamburus/logftxt/jsonlog"
+)
+
+// fieldSet collects fields added by zapcore.ObjectEncoder methods as logf fields.
+// If snapshot is set, marshalers and reflected values are serialized when they are added the same way as zap does it,
+// so that fields added with With do not change if the values are modified later.
+type fieldSet struct {
+	fields     []logf.Field
+	namespaces []namespace
+	snapshot   bool
+}
+
+// namespace is a key of an object containing all fields starting from the given index.
+type namespace struct {
+	key   string
+	start int
+}
+
+func (s *fieldSet) clone() fieldSet {
+	return fieldSet{
+		s.fields[:len(s.fields):len(s.fields)],
+		s.namespaces[:len(s.namespaces):len(s.namespaces)],
+		s.snapshot,
+	}
+}
+
+func (s *fieldSet) addFields(fields []zapcore.Field) {
+	for i := range fields {
+		s.addField(&fields[i])
+	}
+}
+
+func (s *fieldSet) addField(field *zapcore.Field) {
+	// Errors are passed as is to let logftxt format them as configured instead of zap's verbose and causes fields.
+	if field.Type == zapcore.ErrorType {
+		if err, ok := field.Interface.(error); ok {
+			s.add(logf.NamedError(field.Key, err))
+
+			return
+		}
+	}
+
+	field.AddTo(s)
+}
+
+func (s *fieldSet) add(field logf.Field) {
+	s.fields = append(s.fields, field)
+}
+
+// result returns the collected fields with fields of the opened namespaces wrapped into objects.
+func (s *fieldSet) result() []logf.Field {
+	fields := s.fields
+
+	for i := len(s.namespaces) - 1; i >= 0; i-- {
+		ns := s.namespaces[i]
+		inner := fieldsObject(fields[ns.start:len(fields):len(fields)])
+		fields = append(fields[:ns.start:ns.start], logf.Object(ns.key, inner))
+	}
+
+	return fields
+}
+
+func (s *fieldSet) AddArray(key string, marshaler zapcore.ArrayMarshaler) error {
+	if s.snapshot {
+		array, err := newArraySnapshot(marshaler)
+		s.add(logf.Array(key, array))
+
+		return err
+	}
+
+	s.add(logf.Array(key, arrayMarshaler{marshaler}))
+
+	return nil
+}
+
+func (s *fieldSet) AddObject(key string, marshaler zapcore.ObjectMarshaler) error {
+	if s.snapshot {
+		object, err := newObjectSnapshot(marshaler)
+		s.add(logf.Object(key, object))
+
+		return err
+	}
+
+	s.add(logf.Object(key, objectMarshaler{marshaler}))
+
+	return nil
+}
+
+func (s *fieldSet) AddBinary(key string, value []byte) {
+	s.add(logf.Bytes(key, bytes.Clone(value)))
+}
+
+func (s *fieldSet) AddByteString(key string, value []byte) {
+	s.add(logf.String(key, string(value)))
+}
+
+func (s *fieldSet) AddBool(key string, value bool) {
+	s.add(logf.Bool(key, value))
+}
+
+func (s *fieldSet) AddComplex128(key string, value complex128) {
+	s.add(logf.String(key, formatComplex(value, 64)))
+}
+
+func (s *fieldSet) AddComplex64(key string, value complex64) {
+	s.add(logf.String(key, formatComplex(complex128(value), 32)))
+}
+
+func (s *fieldSet) AddDuration(key string, value time.Duration) {
+	s.add(logf.Duration(key, value))
+}
+
+func (s *fieldSet) AddFloat64(key string, value float64) {
+	s.add(logf.Float64(key, value))
+}
+
+func (s *fieldSet) AddFloat32(key string, value float32) {
+	s.add(logf.Float32(key, value))
+}
+
+func (s *fieldSet) AddInt(key string, value int) {
+	s.add(logf.Int(key, value))
+}
+
+func (s *fieldSet) AddInt64(key string, value int64) {
+	s.add(logf.Int64(key, value))
+}
+
+func (s *fieldSet) AddInt32(key string, value int32) {
+	s.add(logf.Int32(key, value))
+}
+
+func (s *fieldSet) AddInt16(key string, value int16) {
+	s.add(logf.Int16(key, value))
+}
+
+func (s *fieldSet) AddInt8(key string, value int8) {
+	s.add(logf.Int8(key, value))
+}
+
+func (s *fieldSet) AddString(key, value string) {
+	s.add(logf.String(key, value))
+}
+
+func (s *fieldSet) AddTime(key string, value time.Time) {
+	s.add(logf.Time(key, value))
+}
+
+func (s *fieldSet) AddUint(key string, value uint) {
+	s.add(logf.Uint(key, value))
+}
+
+func (s *fieldSet) AddUint64(key string, value uint64) {
+	s.add(logf.Uint64(key, value))
+}
+
+func (s *fieldSet) AddUint32(key string, value uint32) {
+	s.add(logf.Uint32(key, value))
+}
+
+func (s *fieldSet) AddUint16(key string, value uint16) {
+	s.add(logf.Uint16(key, value))
+}
+
+func (s *fieldSet) AddUint8(key string, value uint8) {
+	s.add(logf.Uint8(key, value))
+}
+
+func (s *fieldSet) AddUintptr(key string, value uintptr) {
+	s.add(logf.Uint64(key, uint64(value)))
+}
+
+func (s *fieldSet) AddReflected(key string, value interface{}) error {
+	if s.snapshot {
+		value, err := newReflectedSnapshot(value)
+		if err != nil {
+			return err
+		}
+
+		s.add(jsonlog.Field(key, value))
+
+		return nil
+	}
+
+	s.add(logf.Any(key, value))
+
+	return nil
+}
+
+func (s *fieldSet) OpenNamespace(key string) {
+	s.namespaces = append(s.namespaces, namespace{key, len(s.fields)})
+}
+
+// ---
+
+// objectMarshaler makes zapcore.ObjectMarshaler usable as logf.ObjectEncoder.
+type objectMarshaler struct {
+	m zapcore.ObjectMarshaler
+}
+
+func (o objectMarshaler) EncodeLogfObject(enc logf.FieldEncoder) error {
+	var s fieldSet
+
+	err := o.m.MarshalLogObject(&s)
+
+	for _, field := range s.result() {
+		field.Accept(enc)
+	}
+
+	return err //nolint:wrapcheck // error is passed as is from the marshaler
+}
+
+// ---
+
+// arrayMarshaler makes zapcore.ArrayMarshaler usable as logf.ArrayEncoder.
+type arrayMarshaler struct {
+	m zapcore.ArrayMarshaler
+}
+
+func (a arrayMarshaler) EncodeLogfArray(enc logf.TypeEncoder) error {
+	return a.m.MarshalLogArray(arrayEncoder{enc}) //nolint:wrapcheck // error is passed as is from the marshaler
+}
+
+// ---
+
+// arrayEncoder passes elements appended by zapcore.ArrayMarshaler to logf.TypeEncoder.
+type arrayEncoder struct {
+	enc logf.TypeEncoder
+}
+
+func (a arrayEncoder) AppendBool(value bool) {
+	a.enc.EncodeTypeBool(value)
+}
+
+func (a arrayEncoder) AppendByteString(value []byte) {
+	a.enc.EncodeTypeString(string(value))
+}
+
+func (a arrayEncoder) AppendComplex128(value complex128) {
+	a.enc.EncodeTypeString(formatComplex(value, 64))
+}
+
+func (a arrayEncoder) AppendComplex64(value complex64) {
+	a.enc.EncodeTypeString(formatComplex(complex128(value), 32))
+}
+
+func (a arrayEncoder) AppendFloat64(value float64) {
+	a.enc.EncodeTypeFloat64(value)
+}
+
+func (a arrayEncoder) AppendFloat32(value float32) {
+	a.enc.EncodeTypeFloat32(value)
+}
+
+func (a arrayEncoder) AppendInt(value int) {
+	a.enc.EncodeTypeInt64(int64(value))
+}
+
+func (a arrayEncoder) AppendInt64(value int64) {
+	a.enc.EncodeTypeInt64(value)
+}
+
+func (a arrayEncoder) AppendInt32(value int32) {
+	a.enc.EncodeTypeInt32(value)
+}
+
+func (a arrayEncoder) AppendInt16(value int16) {
+	a.enc.EncodeTypeInt16(value)
+}
+
+func (a arrayEncoder) AppendInt8(value int8) {
+	a.enc.EncodeTypeInt8(value)
+}
+
+func (a arrayEncoder) AppendString(value string) {
+	a.enc.EncodeTypeString(value)
+}
+
+func (a arrayEncoder) AppendUint(value uint) {
+	a.enc.EncodeTypeUint64(uint64(value))
+}
+
+func (a arrayEncoder) AppendUint64(value uint64) {
+	a.enc.EncodeTypeUint64(value)
+}
+
+func (a arrayEncoder) AppendUint32(value uint32) {
+	a.enc.EncodeTypeUint32(value)
+}
+
+func (a arrayEncoder) AppendUint16(value uint16) {
+	a.enc.EncodeTypeUint16(value)
+}
+
+func (a arrayEncoder) AppendUint8(value uint8) {
+	a.enc.EncodeTypeUint8(value)
+}
+
+func (a arrayEncoder) AppendUintptr(value uintptr) {
+	a.enc.EncodeTypeUint64(uint64(value))
+}
+
+func (a arrayEncoder) AppendDuration(value time.Duration) {
+	a.enc.EncodeTypeDuration(value)
+}
+
+func (a arrayEncoder) AppendTime(value time.Time) {
+	a.enc.EncodeTypeTime(value)
+}
+
+func (a arrayEncoder) AppendArray(marshaler zapcore.ArrayMarshaler) error {
+	a.enc.EncodeTypeArray(arrayMarshaler{marshaler})
+
+	return nil
+}
+
+func (a arrayEncoder) AppendObject(marshaler zapcore.ObjectMarshaler) error {
+	a.enc.EncodeTypeObject(objectMarshaler{marshaler})
+
+	return nil
+}
+
+func (a arrayEncoder) AppendReflected(value interface{}) error {
+	a.enc.EncodeTypeAny(value)
+
+	return nil
+}
+
+// ---
+
+// newObjectSnapshot returns fields added by the marshaler at the moment.
+func newObjectSnapshot(marshaler zapcore.ObjectMarshaler) (fieldsObject, error) {
+	s := fieldSet{snapshot: true}
+
+	err := marshaler.MarshalLogObject(&s)
+
+	return fieldsObject(s.result()), err //nolint:wrapcheck // error is passed as is from the marshaler
+}
+
+// newArraySnapshot returns elements appended by the marshaler at the moment.
+func newArraySnapshot(marshaler zapcore.ArrayMarshaler) (arraySnapshot, error) {
+	var a arraySnapshot
+
+	err := marshaler.MarshalLogArray(&a)
+
+	return a, err //nolint:wrapcheck // error is passed as is from the marshaler
+}
+
+// newReflectedSnapshot serializes the value to JSON the same way as zap does it by default
+// and returns the result parsed back, see [jsonlog.ParseValue].
+func newReflectedSnapshot(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // error is passed as is from the marshaler
+	}
+
+	result, ok := jsonlog.ParseValue(data)
+	if !ok {
+		return string(data), nil
+	}
+
+	return result, nil
+}
+
+// ---
+
+// arraySnapshot is a zapcore.ArrayEncoder that records appended elements to encode them later as logf.ArrayEncoder.
+// Arrays, objects and reflected values are serialized when they are appended, see [fieldSet].
+type arraySnapshot []func(arrayEncoder)
+
+func (a arraySnapshot) EncodeLogfArray(enc logf.TypeEncoder) error {
+	for _, element := range a {
+		element(arrayEncoder{enc})
+	}
+
+	return nil
+}
+
+func (a *arraySnapshot) append(element func(arrayEncoder)) {
+	*a = append(*a, element)
+}
+
+func (a *arraySnapshot) AppendBool(value bool) {
+	a.append(func(e arrayEncoder) { e.AppendBool(value) })
+}
+
+func (a *arraySnapshot) AppendByteString(value []byte) {
+	text := string(value)
+	a.append(func(e arrayEncoder) { e.AppendString(text) })
+}
+
+func (a *arraySnapshot) AppendComplex128(value complex128) {
+	a.append(func(e arrayEncoder) { e.AppendComplex128(value) })
+}
+
+func (a *arraySnapshot) AppendComplex64(value complex64) {
+	a.append(func(e arrayEncoder) { e.AppendComplex64(value) })
+}
+
+func (a *arraySnapshot) AppendFloat64(value float64) {
+	a.append(func(e arrayEncoder) { e.AppendFloat64(value) })
+}
+
+func (a *arraySnapshot) AppendFloat32(value float32) {
+	a.append(func(e arrayEncoder) { e.AppendFloat32(value) })
+}
+
+func (a *arraySnapshot) AppendInt(value int) {
+	a.append(func(e arrayEncoder) { e.AppendInt(value) })
+}
+
+func (a *arraySnapshot) AppendInt64(value int64) {
+	a.append(func(e arrayEncoder) { e.AppendInt64(value) })
+}
+
+func (a *arraySnapshot) AppendInt32(value int32) {
+	a.append(func(e arrayEncoder) { e.AppendInt32(value) })
+}
+
+func (a *arraySnapshot) AppendInt16(value int16) {
+	a.append(func(e arrayEncoder) { e.AppendInt16(value) })
+}
+
+func (a *arraySnapshot) AppendInt8(value int8) {
+	a.append(func(e arrayEncoder) { e.AppendInt8(value) })
+}
+
+func (a *arraySnapshot) AppendString(value string) {
+	a.append(func(e arrayEncoder) { e.AppendString(value) })
+}
+
+func (a *arraySnapshot) AppendUint(value uint) {
+	a.append(func(e arrayEncoder) { e.AppendUint(value) })
+}
+
+func (a *arraySnapshot) AppendUint64(value uint64) {
+	a.append(func(e arrayEncoder) { e.AppendUint64(value) })
+}
+
+func (a *arraySnapshot) AppendUint32(value uint32) {
+	a.append(func(e arrayEncoder) { e.AppendUint32(value) })
+}
+
+func (a *arraySnapshot) AppendUint16(value uint16) {
+	a.append(func(e arrayEncoder) { e.AppendUint16(value) })
+}
+
+func (a *arraySnapshot) AppendUint8(value uint8) {
+	a.append(func(e arrayEncoder) { e.AppendUint8(value) })
+}
+
+func (a *arraySnapshot) AppendUintptr(value uintptr) {
+	a.append(func(e arrayEncoder) { e.AppendUintptr(value) })
+}
+
+func (a *arraySnapshot) AppendDuration(value time.Duration) {
+	a.append(func(e arrayEncoder) { e.AppendDuration(value) })
+}
+
+func (a *arraySnapshot) AppendTime(value time.Time) {
+	a.append(func(e arrayEncoder) { e.AppendTime(value) })
+}
+
+func (a *arraySnapshot) AppendArray(marshaler zapcore.ArrayMarshaler) error {
+	array, err := newArraySnapshot(marshaler)
+	a.append(func(e arrayEncoder) { e.enc.EncodeTypeArray(array) })
+
+	return err
+}
+
+func (a *arraySnapshot) AppendObject(marshaler zapcore.ObjectMarshaler) error {
+	object, err := newObjectSnapshot(marshaler)
+	a.append(func(e arrayEncoder) { e.enc.EncodeTypeObject(object) })
+
+	return err
+}
+
+func (a *arraySnapshot) AppendReflected(value interface{}) error {
+	value, err := newReflectedSnapshot(value)
+	if err != nil {
+		return err
+	}
+
+	a.append(func(e arrayEncoder) { _ = jsonlog.Array{value}.EncodeLogfArray(e.enc) })
+
+	return nil
+}
+
+// ---
+
+type fieldsObject []logf.Field
+
+func (o fieldsObject) EncodeLogfObject(enc logf.FieldEncoder) error {
+	for _, field := range o {
+		field.Accept(enc)
+	}
+
+	return nil
+}
+
+// ---
+
+// formatComplex formats the complex number the same way as zap does it, for example `1+2i`.
+func formatComplex(value complex128, bitSize int) string {
+	return strings.Trim(strconv.FormatComplex(value, 'g', -1, bitSize*2), "()")
+}
+
+// ---
+
+var (
+	_ zapcore.ObjectEncoder = (*fieldSet)(nil)
+	_ zapcore.ArrayEncoder  = arrayEncoder{}
+	_ logf.ObjectEncoder    = objectMarshaler{}
+	_ logf.ArrayEncoder     = arrayMarshaler{}
+	_ logf.ObjectEncoder    = fieldsObject(nil)
+	_ zapcore.ArrayEncoder  = (*arraySnapshot)(nil)
+	_ logf.ArrayEncoder     = arraySnapshot(nil)
+)
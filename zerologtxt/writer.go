@@ -0,0 +1,296 @@
+// Package zerologtxt provides io.Writer implementation that outputs JSON events of github.com/rs/zerolog
+// in the same textual human-readable form as logftxt does it for logf.
+package zerologtxt
+
+import (
+	"encoding/json"
+	"io"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/ssgreg/logf"
+
+	"github.com/pamburus/logftxt"
+	"github.com/pamburus/logftxt/jsonlog"
+)
+
+// NewWriter returns a new Writer that decodes JSON events written by zerolog.Logger and outputs them to w
+// the same way as logftxt.NewAppender does it, so that theme and configuration are shared with logf loggers.
+// It is intended to be used as a replacement of zerolog.ConsoleWriter, for example `zerolog.New(zerologtxt.NewWriter(os.Stderr))`.
+//
+// Well-known fields are recognized using zerolog's global settings, such as zerolog.TimestampFieldName,
+// zerolog.TimeFieldFormat, zerolog.LevelFieldName, zerolog.MessageFieldName, zerolog.CallerFieldName, zerolog.ErrorFieldName
+// and zerolog.ErrorStackFieldName.
+// Error field is output as an error, so that it is formatted as specified by the configuration.
+// Stack trace made by github.com/rs/zerolog/pkgerrors.MarshalStack becomes the stack trace of the error,
+// so that it is output as a block of frames with [logftxt.ErrorFormatTrace].
+// Numeric values of fields with keys listed in [DefaultDurationKeys] are output as durations, see [Writer.WithDurationKeys].
+// Zerolog levels trace, fatal and panic are mapped to [logftxt.LevelTrace], [logftxt.LevelFatal] and [logftxt.LevelPanic]
+// named with [logftxt.DefaultLevelNames], so they are displayed using the theme's formatting for these levels if it has one
+// or as the nearest standard level otherwise.
+//
+// Lines that are not JSON objects are passed to w unchanged.
+func NewWriter(w io.Writer, options ...logftxt.AppenderOption) *Writer {
+	return &Writer{
+		w:            w,
+		enc:          logftxt.NewWriterEncoder(w, append([]logftxt.AppenderOption{logftxt.DefaultLevelNames()}, options...)...),
+		durationKeys: DefaultDurationKeys(),
+	}
+}
+
+// DefaultDurationKeys returns keys of fields that are conventionally used for durations,
+// such as `duration`, `elapsed` and `latency`, so that [NewWriter] outputs their numeric values as durations.
+func DefaultDurationKeys() []string {
+	return slices.Clone(defaultDurationKeys)
+}
+
+// Writer is an io.Writer that converts JSON events of zerolog to text, see [NewWriter].
+type Writer struct {
+	w            io.Writer
+	enc          logf.Encoder
+	durationKeys []string
+}
+
+// WithDurationKeys returns a copy of the Writer that outputs numeric values of fields with the given keys as durations
+// measured in zerolog.DurationFieldUnit, so that they are formatted as specified by the configuration.
+// Zerolog encodes durations as plain numbers, so they cannot be recognized otherwise.
+// The keys are added to the ones the Writer already has, which are [DefaultDurationKeys] initially.
+func (w *Writer) WithDurationKeys(keys ...string) *Writer {
+	result := *w
+	result.durationKeys = slices.Concat(w.durationKeys, keys)
+
+	return &result
+}
+
+// Write converts a single JSON event to text and writes it to the underlying writer.
+func (w *Writer) Write(p []byte) (int, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	entry, ok := w.parseEntry(p)
+	if ok {
+		err := w.enc.Encode(buf, entry)
+		if err != nil {
+			return 0, err //nolint:wrapcheck // error is passed as is from the encoder
+		}
+	} else {
+		buf.AppendBytes(p)
+	}
+
+	_, err := w.w.Write(buf.Bytes())
+	if err != nil {
+		return 0, err //nolint:wrapcheck // error is passed as is from the writer
+	}
+
+	return len(p), nil
+}
+
+// parseEntry parses a JSON event into a log entry.
+// Well-known keys are mapped to the corresponding entry attributes and all other keys
+// become fields in the same order as they appear in the event.
+func (w *Writer) parseEntry(p []byte) (logf.Entry, bool) {
+	object, ok := jsonlog.Parse(p)
+	if !ok {
+		return logf.Entry{}, false
+	}
+
+	// Field names are read on each call because zerolog allows to change them at any time.
+	schema := jsonlog.Schema{
+		TimeKey:    zerolog.TimestampFieldName,
+		LevelKey:   zerolog.LevelFieldName,
+		MessageKey: zerolog.MessageFieldName,
+		CallerKey:  zerolog.CallerFieldName,
+		ErrorKey:   zerolog.ErrorFieldName,
+		StackKey:   zerolog.ErrorStackFieldName,
+		ParseTime:  parseTime,
+		ParseLevel: parseLevel,
+		ParseStack: parseStack,
+	}
+
+	return schema.Entry(object, w.field), true
+}
+
+func (w *Writer) field(member jsonlog.Member) logf.Field {
+	if slices.Contains(w.durationKeys, member.Key) {
+		if field, ok := durationField(member.Key, member.Value); ok {
+			return field
+		}
+	}
+
+	return jsonlog.Field(member.Key, member.Value)
+}
+
+// ---
+
+// parseTime parses the timestamp encoded as specified by zerolog.TimeFieldFormat.
+func parseTime(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case string:
+		ts, err := time.Parse(zerolog.TimeFieldFormat, v)
+		if err != nil {
+			return jsonlog.ParseTime(v)
+		}
+
+		return ts, true
+	case json.Number:
+		n, err := v.Int64()
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		switch zerolog.TimeFieldFormat {
+		case zerolog.TimeFormatUnixMs:
+			return time.UnixMilli(n), true
+		case zerolog.TimeFormatUnixMicro:
+			return time.UnixMicro(n), true
+		case zerolog.TimeFormatUnixNano:
+			return time.Unix(0, n), true
+		default:
+			return time.Unix(n, 0), true
+		}
+	default:
+		return time.Time{}, false
+	}
+}
+
+func parseLevel(s string) (logf.Level, bool) {
+	level, err := zerolog.ParseLevel(s)
+	if err != nil {
+		return 0, false
+	}
+
+	return zerologLevelToLogf(level), true
+}
+
+// parseStack parses the stack trace encoded by github.com/rs/zerolog/pkgerrors.MarshalStack,
+// which is an array of objects having function name, source file name and line number.
+func parseStack(value any) ([]runtime.Frame, bool) {
+	array, ok := value.(jsonlog.Array)
+	if !ok {
+		return nil, false
+	}
+
+	frames := make([]runtime.Frame, len(array))
+	for i, item := range array {
+		object, ok := item.(jsonlog.Object)
+		if !ok {
+			return nil, false
+		}
+
+		for _, member := range object {
+			s, ok := member.Value.(string)
+			if !ok {
+				return nil, false
+			}
+
+			switch member.Key {
+			case stackFunctionKey:
+				frames[i].Function = s
+			case stackSourceKey:
+				frames[i].File = s
+			case stackLineKey:
+				frames[i].Line, _ = strconv.Atoi(s)
+			}
+		}
+	}
+
+	return frames, true
+}
+
+func durationField(key string, value any) (logf.Field, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		d, ok := parseDuration(v)
+
+		return logf.Duration(key, d), ok
+	case jsonlog.Array:
+		durations := make([]time.Duration, len(v))
+		for i, item := range v {
+			n, ok := item.(json.Number)
+			if !ok {
+				return logf.Field{}, false
+			}
+
+			durations[i], ok = parseDuration(n)
+			if !ok {
+				return logf.Field{}, false
+			}
+		}
+
+		return logf.Durations(key, durations), true
+	default:
+		return logf.Field{}, false
+	}
+}
+
+// parseDuration parses the duration encoded as a number of zerolog.DurationFieldUnit.
+func parseDuration(n json.Number) (time.Duration, bool) {
+	f, err := n.Float64()
+	if err != nil {
+		return 0, false
+	}
+
+	return time.Duration(f * float64(zerolog.DurationFieldUnit)), true
+}
+
+func zerologLevelToLogf(level zerolog.Level) logf.Level {
+	switch level {
+	case zerolog.PanicLevel:
+		return logftxt.LevelPanic
+	case zerolog.FatalLevel:
+		return logftxt.LevelFatal
+	case zerolog.ErrorLevel:
+		return logf.LevelError
+	case zerolog.WarnLevel:
+		return logf.LevelWarn
+	case zerolog.InfoLevel, zerolog.NoLevel:
+		return logf.LevelInfo
+	case zerolog.DebugLevel:
+		return logf.LevelDebug
+	default:
+		if level > zerolog.PanicLevel {
+			return logftxt.LevelPanic
+		}
+
+		return logftxt.LevelTrace
+	}
+}
+
+// ---
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *logf.Buffer {
+	return bufferPool.Get().(*logf.Buffer) //nolint:forcetypeassert // pool contains only buffers
+}
+
+// putBuffer resets the buffer and returns it to the pool.
+func putBuffer(buf *logf.Buffer) {
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+var bufferPool = sync.Pool{
+	New: func() any {
+		return logf.NewBufferWithCapacity(1024)
+	},
+}
+
+// ---
+
+var defaultDurationKeys = []string{"dur", "duration", "elapsed", "latency"}
+
+// Keys of stack trace frames used by github.com/rs/zerolog/pkgerrors.MarshalStack.
+// They are not taken from the package to avoid dependency on github.com/pkg/errors.
+const (
+	stackFunctionKey = "func"
+	stackSourceKey   = "source"
+	stackLineKey     = "line"
+)
+
+// ---
+
+var _ io.Writer = (*Writer)(nil)
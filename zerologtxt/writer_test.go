@@ -0,0 +1,158 @@
+package zerologtxt_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/pamburus/go-tst/tst"
+	"github.com/pamburus/logftxt"
+	"github.com/pamburus/logftxt/zerologtxt"
+)
+
+func TestWriter(tt *testing.T) {
+	t := tst.New(tt)
+
+	theme, err := logftxt.ReadTheme(strings.NewReader(strings.Join([]string{
+		"theme:",
+		"  version: '1.0'",
+		"  items: [level, message, fields, caller]",
+		"  formatting:",
+		"    level:",
+		"      all: {outer: {prefix: '[', suffix: ']'}}",
+		"      panic: {text: PNC}",
+		"      fatal: {text: FTL}",
+		"      error: {text: ERR}",
+		"      warning: {text: WRN}",
+		"      info: {text: INF}",
+		"      debug: {text: DBG}",
+		"      trace: {text: TRC}",
+		"    field: {separator: {text: '='}}",
+		"    caller: {outer: {prefix: '@ '}}",
+		"    types:",
+		"      array: {outer: {prefix: '[', suffix: ']'}, separator: {text: ','}}",
+		"      object: {outer: {prefix: '{', suffix: '}'}, separator: {text: ' '}}",
+	}, "\n")))
+	t.Expect(err).ToNot(tst.HaveOccurred())
+
+	env := logftxt.Environment(func(string) (string, bool) { return "", false })
+
+	newWriter := func(options ...logftxt.AppenderOption) (*zerologtxt.Writer, *bytes.Buffer) {
+		buf := &bytes.Buffer{}
+
+		return zerologtxt.NewWriter(buf, append([]logftxt.AppenderOption{env, &logftxt.Config{}, theme, logftxt.ColorNever}, options...)...), buf
+	}
+
+	t.Run("Levels", func(t tst.Test) {
+		w, buf := newWriter()
+		logger := zerolog.New(w).Level(zerolog.TraceLevel)
+		logger.Trace().Msg("t")
+		logger.Debug().Msg("d")
+		logger.Info().Msg("i")
+		logger.Warn().Msg("w")
+		logger.Error().Msg("e")
+		logger.WithLevel(zerolog.FatalLevel).Msg("f")
+		logger.WithLevel(zerolog.PanicLevel).Msg("p")
+		logger.Log().Msg("n")
+		t.Expect(buf.String()).ToEqual("[TRC] t\n[DBG] d\n[INF] i\n[WRN] w\n[ERR] e\n[FTL] f\n[PNC] p\n[INF] n\n")
+	})
+
+	t.Run("Fields", func(t tst.Test) {
+		w, buf := newWriter(logftxt.FlattenObjects(false))
+		logger := zerolog.New(w)
+		logger.Info().
+			Str("b", "x y").
+			Int("a", 42).
+			Bool("c", true).
+			Float64("f", 1.5).
+			Ints("ints", []int{1, 2}).
+			Dict("user", zerolog.Dict().Str("name", "alice")).
+			Err(errors.New("failed")).
+			Msg("msg")
+		t.Expect(buf.String()).ToEqual(`[INF] msg b="x y" a=42 c=true f=1.5 ints=[1,2] user={name=alice} error=failed` + "\n")
+	})
+
+	t.Run("Context", func(t tst.Test) {
+		w, buf := newWriter()
+		logger := zerolog.New(w).With().Str("service", "api").Logger()
+		logger.Info().Int("n", 1).Msg("msg")
+		t.Expect(buf.String()).ToEqual("[INF] msg service=api n=1\n")
+	})
+
+	t.Run("Durations", func(t tst.Test) {
+		w, buf := newWriter()
+		logger := zerolog.New(w.WithDurationKeys("elapsed", "steps"))
+		logger.Info().
+			Dur("elapsed", 90*time.Second).
+			Durs("steps", []time.Duration{time.Second, time.Minute}).
+			Dur("other", time.Second).
+			Msg("msg")
+		t.Expect(buf.String()).ToEqual("[INF] msg elapsed=00:01:30 steps=[00:00:01,00:01:00] other=1000\n")
+	})
+
+	t.Run("Caller", func(t tst.Test) {
+		w, buf := newWriter()
+		logger := zerolog.New(w).With().Caller().Logger()
+		logger.Info().Msg("msg")
+		t.Expect(buf.String()).ToEqual("[INF] msg @ zerologtxt/writer_test.go:100\n")
+	})
+
+	t.Run("DefaultDurations", func(t tst.Test) {
+		w, buf := newWriter()
+		logger := zerolog.New(w)
+		logger.Info().Dur("elapsed", 90*time.Second).Dur("latency", time.Second).Msg("msg")
+		t.Expect(buf.String()).ToEqual("[INF] msg elapsed=00:01:30 latency=00:00:01\n")
+	})
+
+	t.Run("Stack", func(t tst.Test) {
+		marshaler := zerolog.ErrorStackMarshaler
+		defer func() { zerolog.ErrorStackMarshaler = marshaler }()
+
+		zerolog.ErrorStackMarshaler = func(error) any {
+			return []map[string]string{
+				{"func": "serve", "source": "server.go", "line": "57"},
+				{"func": "main", "source": "main.go", "line": "23"},
+			}
+		}
+
+		w, buf := newWriter(logftxt.ErrorFormatTrace)
+		logger := zerolog.New(w)
+		logger.Error().Stack().Err(errors.New("failed")).Msg("msg")
+		_, err := w.Write([]byte(`{"level":"error","stack":[{"func":"main","source":"main.go","line":"23"}],"a":"x","message":"msg"}`))
+		t.Expect(err).ToNot(tst.HaveOccurred())
+		t.Expect(buf.String()).ToEqual(
+			"[ERR] msg error=failed\n" +
+				"  | error=\n" +
+				"  |   serve server.go:57\n" +
+				"  |   main main.go:23\n" +
+				"[ERR] msg a=x stack=[{func=main source=main.go line=\"23\"}]\n",
+		)
+	})
+
+	t.Run("Time", func(t tst.Test) {
+		theme, err := logftxt.ReadTheme(strings.NewReader(strings.Join([]string{
+			"theme:",
+			"  version: '1.0'",
+			"  items: [timestamp, message]",
+		}, "\n")))
+		t.Expect(err).ToNot(tst.HaveOccurred())
+
+		w, buf := newWriter(theme, logftxt.TimeLayout(time.RFC3339).Timestamp())
+		ts := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+		logger := zerolog.New(w)
+		logger.Info().Time(zerolog.TimestampFieldName, ts).Msg("msg")
+		t.Expect(buf.String()).ToEqual("2024-05-06T07:08:09Z msg\n")
+	})
+
+	t.Run("NotJSON", func(t tst.Test) {
+		w, buf := newWriter()
+		n, err := w.Write([]byte("plain text\n"))
+		t.Expect(err).ToNot(tst.HaveOccurred())
+		t.Expect(n).ToEqual(11)
+		t.Expect(buf.String()).ToEqual("plain text\n")
+	})
+}